@@ -0,0 +1,165 @@
+// Package apikey manages the api keys users authenticate with in the api-key header.
+//
+// A user can have multiple named keys. Keys are random tokens that are only shown once when they are created or rotated,
+// only their SHA-256 hash is stored. Every key can be limited to a set of scopes, a scope is a permission in the format
+// <list>.<action>, for example aredl.user_submit or global.user_request_api_key. A key without scopes has every permission of its user.
+//
+// Keys can be marked as trusted by an admin, trusted keys get higher rate limits.
+package apikey
+
+import (
+	"AREDL/names"
+	"AREDL/util"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/tools/list"
+	"github.com/pocketbase/pocketbase/tools/types"
+	"strings"
+	"time"
+)
+
+const (
+	keyPrefix = "aredl_"
+	// keyBytes is the number of random bytes of a key
+	keyBytes = 32
+	// displayLength is the number of characters of a key that are stored to recognize it
+	displayLength = len(keyPrefix) + 6
+	// lastUsedInterval is the minimum time between two updates of the last usage of a key
+	lastUsedInterval = time.Minute
+)
+
+var (
+	ErrNotFound = errors.New("api key not found")
+	ErrExpired  = errors.New("api key expired")
+)
+
+// generate creates a new random key and returns it together with its hash
+func generate() (string, string, error) {
+	data := make([]byte, keyBytes)
+	_, err := rand.Read(data)
+	if err != nil {
+		return "", "", err
+	}
+	key := keyPrefix + hex.EncodeToString(data)
+	return key, hash(key), nil
+}
+
+func hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Create adds a new key for the user and returns the key record together with the key itself
+func Create(dao *daos.Dao, app core.App, userId string, name string, scopes []string, expires types.DateTime) (*models.Record, string, error) {
+	key, keyHash, err := generate()
+	if err != nil {
+		return nil, "", util.NewErrorResponse(err, "Failed to generate api key")
+	}
+	record, err := util.AddRecordByCollectionName(dao, app, names.TableApiKeys, map[string]any{
+		"user":     userId,
+		"name":     name,
+		"key_hash": keyHash,
+		"prefix":   key[:displayLength],
+		"scopes":   scopes,
+		"expires":  expires,
+	})
+	if err != nil {
+		return nil, "", util.NewErrorResponse(err, "Failed to create api key")
+	}
+	return record, key, nil
+}
+
+// Rotate replaces the key of the record with a new one and returns it. Name, scopes and expiry stay the same
+func Rotate(dao *daos.Dao, record *models.Record) (string, error) {
+	key, keyHash, err := generate()
+	if err != nil {
+		return "", util.NewErrorResponse(err, "Failed to generate api key")
+	}
+	record.Set("key_hash", keyHash)
+	record.Set("prefix", key[:displayLength])
+	record.Set("last_used", "")
+	err = dao.SaveRecord(record)
+	if err != nil {
+		return "", util.NewErrorResponse(err, "Failed to rotate api key")
+	}
+	return key, nil
+}
+
+// Authenticate finds the user of the key and returns it together with the scopes of the key.
+// Expired keys are rejected. The last usage of the key is updated at most once per minute
+func Authenticate(dao *daos.Dao, key string) (*models.Record, []string, error) {
+	record, err := Find(dao, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	expires := record.GetDateTime("expires")
+	if !expires.IsZero() && !expires.Time().After(now) {
+		return nil, nil, ErrExpired
+	}
+	user, err := dao.FindRecordById(names.TableUsers, record.GetString("user"))
+	if err != nil {
+		return nil, nil, err
+	}
+	if lastUsed := record.GetDateTime("last_used"); lastUsed.IsZero() || now.Sub(lastUsed.Time()) >= lastUsedInterval {
+		// written directly so the usage doesn't change the updated date of the key
+		_, err = dao.DB().Update(names.TableApiKeys, dbx.Params{"last_used": types.NowDateTime()}, dbx.HashExp{"id": record.Id}).Execute()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return user, Scopes(record), nil
+}
+
+// ValidFormat reports whether the key has the format of generated keys, the prefix followed by the hex encoded random bytes
+func ValidFormat(key string) bool {
+	if len(key) != len(keyPrefix)+hex.EncodedLen(keyBytes) || !strings.HasPrefix(key, keyPrefix) {
+		return false
+	}
+	_, err := hex.DecodeString(key[len(keyPrefix):])
+	return err == nil
+}
+
+// Find returns the record of the key. Keys that don't have the format of generated keys are not looked up
+func Find(dao *daos.Dao, key string) (*models.Record, error) {
+	if !ValidFormat(key) {
+		return nil, ErrNotFound
+	}
+	records, err := dao.FindRecordsByExpr(names.TableApiKeys, dbx.HashExp{"key_hash": hash(key)})
+	if err != nil {
+		return nil, err
+	}
+	if len(records) != 1 {
+		return nil, ErrNotFound
+	}
+	return records[0], nil
+}
+
+// Scopes returns the scopes of the key record
+func Scopes(record *models.Record) []string {
+	var scopes []string
+	_ = record.UnmarshalJSONField("scopes", &scopes)
+	return scopes
+}
+
+// Scope returns the scope of a permission. Global permissions use global as list name
+func Scope(listName string, action string) string {
+	if listName == "" {
+		listName = "global"
+	}
+	return listName + "." + action
+}
+
+// Allows checks if a key with the given scopes can use the permission. Keys without scopes can use every permission
+func Allows(scopes []string, listName string, action string) bool {
+	if len(scopes) == 0 {
+		return true
+	}
+	return list.ExistInSlice(Scope(listName, action), scopes)
+}
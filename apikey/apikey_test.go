@@ -0,0 +1,26 @@
+package apikey
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidFormat(t *testing.T) {
+	valid := "aredl_" + strings.Repeat("0123456789abcdef", 4)
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{key: valid, want: true},
+		{key: strings.ToUpper(valid[:6]) + valid[6:], want: false},
+		{key: valid[:len(valid)-1], want: false},
+		{key: valid + "0", want: false},
+		{key: valid[:len(valid)-1] + "g", want: false},
+		{key: "", want: false},
+	}
+	for _, test := range tests {
+		if got := ValidFormat(test.key); got != test.want {
+			t.Errorf("ValidFormat(%q) = %v, want %v", test.key, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package audit
+
+import (
+	"AREDL/names"
+	"AREDL/util"
+	"encoding/json"
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/models"
+)
+
+// hiddenFields are never written into a snapshot
+var hiddenFields = []string{"key_hash", "expand"}
+
+// Entry describes a single mutating action.
+// List is empty for global actions. Before and After are snapshots of the target, they are nil if the target did not
+// exist before or after the action
+type Entry struct {
+	List        string
+	Action      string
+	TargetTable string
+	TargetId    string
+	Before      any
+	After       any
+}
+
+// Log writes the entry into the audit log using the user or admin that is authenticated in the given context as actor.
+// It should be called with the same dao as the action itself, so the entry gets rolled back together with it.
+// The context is nil for actions that are done by the server itself, like scheduled jobs
+func Log(dao *daos.Dao, c echo.Context, entry Entry) error {
+	params := dbx.Params{
+		"list":         entry.List,
+		"action":       entry.Action,
+		"target_table": entry.TargetTable,
+		"target_id":    entry.TargetId,
+	}
+	if c != nil {
+		if userRecord, _ := c.Get(apis.ContextAuthRecordKey).(*models.Record); userRecord != nil {
+			params["actor"] = userRecord.Id
+		}
+		if admin, _ := c.Get(apis.ContextAdminKey).(*models.Admin); admin != nil {
+			params["actor_admin"] = admin.Id
+		}
+	}
+	for key, value := range map[string]any{"before": entry.Before, "after": entry.After} {
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return util.NewErrorResponse(err, "Failed to encode audit snapshot")
+		}
+		params[key] = string(encoded)
+	}
+	_, err := dao.DB().Insert(names.TableAuditLog, params).Execute()
+	if err != nil {
+		return util.NewErrorResponse(err, "Failed to write audit log")
+	}
+	return nil
+}
+
+// Snapshot exports the current state of the record for an audit entry. A nil record results in a nil snapshot
+func Snapshot(record *models.Record) map[string]any {
+	if record == nil {
+		return nil
+	}
+	snapshot := record.PublicExport()
+	for _, field := range hiddenFields {
+		delete(snapshot, field)
+	}
+	return snapshot
+}
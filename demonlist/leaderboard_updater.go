@@ -4,7 +4,9 @@ import (
 	"AREDL/names"
 	"fmt"
 	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
 	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/models"
 )
 
 func updateLeaderboardByLevelRange(dao *daos.Dao, listData ListData, minPos int, maxPos int) error {
@@ -35,28 +37,35 @@ func updateLeaderboardByPackId(dao *daos.Dao, listData ListData, packId string)
 
 func updateLeaderboard(dao *daos.Dao, listData ListData, condition string, params dbx.Params) error {
 	err := dao.RunInTransaction(func(txDao *daos.Dao) error {
-		_, err := txDao.DB().NewQuery(fmt.Sprintf(`
-			INSERT INTO %s (user, points) 
-			SELECT u.id as user, (
+		listData, err := loadPointSettings(txDao, listData)
+		if err != nil {
+			return err
+		}
+		_, err = txDao.DB().NewQuery(fmt.Sprintf(`
+			INSERT INTO %s (user, country, points) 
+			SELECT u.id as user, COALESCE(u.country, '') as country, (
 				ROUND(
     			(
-    				SELECT ROUND(COALESCE(SUM(l.points), 0), 1)
+    				SELECT ROUND(COALESCE(SUM(CASE WHEN rs.percentage < 100 THEN rs.points ELSE l.points END), 0), %d)
     				FROM %s rs, %s l
     				WHERE u.id = rs.submitted_by AND rs.level = l.id
     			) + (
-    				SELECT ROUND(COALESCE(SUM(p.points), 0), 1)
+    				SELECT ROUND(COALESCE(SUM(p.points), 0), %d)
     				FROM %s cp, %s p
     				WHERE u.id = cp.user AND cp.pack = p.id
-    			), 1)
+    			), %d)
 			) as points 
 			FROM %s u 
 			%s 
-			ON CONFLICT DO UPDATE SET points = excluded.points`,
+			ON CONFLICT DO UPDATE SET points = excluded.points, country = excluded.country`,
 			listData.LeaderboardTableName,
+			listData.PointPrecision,
 			listData.RecordsTableName,
 			listData.LevelTableName,
+			listData.PointPrecision,
 			listData.Packs.CompletedPacksTableName,
 			listData.Packs.PackTableName,
+			listData.PointPrecision,
 			names.TableUsers,
 			condition)).Bind(params).Execute()
 		if err != nil {
@@ -82,28 +91,73 @@ func updateLeaderboard(dao *daos.Dao, listData ListData, condition string, param
 	return err
 }
 
+// updateLeaderboardRanks ranks all players globally and within their country and rebuilds the country leaderboard.
+// Players without a country have a country rank of 0
 func updateLeaderboardRanks(dao *daos.Dao, listData ListData) error {
-	_, err := dao.DB().NewQuery(fmt.Sprintf(`
+	listData, err := loadPointSettings(dao, listData)
+	if err != nil {
+		return err
+	}
+	_, err = dao.DB().NewQuery(fmt.Sprintf(`
 		WITH ranking AS (
-			SELECT user, RANK() OVER (ORDER BY points DESC) AS position 
+			SELECT user, 
+				RANK() OVER (ORDER BY points DESC) AS position,
+				CASE WHEN country = '' THEN 0 ELSE RANK() OVER (PARTITION BY country ORDER BY points DESC) END AS country_position
 			FROM %s
 		)
 		UPDATE %s 
-		SET rank = position
+		SET rank = position, country_rank = country_position
 		FROM ranking
 		WHERE ranking.user = %s.user`,
 		listData.LeaderboardTableName,
 		listData.LeaderboardTableName,
 		listData.LeaderboardTableName)).Execute()
+	if err != nil {
+		return err
+	}
+	_, err = dao.DB().Delete(listData.CountryLeaderboardTableName, nil).Execute()
+	if err != nil {
+		return err
+	}
+	_, err = dao.DB().NewQuery(fmt.Sprintf(`
+		INSERT INTO %s (country, points, players, rank)
+		SELECT country, ROUND(SUM(points), %d), COUNT(*), RANK() OVER (ORDER BY SUM(points) DESC)
+		FROM %s
+		WHERE country <> ''
+		GROUP BY country`,
+		listData.CountryLeaderboardTableName,
+		listData.PointPrecision,
+		listData.LeaderboardTableName)).Execute()
 	return err
 }
 
+// RegisterLeaderboardCountries moves players to their new country on the leaderboards of every list when they change it
+func RegisterLeaderboardCountries(app core.App) {
+	app.OnModelAfterUpdate(names.TableUsers).Add(func(e *core.ModelEvent) error {
+		record, ok := e.Model.(*models.Record)
+		if !ok || record.GetString("country") == record.OriginalCopy().GetString("country") {
+			return nil
+		}
+		for _, listData := range Lists() {
+			err := UpdateLeaderboardByUserIds(app.Dao(), listData, []interface{}{record.Id})
+			if err != nil {
+				app.Logger().Error("Failed to update leaderboard country", "list", listData.Name, "user", record.Id, "error", err)
+			}
+		}
+		return nil
+	})
+}
+
 func UpdateLeaderboardAndPacksForUser(dao *daos.Dao, listData ListData, userId string) error {
 	err := dao.RunInTransaction(func(txDao *daos.Dao) error {
 		err := updateCompletedPacksByUser(txDao, listData, userId)
 		if err != nil {
 			return err
 		}
+		err = updateProgressPointsByUser(txDao, listData, userId)
+		if err != nil {
+			return err
+		}
 		return UpdateLeaderboardByUserIds(txDao, listData, []interface{}{userId})
 	})
 	return err
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"github.com/pocketbase/pocketbase/core"
 	"github.com/pocketbase/pocketbase/tools/cron"
+	"github.com/pocketbase/pocketbase/tools/list"
 	"io"
 	"math/rand"
 	"net/http"
@@ -101,7 +102,13 @@ func RequestLevelData(app core.App) func() {
 }
 
 func getMissingLevelIds(app core.App) ([]string, error) {
-	query := app.Dao().DB().NewQuery(fmt.Sprintf(`
+	type LevelData struct {
+		LevelId string `db:"level_id"`
+	}
+
+	var stringIds []string
+	for _, listData := range Lists() {
+		query := app.Dao().DB().NewQuery(fmt.Sprintf(`
 			SELECT level_id
 			FROM %v level 
 			WHERE NOT EXISTS (
@@ -109,20 +116,17 @@ func getMissingLevelIds(app core.App) ([]string, error) {
 				FROM %v level_info
 				WHERE level.level_id == level_info.level_id
 			)
-		`, Aredl().LevelTableName, names.TableLevelInfo))
+		`, listData.LevelTableName, names.TableLevelInfo))
 
-	type LevelData struct {
-		LevelId string `db:"level_id"`
-	}
-
-	var levelIds []LevelData
+		var levelIds []LevelData
 
-	err := query.All(&levelIds)
-	if err != nil {
-		return []string{}, fmt.Errorf("level id query error: %w", err)
+		err := query.All(&levelIds)
+		if err != nil {
+			return []string{}, fmt.Errorf("level id query error: %w", err)
+		}
+		stringIds = append(stringIds, util.MapSlice(levelIds, func(value LevelData) string { return value.LevelId })...)
 	}
-	stringIds := util.MapSlice(levelIds, func(value LevelData) string { return value.LevelId })
-	return stringIds, nil
+	return list.ToUniqueStringSlice(stringIds), nil
 }
 
 func parseLevelData(data string) (LevelData, error) {
@@ -3,19 +3,22 @@ package demonlist
 import (
 	"AREDL/names"
 	"AREDL/util"
+	"AREDL/webhook"
 	"fmt"
 	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
 	"github.com/pocketbase/pocketbase/core"
 	"github.com/pocketbase/pocketbase/daos"
 	"github.com/pocketbase/pocketbase/forms"
+	"github.com/pocketbase/pocketbase/models"
 	"github.com/pocketbase/pocketbase/tools/list"
-	"gopkg.in/Knetic/govaluate.v2"
 	"math"
 	"modernc.org/mathutil"
-	"strings"
+	"strconv"
 )
 
-func PlaceLevel(dao *daos.Dao, app core.App, userId string, listData ListData, levelData map[string]interface{}, verificationData map[string]interface{}, creatorIds []string) error {
+// PlaceLevel adds a new level at the given position together with its verification and returns the created level
+func PlaceLevel(dao *daos.Dao, app core.App, userId string, listData ListData, levelData map[string]interface{}, verificationData map[string]interface{}, creatorIds []string) (*models.Record, error) {
 	var legacy bool
 	if levelData["legacy"] == nil {
 		levelData["legacy"] = false
@@ -23,6 +26,7 @@ func PlaceLevel(dao *daos.Dao, app core.App, userId string, listData ListData, l
 	} else {
 		legacy = levelData["legacy"].(bool)
 	}
+	var levelRecord *models.Record
 	err := dao.RunInTransaction(func(txDao *daos.Dao) error {
 		highestPosition, err := queryMaxPosition(txDao, listData, legacy)
 		if err != nil {
@@ -45,7 +49,7 @@ func PlaceLevel(dao *daos.Dao, app core.App, userId string, listData ListData, l
 		if err != nil {
 			return util.NewErrorResponse(err, "Failed to move other levels")
 		}
-		levelRecord, err := util.AddRecordByCollectionName(txDao, app, listData.LevelTableName, levelData)
+		levelRecord, err = util.AddRecordByCollectionName(txDao, app, listData.LevelTableName, levelData)
 		if err != nil {
 			return util.NewErrorResponse(err, "Failed to add new level")
 		}
@@ -56,6 +60,7 @@ func PlaceLevel(dao *daos.Dao, app core.App, userId string, listData ListData, l
 		verificationData["level"] = levelRecord.Id
 		verificationData["reviewer"] = userId
 		verificationData["placement_order"] = 1
+		verificationData["percentage"] = 100
 		_, err = util.AddRecordByCollectionName(txDao, app, listData.RecordsTableName, verificationData)
 		if err != nil {
 			return util.NewErrorResponse(err, "Failed to add verification")
@@ -79,24 +84,19 @@ func PlaceLevel(dao *daos.Dao, app core.App, userId string, listData ListData, l
 		if err != nil {
 			return util.NewErrorResponse(err, "Failed to add placement into history")
 		}
-		_, err = txDao.DB().NewQuery(fmt.Sprintf(`
-			INSERT INTO %s (level, action, new_position, cause, action_by)
-			SELECT l.id AS level, {:action} AS action, l.position AS new_position, {:cause} AS cause, {:action_by} AS action_by
-			FROM %s l
-			WHERE l.position > {:position}`,
-			listData.HistoryTableName,
-			listData.LevelTableName)).Bind(dbx.Params{
+		err = addLevelsHistory(txDao, listData, dbx.NewExp("position > {:position}", dbx.Params{"position": position}), map[string]any{
 			"action":    "placedAbove",
 			"cause":     levelRecord.Id,
 			"action_by": userId,
-			"position":  position,
-		}).Execute()
+		})
 		if err != nil {
 			return util.NewErrorResponse(err, "Failed to update level history")
 		}
-		return nil
+		return webhook.Emit(txDao, listData.Name, webhook.EventLevelPlaced, map[string]any{
+			"level": levelRecord.PublicExport(),
+		})
 	})
-	return err
+	return levelRecord, err
 }
 
 func UpdateLevel(dao *daos.Dao, app core.App, recordId string, userId string, listData ListData, levelData map[string]interface{}, creatorIds interface{}) error {
@@ -127,6 +127,14 @@ func UpdateLevel(dao *daos.Dao, app core.App, recordId string, userId string, li
 		if err != nil {
 			return util.NewErrorResponse(err, "Failed to submit level data")
 		}
+		if levelData["percent_to_qualify"] != nil {
+			// progress records of the level can be worth a different amount of points now
+			position := levelRecord.GetInt("position")
+			err = UpdateLevelListPointsByPositionRange(txDao, listData, position, position)
+			if err != nil {
+				return err
+			}
+		}
 		return nil
 	})
 	return err
@@ -154,6 +162,10 @@ func moveLevel(dao *daos.Dao, app core.App, listData ListData, levelId string, u
 		}
 		if legacyChanged {
 			levelRecord.Set("legacy", legacy)
+			if legacy {
+				// kept for the frozen legacy policy
+				levelRecord.Set("legacy_points", levelRecord.GetFloat("points"))
+			}
 			err = txDao.SaveRecord(levelRecord)
 			if err != nil {
 				return util.NewErrorResponse(err, "Failed to update legacy")
@@ -199,14 +211,25 @@ func moveLevel(dao *daos.Dao, app core.App, listData ListData, levelId string, u
 		levelMovedStatus := util.If(moveUp, "movedUp", "movedDown")
 		levelOtherStatus := util.If(moveUp, "movedPastUp", "movedPastDown")
 		if legacyChanged {
-			// moved into or out of legacy
+			// the level counts changed
+			err = UpdatePointTable(txDao, listData)
+		} else {
+			err = updatePointTableAfterMove(txDao, listData)
+		}
+		if err != nil {
+			return err
+		}
+		updateMinPos, updateMaxPos := mathutil.Min(newPos, oldPos), mathutil.Max(newPos, oldPos)
+		if legacyChanged {
+			// moved into or out of legacy, this changes the level counts and therefore the points of every position
 			levelMovedStatus = util.If(legacy, "movedToLegacy", "movedFromLegacy")
-			err := UpdatePointTable(txDao, listData)
+			updateMinPos = 1
+			updateMaxPos, err = queryMaxPosition(txDao, listData, true)
 			if err != nil {
-				return err
+				return util.NewErrorResponse(err, "Could not query max pos including legacy")
 			}
 		}
-		err = UpdateLevelListPointsByPositionRange(txDao, listData, mathutil.Min(newPos, oldPos), mathutil.Max(newPos, oldPos))
+		err = UpdateLevelListPointsByPositionRange(txDao, listData, updateMinPos, updateMaxPos)
 		if err != nil {
 			return util.NewErrorResponse(err, "Failed to update level listData points")
 		}
@@ -221,28 +244,184 @@ func moveLevel(dao *daos.Dao, app core.App, listData ListData, levelId string, u
 		if err != nil {
 			return util.NewErrorResponse(err, "Failed to write place into the position history")
 		}
-		_, err = txDao.DB().NewQuery(fmt.Sprintf(`
-			INSERT INTO %s (level, action, new_position, cause, action_by) 
-			SELECT l.id AS level, {:status} AS status, l.position AS new_position, {:cause} AS cause, {:action_by} AS action_by
-			FROM %s l
-			WHERE l.position BETWEEN {:minPos} AND {:maxPos} AND l.position <> {:newPos}`,
-			listData.HistoryTableName,
-			listData.LevelTableName)).Bind(dbx.Params{
-			"status":    levelOtherStatus,
-			"minPos":    mathutil.Min(newPos, oldPos),
-			"maxPos":    mathutil.Max(newPos, oldPos),
+		err = addLevelsHistory(txDao, listData, dbx.And(
+			dbx.Between("position", mathutil.Min(newPos, oldPos), mathutil.Max(newPos, oldPos)),
+			dbx.Not(dbx.HashExp{"position": newPos})), map[string]any{
+			"action":    levelOtherStatus,
 			"cause":     levelRecord.Id,
 			"action_by": userId,
-			"newPos":    newPos,
-		}).Execute()
+		})
 		if err != nil {
 			return util.NewErrorResponse(err, "Failed to write history")
 		}
-		return nil
+		levelRecord.Set("position", newPos)
+		return webhook.Emit(txDao, listData.Name, util.If(legacyChanged && legacy, webhook.EventLevelMovedToLegacy, webhook.EventLevelMoved), map[string]any{
+			"level":        levelRecord.PublicExport(),
+			"old_position": oldPos,
+			"new_position": newPos,
+		})
 	})
 	return err
 }
 
+// RemoveLevel removes the level from the list and moves all levels below it up by one position.
+// The level is kept in the removed levels archive. Its records are either archived as well or deleted.
+// Everything that belongs to the level is deleted with it. The position history keeps the id of the level, which is the id of its archive entry as well
+func RemoveLevel(dao *daos.Dao, app core.App, listData ListData, levelId string, userId string, reason string, archiveRecords bool) error {
+	err := dao.RunInTransaction(func(txDao *daos.Dao) error {
+		levelRecord, err := txDao.FindRecordById(listData.LevelTableName, levelId)
+		if err != nil {
+			return util.NewErrorResponse(err, "Could not find level")
+		}
+		position := levelRecord.GetInt("position")
+		records, err := txDao.FindRecordsByExpr(listData.RecordsTableName, dbx.HashExp{"level": levelRecord.Id})
+		if err != nil {
+			return util.NewErrorResponse(err, "Failed to load records")
+		}
+		var packIds []string
+		err = txDao.DB().Select("pack").From(listData.Packs.PackLevelTableName).Where(dbx.HashExp{"level": levelRecord.Id}).Column(&packIds)
+		if err != nil {
+			return util.NewErrorResponse(err, "Failed to load packs of the level")
+		}
+
+		// archive
+		removedCollection, err := txDao.FindCollectionByNameOrId(listData.RemovedLevelsTableName)
+		if err != nil {
+			return util.NewErrorResponse(err, "Failed to load collection")
+		}
+		removedLevel := models.NewRecord(removedCollection)
+		removedLevel.SetId(levelRecord.Id)
+		removedLevel.MarkAsNew()
+		removedLevel.Set("level_id", levelRecord.GetInt("level_id"))
+		removedLevel.Set("name", levelRecord.GetString("name"))
+		removedLevel.Set("position", position)
+		removedLevel.Set("legacy", levelRecord.GetBool("legacy"))
+		removedLevel.Set("publisher", levelRecord.GetString("publisher"))
+		removedLevel.Set("removed_by", userId)
+		removedLevel.Set("reason", reason)
+		removedLevel.Set("records_archived", archiveRecords)
+		removedLevel.Set("level", levelRecord.PublicExport())
+		err = txDao.SaveRecord(removedLevel)
+		if err != nil {
+			return util.NewErrorResponse(err, "Failed to archive level")
+		}
+		if archiveRecords {
+			for _, record := range records {
+				_, err = util.AddRecordByCollectionName(txDao, app, listData.ArchivedRecordsTableName, map[string]any{
+					"removed_level":   removedLevel.Id,
+					"submitted_by":    record.GetString("submitted_by"),
+					"placement_order": record.GetInt("placement_order"),
+					"record":          record.PublicExport(),
+				})
+				if err != nil {
+					return util.NewErrorResponse(err, "Failed to archive record")
+				}
+			}
+		}
+
+		_, err = util.AddRecordByCollectionName(txDao, app, listData.HistoryTableName, map[string]any{
+			"level":        levelRecord.Id,
+			"action":       "removed",
+			"new_position": position,
+			"cause":        levelRecord.Id,
+			"action_by":    userId,
+		})
+		if err != nil {
+			return util.NewErrorResponse(err, "Failed to write removal into the position history")
+		}
+		// deletes the records, submissions, tombstones, creators and pack levels of the level with it
+		err = txDao.DeleteRecord(levelRecord)
+		if err != nil {
+			return util.NewErrorResponse(err, "Failed to delete level")
+		}
+
+		// collapse positions
+		_, err = txDao.DB().Update(listData.LevelTableName,
+			dbx.Params{"position": dbx.NewExp("position-1")}, dbx.NewExp("position>{:position}", dbx.Params{"position": position})).Execute()
+		if err != nil {
+			return util.NewErrorResponse(err, "Failed to move other levels")
+		}
+		err = addLevelsHistory(txDao, listData, dbx.NewExp("position >= {:position}", dbx.Params{"position": position}), map[string]any{
+			"action":    "movedPastUp",
+			"cause":     levelRecord.Id,
+			"action_by": userId,
+		})
+		if err != nil {
+			return util.NewErrorResponse(err, "Failed to update level history")
+		}
+
+		// removing a level changes the level count and therefore possibly the points of every position
+		err = UpdatePointTable(txDao, listData)
+		if err != nil {
+			return err
+		}
+		highestPosition, err := queryMaxPosition(txDao, listData, true)
+		if err != nil {
+			return util.NewErrorResponse(err, "Could not query max pos including legacy")
+		}
+		err = UpdateLevelListPointsByPositionRange(txDao, listData, 1, highestPosition)
+		if err != nil {
+			return err
+		}
+		usersToUpdate := util.MapSlice(records, func(record *models.Record) interface{} { return record.GetString("submitted_by") })
+		for _, packId := range packIds {
+			err = updatePackPointsByPackId(txDao, listData, packId)
+			if err != nil {
+				return err
+			}
+			removedUsers, err := updateCompletedPacksByPackId(txDao, listData, packId)
+			if err != nil {
+				return util.NewErrorResponse(err, "Failed to update completed packs")
+			}
+			usersToUpdate = append(usersToUpdate, removedUsers...)
+			err = updateLeaderboardByPackId(txDao, listData, packId)
+			if err != nil {
+				return util.NewErrorResponse(err, "Failed to update leaderboard")
+			}
+		}
+		if len(usersToUpdate) > 0 {
+			err = UpdateLeaderboardByUserIds(txDao, listData, usersToUpdate)
+			if err != nil {
+				return util.NewErrorResponse(err, "Failed to update leaderboard")
+			}
+		}
+		return webhook.Emit(txDao, listData.Name, webhook.EventLevelRemoved, map[string]any{
+			"level":            levelRecord.PublicExport(),
+			"old_position":     position,
+			"records_archived": archiveRecords,
+		})
+	})
+	return err
+}
+
+// addLevelsHistory adds a history entry with the given values for every level that matches the condition at its current position.
+// The entries are saved as records, so the model hooks publish them to the event stream after the transaction has been committed
+func addLevelsHistory(dao *daos.Dao, listData ListData, condition dbx.Expression, values map[string]any) error {
+	var levels []struct {
+		Id       string `db:"id"`
+		Position int    `db:"position"`
+	}
+	err := dao.DB().Select("id", "position").From(listData.LevelTableName).Where(condition).OrderBy("position").All(&levels)
+	if err != nil {
+		return err
+	}
+	collection, err := dao.FindCollectionByNameOrId(listData.HistoryTableName)
+	if err != nil {
+		return err
+	}
+	for _, level := range levels {
+		record := models.NewRecord(collection)
+		record.Load(values)
+		record.Set("level", level.Id)
+		record.Set("new_position", level.Position)
+		err = dao.SaveRecord(record)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func updateCreators(dao *daos.Dao, listData ListData, recordId string, newCreatos []string) error {
 	err := dao.RunInTransaction(func(txDao *daos.Dao) error {
 		type Creator struct {
@@ -273,19 +452,27 @@ func updateCreators(dao *daos.Dao, listData ListData, recordId string, newCreato
 
 func UpdateLevelListPointsByPositionRange(dao *daos.Dao, list ListData, minPos int, maxPos int) error {
 	err := dao.RunInTransaction(func(txDao *daos.Dao) error {
-		query := txDao.DB().NewQuery(fmt.Sprintf(`
-		UPDATE %s
-		SET points=(
+		list, err := loadPointSettings(txDao, list)
+		if err != nil {
+			return err
+		}
+		pointLookup := fmt.Sprintf(`(
 			SELECT p.points 
 			FROM %s p 
 			WHERE p.id=position 
-		)
-		WHERE position BETWEEN {:minPos} AND {:maxPos}`, list.LevelTableName, list.PointLookupTableName)).Bind(dbx.Params{
+		)`, list.PointLookupTableName)
+		if list.LegacyPolicy == LegacyPointsFrozen {
+			pointLookup = fmt.Sprintf("CASE WHEN legacy = true THEN COALESCE(legacy_points, 0) ELSE %s END", pointLookup)
+		}
+		query := txDao.DB().NewQuery(fmt.Sprintf(`
+		UPDATE %s
+		SET points=%s
+		WHERE position BETWEEN {:minPos} AND {:maxPos}`, list.LevelTableName, pointLookup)).Bind(dbx.Params{
 			"minPos": minPos,
 			"maxPos": maxPos,
 		})
 
-		_, err := query.Execute()
+		_, err = query.Execute()
 		if err != nil {
 			return util.NewErrorResponse(err, "Failed to update level list points")
 		}
@@ -293,127 +480,175 @@ func UpdateLevelListPointsByPositionRange(dao *daos.Dao, list ListData, minPos i
 		if err != nil {
 			return err
 		}
+		err = updateProgressPointsByLevelRange(txDao, list, minPos, maxPos)
+		if err != nil {
+			return err
+		}
 		err = updateLeaderboardByLevelRange(txDao, list, minPos, maxPos)
 		return err
 	})
 	return err
 }
 
-func UpdatePointTable(dao *daos.Dao, list ListData) error {
-	functions := map[string]govaluate.ExpressionFunction{
-		"sqrt": func(args ...interface{}) (interface{}, error) {
-			if len(args) != 1 {
-				return nil, fmt.Errorf("sqrt exactly takes one argument")
-			}
-			value, ok := args[0].(float64)
-			if !ok {
-				return nil, fmt.Errorf("argument must be a float64 for sqrt")
-			}
-			return math.Sqrt(value), nil
-		},
+// updatePointTableAfterMove regenerates the point table after levels moved without changing the level counts.
+// This is only needed if the formulas use the enjoyment of the level at a position
+func updatePointTableAfterMove(dao *daos.Dao, list ListData) error {
+	data, err := loadPointFormulaData(dao, list)
+	if err != nil {
+		return err
+	}
+	if !usesEnjoyment(data) {
+		return nil
 	}
+	return updatePointTableWithFormula(dao, list, data)
+}
+
+func UpdatePointTable(dao *daos.Dao, list ListData) error {
 	err := dao.RunInTransaction(func(txDao *daos.Dao) error {
-		formulaData, err := txDao.FindFirstRecordByData("point_formula", "list", list.Name)
+		data, err := loadPointFormulaData(txDao, list)
 		if err != nil {
-			return util.NewErrorResponse(nil, "failed to load formula data")
+			return err
 		}
-		levelCount, err := queryMaxPosition(txDao, list, false)
+		return updatePointTableWithFormula(txDao, list, data)
+	})
+	return err
+}
+
+// updatePointTableWithFormula rewrites the point lookup table of the list using the given formulas.
+// Legacy positions only get points from the legacy formula if the list uses the formula legacy policy
+func updatePointTableWithFormula(dao *daos.Dao, list ListData, data PointFormulaData) error {
+	err := dao.RunInTransaction(func(txDao *daos.Dao) error {
+		list, err := loadPointSettings(txDao, list)
 		if err != nil {
-			return util.NewErrorResponse(nil, "failed to query max pos")
+			return err
 		}
-		levelCount--
-		totalLevelCount, err := queryMaxPosition(txDao, list, true)
+		variables, err := queryPointFormulaVariables(txDao, list)
 		if err != nil {
-			return util.NewErrorResponse(nil, "failed to query max legacy pos")
+			return err
 		}
-		totalLevelCount--
-		parameters := make(map[string]interface{}, 1)
-		parameters["level_count"] = levelCount
-		for _, precalc := range strings.Split(formulaData.GetString("precalc"), ",") {
-			if len(precalc) == 0 {
-				continue
-			}
-			calc := strings.SplitN(precalc, "=", 2)
-			if len(calc) != 2 {
-				return util.NewErrorResponse(nil, "invalid format")
-			}
-			calcFormula, err := govaluate.NewEvaluableExpressionWithFunctions(calc[1], functions)
-			if err != nil {
-				return util.NewErrorResponse(nil, "failed to parse precalc formula")
-			}
-			result, err := calcFormula.Evaluate(parameters)
-			if err != nil {
-				return util.NewErrorResponse(nil, "failed to evaluate precalc formula")
-			}
-			parameters[calc[0]] = result
+		var levels []struct {
+			Position  int     `db:"position"`
+			Enjoyment float64 `db:"enjoyment"`
+		}
+		err = txDao.DB().Select("position", "COALESCE(enjoyment, 0) AS enjoyment").From(list.LevelTableName).All(&levels)
+		if err != nil {
+			return util.NewErrorResponse(err, "failed to load level enjoyment")
+		}
+		enjoyments := make(map[int]float64, len(levels))
+		for _, level := range levels {
+			enjoyments[level.Position] = level.Enjoyment
 		}
-		formula, err := govaluate.NewEvaluableExpressionWithFunctions(formulaData.GetString("formula"), functions)
+		points, err := calculatePointTable(list, data, variables, enjoyments)
 		if err != nil {
-			return util.NewErrorResponse(nil, "failed to parse formula")
+			return err
 		}
 		_, err = txDao.DB().Delete(list.PointLookupTableName, nil).Execute()
 		if err != nil {
 			return util.NewErrorResponse(nil, "failed to delete old points")
 		}
-		for i := 1; i <= levelCount+1; i++ {
-			parameters["x"] = float64(i)
-			result, err := formula.Evaluate(parameters)
-			if err != nil {
-				return util.NewErrorResponse(nil, "failed to evaluate formula")
-			}
-			value, ok := result.(float64)
-			if !ok {
-				return fmt.Errorf("resulting value is not a float64")
-			}
-			if value < 0.0 {
-				value = 0.0
-			}
+		for i, value := range points {
 			_, err = txDao.DB().Insert(list.PointLookupTableName, dbx.Params{
-				"id":     i,
-				"points": fmt.Sprintf("%.1f", math.Round(value*10)/10),
+				"id":     i + 1,
+				"points": formatPoints(value, list.PointPrecision),
 			}).Execute()
 			if err != nil {
 				return util.NewErrorResponse(nil, "failed to insert new points")
 			}
 		}
-		for i := levelCount + 2; i <= totalLevelCount+1; i++ {
-			_, err = txDao.DB().Insert(list.PointLookupTableName, dbx.Params{
-				"id":     i,
-				"points": fmt.Sprintf("%.1f", 0.0),
-			}).Execute()
-			if err != nil {
-				return util.NewErrorResponse(nil, "failed to insert new remaining legacy points")
-			}
-		}
 		return nil
 	})
 	return err
 }
 
+// calculatePointTable evaluates the formulas for every position of a list with the given variables.
+// The result contains the unrounded points of position i at index i-1
+func calculatePointTable(list ListData, data PointFormulaData, variables pointFormulaVariables, enjoyments map[int]float64) ([]float64, error) {
+	if list.LegacyPolicy != LegacyPointsFormula {
+		data.LegacyFormula = ""
+	}
+	formula, err := newPointFormula(data, variables)
+	if err != nil {
+		return nil, util.NewErrorResponse(err, "invalid point formula")
+	}
+	points := make([]float64, variables.TotalCount)
+	for i := 1; i <= variables.TotalCount; i++ {
+		var value float64
+		if i <= variables.LevelCount+1 {
+			value, err = formula.evaluate(i, enjoyments[i])
+		} else {
+			value, err = formula.evaluateLegacy(i, enjoyments[i])
+		}
+		if err != nil {
+			return nil, util.NewErrorResponse(err, fmt.Sprintf("invalid point formula at position %d", i))
+		}
+		if math.IsInf(value, 0) {
+			return nil, util.NewErrorResponse(nil, fmt.Sprintf("point formula results in an infinite value at position %d", i))
+		}
+		if value < 0.0 || math.IsNaN(value) {
+			value = 0.0
+		}
+		points[i-1] = value
+	}
+	return points, nil
+}
+
+// formatPoints rounds the points to the given number of decimals
+func formatPoints(points float64, precision int) string {
+	return strconv.FormatFloat(roundPoints(points, precision), 'f', precision, 64)
+}
+
+// roundPoints rounds the points to the given number of decimals, halves are rounded away from zero like ROUND in sqlite
+func roundPoints(points float64, precision int) float64 {
+	factor := math.Pow(10, float64(precision))
+	return math.Round(points*factor) / factor
+}
+
 func RegisterUpdatePoints(app core.App) {
+	validateFormula := func(record *models.Record) error {
+		listData, ok := GetList(record.GetString("list"))
+		if !ok {
+			return util.NewErrorResponse(nil, "Unknown list")
+		}
+		err := ValidatePointFormula(app.Dao(), pointSettingsFromRecord(listData, record), pointFormulaDataFromRecord(record))
+		if err != nil {
+			return util.NewErrorResponse(err, "Invalid point formula")
+		}
+		return nil
+	}
+	app.OnRecordBeforeCreateRequest(names.TablePointFormular).Add(func(e *core.RecordCreateEvent) error {
+		return validateFormula(e.Record)
+	})
+	app.OnRecordBeforeUpdateRequest(names.TablePointFormular).Add(func(e *core.RecordUpdateEvent) error {
+		return validateFormula(e.Record)
+	})
 	app.OnRecordAfterUpdateRequest(names.TablePointFormular).Add(func(e *core.RecordUpdateEvent) error {
-		var listData ListData
 		listName := e.Record.GetString("list")
-		switch listName {
-		case "aredl":
-			listData = Aredl()
-		default:
+		listData, ok := GetList(listName)
+		if !ok {
 			return fmt.Errorf("unknown list %s", listName)
 		}
+		userId, adminId := "", ""
+		if userRecord, _ := e.HttpContext.Get(apis.ContextAuthRecordKey).(*models.Record); userRecord != nil {
+			userId = userRecord.Id
+		}
+		if admin, _ := e.HttpContext.Get(apis.ContextAdminKey).(*models.Admin); admin != nil {
+			adminId = admin.Id
+		}
 		err := app.Dao().RunInTransaction(func(txDao *daos.Dao) error {
-			err := UpdatePointTable(txDao, listData)
-			if err != nil {
-				return err
+			data := pointFormulaDataFromRecord(e.Record)
+			originalData := pointFormulaDataFromRecord(e.Record.OriginalCopy())
+			// changing only the point settings recalculates the points without adding a formula version
+			if data != originalData {
+				err := seedPointFormulaHistory(txDao, app, listData, originalData)
+				if err != nil {
+					return err
+				}
+				err = addPointFormulaHistory(txDao, app, listData, userId, adminId, data)
+				if err != nil {
+					return err
+				}
 			}
-			maxPos, err := queryMaxPosition(txDao, listData, true)
-			if err != nil {
-				return util.NewErrorResponse(nil, "Failed to query max pos")
-			}
-			err = UpdateLevelListPointsByPositionRange(txDao, listData, 1, maxPos)
-			if err != nil {
-				return util.NewErrorResponse(err, "Failed to update list points")
-			}
-			return nil
+			return RecalculatePoints(txDao, listData)
 		})
 		return err
 	})
@@ -1,39 +1,235 @@
 package demonlist
 
+import (
+	"AREDL/names"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/models/schema"
+	"github.com/pocketbase/pocketbase/tools/list"
+	"os"
+	"regexp"
+)
+
+var registeredLists []ListData
+
+// listConfigEnv is the environment variable with the path of the list configuration
+const listConfigEnv = "AREDL_LISTS"
+
+// defaultListConfig is the list configuration that is used if AREDL_LISTS is not set
+const defaultListConfig = "lists.json"
+
+// RegisterList adds a list to the registry. Every registered list gets its own endpoints under /api/{name},
+// its own permission scope and is included in user merges. The collections of the list and its option in the list select
+// of the role permissions collection are created by a migration when the list is added, see AddListOption.
+func RegisterList(listData ListData) {
+	for i, registered := range registeredLists {
+		if registered.Name == listData.Name {
+			registeredLists[i] = listData
+			return
+		}
+	}
+	registeredLists = append(registeredLists, listData)
+}
+
+// RegisterConfiguredLists registers every list of the list configuration, a JSON array of lists.
+// The path of the configuration is read from AREDL_LISTS and defaults to lists.json. Only the AREDL is registered if the default configuration does not exist
+func RegisterConfiguredLists() error {
+	path := os.Getenv(listConfigEnv)
+	if path == "" {
+		path = defaultListConfig
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			RegisterList(Aredl())
+			return nil
+		}
+	}
+	lists, err := LoadListConfig(path)
+	if err != nil {
+		return err
+	}
+	for _, listData := range lists {
+		RegisterList(listData)
+	}
+	return nil
+}
+
+// LoadListConfig reads the lists of the list configuration at the given path
+func LoadListConfig(path string) ([]ListData, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the list configuration: %w", err)
+	}
+	var lists []ListData
+	err = json.Unmarshal(data, &lists)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the list configuration: %w", err)
+	}
+	return lists, validateListConfig(lists)
+}
+
+func validateListConfig(lists []ListData) error {
+	if len(lists) == 0 {
+		return errors.New("the list configuration does not contain any list")
+	}
+	names := map[string]bool{}
+	for _, listData := range lists {
+		if !listNamePattern.MatchString(listData.Name) {
+			return fmt.Errorf("invalid list name %q, only lowercase letters, digits and underscores are allowed", listData.Name)
+		}
+		if listData.Name == "global" {
+			return errors.New("global can't be used as list name, it is the scope of the global permissions")
+		}
+		if names[listData.Name] {
+			return fmt.Errorf("the list %s is configured multiple times", listData.Name)
+		}
+		names[listData.Name] = true
+	}
+	return nil
+}
+
+var listNamePattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// Lists returns all registered lists in the order they were registered
+func Lists() []ListData {
+	return registeredLists
+}
+
+// GetList returns the registered list with the given name
+func GetList(name string) (ListData, bool) {
+	for _, listData := range registeredLists {
+		if listData.Name == name {
+			return listData, true
+		}
+	}
+	return ListData{}, false
+}
+
+// AddListOption adds the list to the list select of the role permissions collection, so permissions of the list can be created.
+// It is meant to be called by the migration that adds the list
+func AddListOption(dao *daos.Dao, listName string) error {
+	collection, err := dao.FindCollectionByNameOrId(names.TablePermissions)
+	if err != nil {
+		return err
+	}
+	options := collection.Schema.GetFieldByName("list").Options.(*schema.SelectOptions)
+	if list.ExistInSlice(listName, options.Values) {
+		return nil
+	}
+	options.Values = append(options.Values, listName)
+	return dao.SaveCollection(collection)
+}
+
+// RemoveListOption removes the list from the list select of the role permissions collection together with the permissions of the list
+func RemoveListOption(dao *daos.Dao, listName string) error {
+	collection, err := dao.FindCollectionByNameOrId(names.TablePermissions)
+	if err != nil {
+		return err
+	}
+	_, err = dao.DB().Delete(names.TablePermissions, dbx.HashExp{"list": listName}).Execute()
+	if err != nil {
+		return err
+	}
+	options := collection.Schema.GetFieldByName("list").Options.(*schema.SelectOptions)
+	options.Values = list.SubtractSlice(options.Values, []string{listName})
+	return dao.SaveCollection(collection)
+}
+
+// Aredl is the list that is registered if there is no list configuration
 func Aredl() ListData {
 	return ListData{
-		Name:                 "aredl",
-		LeaderboardTableName: "aredl_leaderboard",
-		SubmissionsTableName: "record_submissions",
-		RecordsTableName:     "records",
-		LevelTableName:       "aredl",
-		CreatorTableName:     "creators",
-		HistoryTableName:     "position_history",
-		PointLookupTableName: "points",
+		Name:                        "aredl",
+		LeaderboardTableName:        "aredl_leaderboard",
+		CountryLeaderboardTableName: "aredl_country_leaderboard",
+		SubmissionsTableName:        "record_submissions",
+		SubmissionTimelineTableName: "submission_timeline",
+		SubmissionCommentsTableName: "submission_comments",
+		RecordsTableName:            "records",
+		RecordTombstonesTableName:   "record_tombstones",
+		LevelTableName:              "aredl",
+		RemovedLevelsTableName:      "removed_levels",
+		ArchivedRecordsTableName:    "archived_records",
+		CreatorTableName:            "creators",
+		HistoryTableName:            "position_history",
+		ListUpdatesTableName:        "list_updates",
+		SnapshotsTableName:          "list_snapshots",
+		LeaderboardHistoryTableName: "leaderboard_history",
+		PointLookupTableName:        "points",
+		PointPrecision:              1,
+		LegacyPolicy:                LegacyPointsZero,
 		Packs: PackData{
 			PackTableName:           "packs",
 			PackLevelTableName:      "pack_levels",
 			CompletedPacksTableName: "completed_packs",
 			PackMultiplier:          0.5,
+			LegacyPolicy:            PackLegacyZero,
 		},
 	}
 }
 
+// LegacyPolicy decides how many points legacy levels are worth. An empty policy behaves like LegacyPointsZero
+type LegacyPolicy string
+
+const (
+	// LegacyPointsZero gives every legacy level 0 points
+	LegacyPointsZero LegacyPolicy = "zero"
+	// LegacyPointsFrozen keeps the points a level had when it was moved to legacy
+	LegacyPointsFrozen LegacyPolicy = "frozen"
+	// LegacyPointsFormula calculates the points of legacy positions using the legacy formula of the list
+	LegacyPointsFormula LegacyPolicy = "formula"
+)
+
+// PackLegacyPolicy decides how legacy levels affect the points of packs containing them. An empty policy behaves like PackLegacyZero
+type PackLegacyPolicy string
+
+const (
+	// PackLegacyZero gives packs containing a legacy level 0 points
+	PackLegacyZero PackLegacyPolicy = "zero"
+	// PackLegacyExclude only counts the points of non-legacy levels of a pack
+	PackLegacyExclude PackLegacyPolicy = "exclude"
+	// PackLegacyInclude counts the points of every level of a pack, including legacy levels
+	PackLegacyInclude PackLegacyPolicy = "include"
+)
+
 type PackData struct {
-	PackTableName           string
-	PackLevelTableName      string
-	CompletedPacksTableName string
-	PackMultiplier          float64
+	PackTableName           string           `json:"pack_table_name"`
+	PackLevelTableName      string           `json:"pack_level_table_name"`
+	CompletedPacksTableName string           `json:"completed_packs_table_name"`
+	PackMultiplier          float64          `json:"pack_multiplier"`
+	LegacyPolicy            PackLegacyPolicy `json:"legacy_policy"`
 }
 
 type ListData struct {
-	Name                 string
-	LeaderboardTableName string
-	SubmissionsTableName string
-	RecordsTableName     string
-	LevelTableName       string
-	CreatorTableName     string
-	HistoryTableName     string
-	PointLookupTableName string
-	Packs                PackData
+	Name                 string `json:"name"`
+	LeaderboardTableName string `json:"leaderboard_table_name"`
+	// CountryLeaderboardTableName sums up the leaderboard per country, it is rebuilt whenever the leaderboard ranks change
+	CountryLeaderboardTableName string `json:"country_leaderboard_table_name"`
+	SubmissionsTableName        string `json:"submissions_table_name"`
+	// SubmissionTimelineTableName stores every status change of a submission
+	SubmissionTimelineTableName string `json:"submission_timeline_table_name"`
+	// SubmissionCommentsTableName stores the messages between reviewers and the submitter of a submission
+	SubmissionCommentsTableName string `json:"submission_comments_table_name"`
+	RecordsTableName            string `json:"records_table_name"`
+	// RecordTombstonesTableName keeps removed records and the reason they were removed for
+	RecordTombstonesTableName string `json:"record_tombstones_table_name"`
+	LevelTableName            string `json:"level_table_name"`
+	// RemovedLevelsTableName archives levels that have been removed from the list using the id they had on the list
+	RemovedLevelsTableName string `json:"removed_levels_table_name"`
+	// ArchivedRecordsTableName keeps the records of removed levels if staff decided to archive them
+	ArchivedRecordsTableName string `json:"archived_records_table_name"`
+	CreatorTableName         string `json:"creator_table_name"`
+	HistoryTableName         string `json:"history_table_name"`
+	// ListUpdatesTableName groups position history entries that were applied together in one batch
+	ListUpdatesTableName string `json:"list_updates_table_name"`
+	// SnapshotsTableName stores periodic copies of the levels and the leaderboard to reconstruct past states of the list
+	SnapshotsTableName string `json:"snapshots_table_name"`
+	// LeaderboardHistoryTableName keeps the rank and points of every player whenever they changed between two snapshots
+	LeaderboardHistoryTableName string `json:"leaderboard_history_table_name"`
+	PointLookupTableName        string `json:"point_lookup_table_name"`
+	// PointPrecision is the number of decimals that level, pack and leaderboard points are rounded to.
+	// PointPrecision, LegacyPolicy and Packs.LegacyPolicy are defaults, the point formula record of the list can override them
+	PointPrecision int          `json:"point_precision"`
+	LegacyPolicy   LegacyPolicy `json:"legacy_policy"`
+	Packs          PackData     `json:"packs"`
 }
@@ -0,0 +1,63 @@
+package demonlist
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadListConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		want    []string
+		wantErr string
+	}{
+		{name: "lists", config: `[{"name": "aredl"}, {"name": "edel_2"}]`, want: []string{"aredl", "edel_2"}},
+		{name: "invalid json", config: `{"name": "aredl"}`, wantErr: "failed to parse"},
+		{name: "without lists", config: `[]`, wantErr: "does not contain any list"},
+		{name: "invalid name", config: `[{"name": "Aredl List"}]`, wantErr: "invalid list name"},
+		{name: "global", config: `[{"name": "global"}]`, wantErr: "global"},
+		{name: "duplicate", config: `[{"name": "aredl"}, {"name": "aredl"}]`, wantErr: "multiple times"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "lists.json")
+			if err := os.WriteFile(path, []byte(test.config), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			lists, err := LoadListConfig(path)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("LoadListConfig() error = %v, want it to contain %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadListConfig() failed: %v", err)
+			}
+			if len(lists) != len(test.want) {
+				t.Fatalf("LoadListConfig() returned %d lists, want %d", len(lists), len(test.want))
+			}
+			for i, listData := range lists {
+				if listData.Name != test.want[i] {
+					t.Errorf("list %d = %s, want %s", i, listData.Name, test.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestListConfigMatchesAredl(t *testing.T) {
+	lists, err := LoadListConfig(filepath.Join("..", defaultListConfig))
+	if err != nil {
+		t.Fatalf("LoadListConfig() failed: %v", err)
+	}
+	got, _ := json.Marshal(lists[0])
+	want, _ := json.Marshal(Aredl())
+	if string(got) != string(want) {
+		t.Errorf("the aredl configuration = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,336 @@
+package demonlist
+
+import (
+	"AREDL/names"
+	"AREDL/util"
+	"fmt"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/tools/cron"
+	"github.com/pocketbase/pocketbase/tools/types"
+	"sort"
+	"strconv"
+)
+
+// Snapshots store the levels and the leaderboard of a list once a day. Past states of the list are reconstructed
+// by replaying the position history on top of the latest snapshot before the requested time, so only the history
+// since that snapshot has to be loaded. The leaderboard can't be reconstructed from the history and is only available from snapshots.
+// Every snapshot also adds the rank and points of players that changed since the previous one to the leaderboard history.
+
+// SnapshotLevel is the state of a level at a point in time
+type SnapshotLevel struct {
+	Id       string  `db:"id" json:"id"`
+	Position int     `db:"position" json:"position"`
+	Legacy   bool    `db:"legacy" json:"legacy"`
+	Points   float64 `db:"points" json:"points"`
+}
+
+// SnapshotLeaderboardEntry is the state of a player on the leaderboard at a point in time
+type SnapshotLeaderboardEntry struct {
+	User   string  `db:"user" json:"user"`
+	Rank   int     `db:"rank" json:"rank"`
+	Points float64 `db:"points" json:"points"`
+}
+
+// RegisterSnapshots stores a snapshot of every list once a day
+func RegisterSnapshots(app core.App) {
+	app.OnBeforeServe().Add(func(e *core.ServeEvent) error {
+		scheduler := cron.New()
+		scheduler.MustAdd("snapshots", "0 0 * * *", func() {
+			for _, listData := range Lists() {
+				_, err := CreateSnapshot(app.Dao(), app, listData)
+				if err != nil {
+					app.Logger().Error("Failed to create list snapshot", "list", listData.Name, "error", err)
+				}
+			}
+		})
+		scheduler.Start()
+		return nil
+	})
+}
+
+// CreateSnapshot stores the current levels and leaderboard of the list
+func CreateSnapshot(dao *daos.Dao, app core.App, listData ListData) (*models.Record, error) {
+	var snapshot *models.Record
+	err := dao.RunInTransaction(func(txDao *daos.Dao) error {
+		var levels []SnapshotLevel
+		err := txDao.DB().Select("id", "position", "legacy", "points").From(listData.LevelTableName).OrderBy("position").All(&levels)
+		if err != nil {
+			return util.NewErrorResponse(err, "Failed to load levels")
+		}
+		var leaderboard []SnapshotLeaderboardEntry
+		err = txDao.DB().Select("user", "rank", "points").From(listData.LeaderboardTableName).OrderBy("rank").All(&leaderboard)
+		if err != nil {
+			return util.NewErrorResponse(err, "Failed to load leaderboard")
+		}
+		snapshot, err = util.AddRecordByCollectionName(txDao, app, listData.SnapshotsTableName, map[string]any{
+			"levels":      levels,
+			"leaderboard": leaderboard,
+		})
+		if err != nil {
+			return util.NewErrorResponse(err, "Failed to save snapshot")
+		}
+		return updateLeaderboardHistory(txDao, listData)
+	})
+	return snapshot, err
+}
+
+// updateLeaderboardHistory adds the current rank and points of every player whose latest history entry differs from them
+func updateLeaderboardHistory(dao *daos.Dao, listData ListData) error {
+	_, err := dao.DB().NewQuery(fmt.Sprintf(`
+		INSERT INTO %[1]s (user, rank, points)
+		SELECT lb.user, lb.rank, lb.points
+		FROM %[2]s lb
+		WHERE NOT EXISTS (
+			SELECT 1 FROM %[1]s h
+			WHERE h.user = lb.user AND h.rank = lb.rank AND h.points = lb.points
+			AND h.created = (SELECT MAX(created) FROM %[1]s WHERE user = lb.user)
+		)`,
+		listData.LeaderboardHistoryTableName,
+		listData.LeaderboardTableName)).Execute()
+	if err != nil {
+		return util.NewErrorResponse(err, "Failed to update leaderboard history")
+	}
+	return nil
+}
+
+// findSnapshotBefore returns the latest snapshot created at or before the given time or nil if there is none
+func findSnapshotBefore(dao *daos.Dao, listData ListData, at types.DateTime) (*models.Record, error) {
+	snapshots, err := dao.FindRecordsByFilter(listData.SnapshotsTableName, "created <= {:at}", "-created", 1, 0, dbx.Params{"at": at.String()})
+	if err != nil {
+		return nil, util.NewErrorResponse(err, "Failed to load snapshot")
+	}
+	if len(snapshots) == 0 {
+		return nil, nil
+	}
+	return snapshots[0], nil
+}
+
+// LeaderboardAt returns the leaderboard of the latest snapshot at or before the given time together with the time of that snapshot
+func LeaderboardAt(dao *daos.Dao, listData ListData, at types.DateTime) ([]SnapshotLeaderboardEntry, types.DateTime, error) {
+	snapshot, err := findSnapshotBefore(dao, listData, at)
+	if err != nil {
+		return nil, types.DateTime{}, err
+	}
+	if snapshot == nil {
+		return nil, types.DateTime{}, util.NewErrorResponse(nil, "No leaderboard snapshot exists before the given time")
+	}
+	var leaderboard []SnapshotLeaderboardEntry
+	err = snapshot.UnmarshalJSONField("leaderboard", &leaderboard)
+	if err != nil {
+		return nil, types.DateTime{}, util.NewErrorResponse(err, "Failed to read snapshot")
+	}
+	return leaderboard, snapshot.GetDateTime("created"), nil
+}
+
+// LevelsAt reconstructs the levels of the list ordered by position as they were at the given time.
+// Points are calculated with the point formula that was active at that time
+func LevelsAt(dao *daos.Dao, listData ListData, at types.DateTime) ([]SnapshotLevel, error) {
+	var result []SnapshotLevel
+	err := dao.RunInTransaction(func(txDao *daos.Dao) error {
+		levels := map[string]*SnapshotLevel{}
+		// the legacy state of levels placed after the snapshot is not part of the history and has to be looked up
+		legacyKnown := map[string]bool{}
+		snapshotPoints := map[string]float64{}
+		from := ""
+		snapshot, err := findSnapshotBefore(txDao, listData, at)
+		if err != nil {
+			return err
+		}
+		if snapshot != nil {
+			var snapshotLevels []SnapshotLevel
+			err = snapshot.UnmarshalJSONField("levels", &snapshotLevels)
+			if err != nil {
+				return util.NewErrorResponse(err, "Failed to read snapshot")
+			}
+			for i := range snapshotLevels {
+				level := &snapshotLevels[i]
+				levels[level.Id] = level
+				legacyKnown[level.Id] = true
+				if level.Legacy {
+					snapshotPoints[level.Id] = level.Points
+				}
+			}
+			from = snapshot.GetDateTime("created").String()
+		}
+
+		var history []struct {
+			Level       string `db:"level"`
+			Action      string `db:"action"`
+			NewPosition int    `db:"new_position"`
+		}
+		err = txDao.DB().Select("level", "action", "new_position").
+			From(listData.HistoryTableName).
+			Where(dbx.NewExp("created > {:from} AND created <= {:at}", dbx.Params{"from": from, "at": at.String()})).
+			OrderBy("created", "rowid").
+			All(&history)
+		if err != nil {
+			return util.NewErrorResponse(err, "Failed to load history")
+		}
+		for _, entry := range history {
+			switch entry.Action {
+			case "placed":
+				levels[entry.Level] = &SnapshotLevel{Id: entry.Level}
+				legacyKnown[entry.Level] = false
+			case "removed":
+				delete(levels, entry.Level)
+				continue
+			}
+			level, ok := levels[entry.Level]
+			if !ok {
+				continue
+			}
+			level.Position = entry.NewPosition
+			if entry.Action == "movedToLegacy" || entry.Action == "movedFromLegacy" {
+				level.Legacy = entry.Action == "movedToLegacy"
+				legacyKnown[entry.Level] = true
+			}
+		}
+
+		err = setUnknownLegacyStates(txDao, listData, levels, legacyKnown, at)
+		if err != nil {
+			return err
+		}
+		result = make([]SnapshotLevel, 0, len(levels))
+		for _, level := range levels {
+			result = append(result, *level)
+		}
+		sort.Slice(result, func(i, j int) bool {
+			return result[i].Position < result[j].Position
+		})
+		return setPointsAt(txDao, listData, result, snapshotPoints, at)
+	})
+	return result, err
+}
+
+// setUnknownLegacyStates sets the legacy state of levels that weren't moved into or out of legacy before the given time.
+// The first legacy move after that time tells the state before it, levels that never moved keep their current state
+func setUnknownLegacyStates(dao *daos.Dao, listData ListData, levels map[string]*SnapshotLevel, legacyKnown map[string]bool, at types.DateTime) error {
+	var moves []struct {
+		Level  string `db:"level"`
+		Action string `db:"action"`
+	}
+	err := dao.DB().Select("level", "action").
+		From(listData.HistoryTableName).
+		Where(dbx.In("action", "movedToLegacy", "movedFromLegacy")).
+		AndWhere(dbx.NewExp("created > {:at}", dbx.Params{"at": at.String()})).
+		OrderBy("created", "rowid").
+		All(&moves)
+	if err != nil {
+		return util.NewErrorResponse(err, "Failed to load legacy history")
+	}
+	for _, move := range moves {
+		level, ok := levels[move.Level]
+		if !ok || legacyKnown[move.Level] {
+			continue
+		}
+		level.Legacy = move.Action == "movedFromLegacy"
+		legacyKnown[move.Level] = true
+	}
+	var current []struct {
+		Id     string `db:"id"`
+		Legacy bool   `db:"legacy"`
+	}
+	err = dao.DB().NewQuery(`SELECT id, legacy FROM ` + listData.LevelTableName + `
+		UNION ALL
+		SELECT id, legacy FROM ` + listData.RemovedLevelsTableName).All(&current)
+	if err != nil {
+		return util.NewErrorResponse(err, "Failed to load legacy states")
+	}
+	for _, state := range current {
+		level, ok := levels[state.Id]
+		if ok && !legacyKnown[state.Id] {
+			level.Legacy = state.Legacy
+		}
+	}
+	return nil
+}
+
+// setPointsAt calculates the points of the levels with the point formula that was active at the given time.
+// Levels are expected to be ordered by position. Frozen legacy points are taken from the snapshot if the level was legacy in it
+func setPointsAt(dao *daos.Dao, listData ListData, levels []SnapshotLevel, snapshotPoints map[string]float64, at types.DateTime) error {
+	listData, err := loadPointSettings(dao, listData)
+	if err != nil {
+		return err
+	}
+	data, err := pointFormulaDataAt(dao, listData, at)
+	if err != nil {
+		return err
+	}
+	variables := pointFormulaVariables{LevelCount: -1}
+	for _, level := range levels {
+		if !level.Legacy {
+			variables.LevelCount = level.Position - 1
+		}
+		variables.TotalCount = level.Position
+	}
+	variables.LegacyCount = variables.TotalCount - variables.LevelCount - 1
+
+	var current []struct {
+		Id           string  `db:"id"`
+		Enjoyment    float64 `db:"enjoyment"`
+		LegacyPoints float64 `db:"legacy_points"`
+	}
+	err = dao.DB().Select("id", "COALESCE(enjoyment, 0) AS enjoyment", "COALESCE(legacy_points, 0) AS legacy_points").
+		From(listData.LevelTableName).
+		All(&current)
+	if err != nil {
+		return util.NewErrorResponse(err, "Failed to load level enjoyment")
+	}
+	positions := map[string]int{}
+	for _, level := range levels {
+		positions[level.Id] = level.Position
+	}
+	enjoyments := map[int]float64{}
+	legacyPoints := map[string]float64{}
+	for _, level := range current {
+		enjoyments[positions[level.Id]] = level.Enjoyment
+		legacyPoints[level.Id] = level.LegacyPoints
+	}
+	points, err := calculatePointTable(listData, data, variables, enjoyments)
+	if err != nil {
+		return err
+	}
+	for i := range levels {
+		level := &levels[i]
+		value := 0.0
+		if level.Legacy && listData.LegacyPolicy == LegacyPointsFrozen {
+			var ok bool
+			value, ok = snapshotPoints[level.Id]
+			if !ok {
+				value = legacyPoints[level.Id]
+			}
+		} else if level.Position >= 1 && level.Position <= len(points) {
+			value = points[level.Position-1]
+		}
+		level.Points, err = strconv.ParseFloat(formatPoints(value, listData.PointPrecision), 64)
+		if err != nil {
+			return util.NewErrorResponse(err, "Failed to round points")
+		}
+	}
+	return nil
+}
+
+// pointFormulaDataAt returns the formula of the latest history version applied at or before the given time.
+// The first version holds the formula used before the first change, so it is used for earlier times as well.
+// Lists without any version use their current formula
+func pointFormulaDataAt(dao *daos.Dao, listData ListData, at types.DateTime) (PointFormulaData, error) {
+	versions, err := dao.FindRecordsByFilter(names.TablePointFormulaHistory, "list = {:list} && created <= {:at}", "-version", 1, 0,
+		dbx.Params{"list": listData.Name, "at": at.String()})
+	if err != nil {
+		return PointFormulaData{}, util.NewErrorResponse(err, "Failed to load formula history")
+	}
+	if len(versions) == 0 {
+		versions, err = dao.FindRecordsByFilter(names.TablePointFormulaHistory, "list = {:list}", "version", 1, 0,
+			dbx.Params{"list": listData.Name})
+		if err != nil {
+			return PointFormulaData{}, util.NewErrorResponse(err, "Failed to load formula history")
+		}
+	}
+	if len(versions) == 0 {
+		return loadPointFormulaData(dao, listData)
+	}
+	return pointFormulaDataFromRecord(versions[0]), nil
+}
@@ -0,0 +1,289 @@
+package demonlist
+
+import (
+	"AREDL/util"
+	"AREDL/webhook"
+	"fmt"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/models"
+)
+
+// LevelMove moves an existing level to a new position. Legacy is optional and keeps the current state if it is not set
+type LevelMove struct {
+	Id       string `json:"id"`
+	Position int    `json:"position"`
+	Legacy   *bool  `json:"legacy,omitempty"`
+}
+
+// LevelPlacement places a new level together with its verification
+type LevelPlacement struct {
+	Position         int      `json:"position"`
+	Legacy           bool     `json:"legacy"`
+	LevelId          int      `json:"level_id"`
+	Name             string   `json:"name"`
+	Publisher        string   `json:"publisher"`
+	LevelPassword    string   `json:"level_password"`
+	PercentToQualify int      `json:"percent_to_qualify"`
+	CreatorIds       []string `json:"creator_ids"`
+	Verification     struct {
+		SubmittedBy string `json:"submitted_by"`
+		VideoUrl    string `json:"video_url"`
+		Mobile      bool   `json:"mobile"`
+		RawFootage  string `json:"raw_footage"`
+	} `json:"verification"`
+}
+
+// ListUpdate is a set of placements and moves that is applied at once
+type ListUpdate struct {
+	Name       string
+	Changelog  string
+	Moves      []LevelMove
+	Placements []LevelPlacement
+}
+
+// listSlot is a position of the list after the update. Either level or placement is set
+type listSlot struct {
+	level     *listLevel
+	placement *LevelPlacement
+	legacy    bool
+	explicit  bool
+}
+
+type listLevel struct {
+	Id       string  `db:"id"`
+	Position int     `db:"position"`
+	Legacy   bool    `db:"legacy"`
+	Points   float64 `db:"points"`
+}
+
+// ApplyListUpdate places and moves all levels of the update in one transaction and returns the created list update.
+// Positions refer to the list after the update, levels that are not part of the update keep their order and fill the remaining positions.
+// All history entries are linked to the list update and points are only recalculated once
+func ApplyListUpdate(dao *daos.Dao, app core.App, listData ListData, userId string, update ListUpdate) (*models.Record, error) {
+	var listUpdateRecord *models.Record
+	err := dao.RunInTransaction(func(txDao *daos.Dao) error {
+		var levels []listLevel
+		err := txDao.DB().Select("id", "position", "legacy", "points").From(listData.LevelTableName).OrderBy("position").All(&levels)
+		if err != nil {
+			return util.NewErrorResponse(err, "Failed to load levels")
+		}
+		slots, err := buildListSlots(levels, update)
+		if err != nil {
+			return err
+		}
+
+		listUpdateRecord, err = util.AddRecordByCollectionName(txDao, app, listData.ListUpdatesTableName, map[string]any{
+			"name":      update.Name,
+			"changelog": update.Changelog,
+			"action_by": userId,
+		})
+		if err != nil {
+			return util.NewErrorResponse(err, "Failed to create list update")
+		}
+		historyCollection, err := txDao.FindCollectionByNameOrId(listData.HistoryTableName)
+		if err != nil {
+			return util.NewErrorResponse(err, "Failed to load collection")
+		}
+		addHistory := func(levelId string, action string, position int, cause string) error {
+			// saved as a record, so it is published to the event stream like every other history entry
+			historyRecord := models.NewRecord(historyCollection)
+			historyRecord.Load(map[string]any{
+				"level":        levelId,
+				"action":       action,
+				"new_position": position,
+				"cause":        cause,
+				"action_by":    userId,
+				"list_update":  listUpdateRecord.Id,
+			})
+			err := txDao.SaveRecord(historyRecord)
+			if err != nil {
+				return util.NewErrorResponse(err, "Failed to write history")
+			}
+			return nil
+		}
+
+		for i, slot := range slots {
+			position := i + 1
+			if slot.placement != nil {
+				levelRecord, err := placeListUpdateLevel(txDao, app, listData, userId, position, slot.placement)
+				if err != nil {
+					return err
+				}
+				err = addHistory(levelRecord.Id, "placed", position, levelRecord.Id)
+				if err != nil {
+					return err
+				}
+				err = webhook.Emit(txDao, listData.Name, webhook.EventLevelPlaced, map[string]any{
+					"level": levelRecord.PublicExport(),
+				})
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			level := slot.level
+			action, cause := slotHistory(slot, position)
+			if action == "" {
+				continue
+			}
+			legacyChanged := level.Legacy != slot.legacy
+			params := dbx.Params{"position": position, "legacy": slot.legacy}
+			if legacyChanged && slot.legacy {
+				// kept for the frozen legacy policy
+				params["legacy_points"] = level.Points
+			}
+			_, err = txDao.DB().Update(listData.LevelTableName, params, dbx.HashExp{"id": level.Id}).Execute()
+			if err != nil {
+				return util.NewErrorResponse(err, "Failed to update positions")
+			}
+			err = addHistory(level.Id, action, position, cause)
+			if err != nil {
+				return err
+			}
+			if slot.explicit {
+				err = webhook.Emit(txDao, listData.Name, util.If(legacyChanged && slot.legacy, webhook.EventLevelMovedToLegacy, webhook.EventLevelMoved), map[string]any{
+					"level":        map[string]any{"id": level.Id, "position": position, "legacy": slot.legacy},
+					"old_position": level.Position,
+					"new_position": position,
+				})
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		err = UpdatePointTable(txDao, listData)
+		if err != nil {
+			return err
+		}
+		return UpdateLevelListPointsByPositionRange(txDao, listData, 1, len(slots))
+	})
+	return listUpdateRecord, err
+}
+
+// buildListSlots calculates the order of the list after the update and validates it
+func buildListSlots(levels []listLevel, update ListUpdate) ([]listSlot, error) {
+	if len(update.Moves) == 0 && len(update.Placements) == 0 {
+		return nil, util.NewErrorResponse(nil, "List update does not change anything")
+	}
+	slots := make([]listSlot, len(levels)+len(update.Placements))
+	setSlot := func(position int, slot listSlot) error {
+		if position < 1 || position > len(slots) {
+			return util.NewErrorResponse(nil, fmt.Sprintf("Position %v is outside the list (1, %v)", position, len(slots)))
+		}
+		if slots[position-1].explicit {
+			return util.NewErrorResponse(nil, fmt.Sprintf("Position %v is used multiple times", position))
+		}
+		slots[position-1] = slot
+		return nil
+	}
+	levelsById := map[string]*listLevel{}
+	for i := range levels {
+		levelsById[levels[i].Id] = &levels[i]
+	}
+	moved := map[string]bool{}
+	for _, move := range update.Moves {
+		level, ok := levelsById[move.Id]
+		if !ok {
+			return nil, util.NewErrorResponse(nil, fmt.Sprintf("Level %s not found", move.Id))
+		}
+		if moved[move.Id] {
+			return nil, util.NewErrorResponse(nil, fmt.Sprintf("Level %s is moved multiple times", move.Id))
+		}
+		moved[move.Id] = true
+		legacy := level.Legacy
+		if move.Legacy != nil {
+			legacy = *move.Legacy
+		}
+		err := setSlot(move.Position, listSlot{level: level, legacy: legacy, explicit: true})
+		if err != nil {
+			return nil, err
+		}
+	}
+	for i := range update.Placements {
+		placement := &update.Placements[i]
+		if placement.LevelId < 1 || placement.Name == "" || placement.Publisher == "" ||
+			placement.Verification.SubmittedBy == "" || placement.Verification.VideoUrl == "" {
+			return nil, util.NewErrorResponse(nil, fmt.Sprintf("Placement at position %v is missing level data", placement.Position))
+		}
+		err := setSlot(placement.Position, listSlot{placement: placement, legacy: placement.Legacy, explicit: true})
+		if err != nil {
+			return nil, err
+		}
+	}
+	next := 0
+	for i := range slots {
+		if slots[i].explicit {
+			continue
+		}
+		for moved[levels[next].Id] {
+			next++
+		}
+		slots[i] = listSlot{level: &levels[next], legacy: levels[next].Legacy}
+		next++
+	}
+	for i := 1; i < len(slots); i++ {
+		if slots[i-1].legacy && !slots[i].legacy {
+			return nil, util.NewErrorResponse(nil, fmt.Sprintf("Position %v is not legacy but placed below a legacy level", i+1))
+		}
+	}
+	return slots, nil
+}
+
+// slotHistory returns the history action and cause of an existing level that ends up at the given position.
+// The action is empty if the level keeps its position and legacy state
+func slotHistory(slot listSlot, position int) (string, string) {
+	level := slot.level
+	legacyChanged := level.Legacy != slot.legacy
+	if level.Position == position && !legacyChanged {
+		return "", ""
+	}
+	moveUp := position < level.Position
+	switch {
+	case legacyChanged:
+		return util.If(slot.legacy, "movedToLegacy", "movedFromLegacy"), level.Id
+	case slot.explicit:
+		return util.If(moveUp, "movedUp", "movedDown"), level.Id
+	default:
+		// the level was moved by other changes of the update
+		return util.If(moveUp, "movedPastUp", "movedPastDown"), ""
+	}
+}
+
+func placeListUpdateLevel(dao *daos.Dao, app core.App, listData ListData, userId string, position int, placement *LevelPlacement) (*models.Record, error) {
+	levelData := map[string]any{
+		"position":       position,
+		"legacy":         placement.Legacy,
+		"level_id":       placement.LevelId,
+		"name":           placement.Name,
+		"publisher":      placement.Publisher,
+		"level_password": placement.LevelPassword,
+	}
+	if placement.PercentToQualify > 0 {
+		levelData["percent_to_qualify"] = placement.PercentToQualify
+	}
+	levelRecord, err := util.AddRecordByCollectionName(dao, app, listData.LevelTableName, levelData)
+	if err != nil {
+		return nil, util.NewErrorResponse(err, "Failed to add new level")
+	}
+	err = updateCreators(dao, listData, levelRecord.Id, placement.CreatorIds)
+	if err != nil {
+		return nil, err
+	}
+	_, err = util.AddRecordByCollectionName(dao, app, listData.RecordsTableName, map[string]any{
+		"level":           levelRecord.Id,
+		"submitted_by":    placement.Verification.SubmittedBy,
+		"video_url":       placement.Verification.VideoUrl,
+		"mobile":          placement.Verification.Mobile,
+		"raw_footage":     placement.Verification.RawFootage,
+		"percentage":      100,
+		"reviewer":        userId,
+		"placement_order": 1,
+	})
+	if err != nil {
+		return nil, util.NewErrorResponse(err, "Failed to add verification")
+	}
+	return levelRecord, nil
+}
@@ -0,0 +1,161 @@
+package demonlist
+
+import (
+	"strings"
+	"testing"
+)
+
+func testListLevels() []listLevel {
+	return []listLevel{
+		{Id: "a", Position: 1},
+		{Id: "b", Position: 2},
+		{Id: "c", Position: 3},
+		{Id: "d", Position: 4, Legacy: true},
+	}
+}
+
+func testPlacement(position int, name string, legacy bool) LevelPlacement {
+	placement := LevelPlacement{Position: position, Legacy: legacy, LevelId: 1, Name: name, Publisher: "publisher"}
+	placement.Verification.SubmittedBy = "verifier"
+	placement.Verification.VideoUrl = "https://example.com"
+	return placement
+}
+
+// slotNames returns the level id or the placement name of every slot, legacy slots are marked with a *
+func slotNames(slots []listSlot) string {
+	names := make([]string, len(slots))
+	for i, slot := range slots {
+		if slot.placement != nil {
+			names[i] = slot.placement.Name
+		} else {
+			names[i] = slot.level.Id
+		}
+		if slot.legacy {
+			names[i] += "*"
+		}
+	}
+	return strings.Join(names, " ")
+}
+
+func TestBuildListSlots(t *testing.T) {
+	legacy, notLegacy := true, false
+	tests := []struct {
+		name    string
+		update  ListUpdate
+		want    string
+		wantErr string
+	}{
+		{name: "empty update", update: ListUpdate{}, wantErr: "does not change anything"},
+		{name: "move up", update: ListUpdate{Moves: []LevelMove{{Id: "c", Position: 1}}}, want: "c a b d*"},
+		{name: "move down", update: ListUpdate{Moves: []LevelMove{{Id: "a", Position: 3}}}, want: "b c a d*"},
+		{name: "swap", update: ListUpdate{Moves: []LevelMove{{Id: "a", Position: 2}, {Id: "b", Position: 1}}}, want: "b a c d*"},
+		{name: "placement", update: ListUpdate{Placements: []LevelPlacement{testPlacement(2, "new", false)}}, want: "a new b c d*"},
+		{
+			name: "placements and moves",
+			update: ListUpdate{
+				Moves:      []LevelMove{{Id: "c", Position: 1}},
+				Placements: []LevelPlacement{testPlacement(3, "x", false), testPlacement(6, "y", true)},
+			},
+			want: "c a x b d* y*",
+		},
+		{name: "move to legacy", update: ListUpdate{Moves: []LevelMove{{Id: "c", Position: 3, Legacy: &legacy}}}, want: "a b c* d*"},
+		{name: "move from legacy", update: ListUpdate{Moves: []LevelMove{{Id: "d", Position: 1, Legacy: &notLegacy}}}, want: "d a b c"},
+		{name: "position outside the list", update: ListUpdate{Moves: []LevelMove{{Id: "a", Position: 5}}}, wantErr: "outside the list"},
+		{name: "position zero", update: ListUpdate{Placements: []LevelPlacement{testPlacement(0, "new", false)}}, wantErr: "outside the list"},
+		{name: "position used twice", update: ListUpdate{Moves: []LevelMove{{Id: "a", Position: 2}, {Id: "b", Position: 2}}}, wantErr: "used multiple times"},
+		{name: "level moved twice", update: ListUpdate{Moves: []LevelMove{{Id: "a", Position: 2}, {Id: "a", Position: 3}}}, wantErr: "moved multiple times"},
+		{name: "unknown level", update: ListUpdate{Moves: []LevelMove{{Id: "e", Position: 1}}}, wantErr: "not found"},
+		{name: "placement without data", update: ListUpdate{Placements: []LevelPlacement{{Position: 1}}}, wantErr: "missing level data"},
+		{name: "legacy above a level", update: ListUpdate{Moves: []LevelMove{{Id: "a", Position: 1, Legacy: &legacy}}}, wantErr: "Position 2 is not legacy"},
+		{name: "legacy level moved up", update: ListUpdate{Moves: []LevelMove{{Id: "d", Position: 2}}}, wantErr: "Position 3 is not legacy"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			slots, err := buildListSlots(testListLevels(), test.update)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("buildListSlots() error = %v, want it to contain %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("buildListSlots() failed: %v", err)
+			}
+			if got := slotNames(slots); got != test.want {
+				t.Errorf("buildListSlots() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestBuildListSlotsMarksExplicitChanges(t *testing.T) {
+	slots, err := buildListSlots(testListLevels(), ListUpdate{
+		Moves:      []LevelMove{{Id: "c", Position: 1}},
+		Placements: []LevelPlacement{testPlacement(2, "new", false)},
+	})
+	if err != nil {
+		t.Fatalf("buildListSlots() failed: %v", err)
+	}
+	want := []bool{true, true, false, false, false}
+	for i, slot := range slots {
+		if slot.explicit != want[i] {
+			t.Errorf("slot %d explicit = %v, want %v", i+1, slot.explicit, want[i])
+		}
+	}
+}
+
+func TestSlotHistory(t *testing.T) {
+	legacy, notLegacy := true, false
+	tests := []struct {
+		name   string
+		update ListUpdate
+		want   []string
+	}{
+		{
+			name:   "move up",
+			update: ListUpdate{Moves: []LevelMove{{Id: "c", Position: 1}}},
+			want:   []string{"movedUp c", "movedPastDown", "movedPastDown", ""},
+		},
+		{
+			name:   "move down",
+			update: ListUpdate{Moves: []LevelMove{{Id: "a", Position: 3}}},
+			want:   []string{"movedPastUp", "movedPastUp", "movedDown a", ""},
+		},
+		{
+			name:   "placement",
+			update: ListUpdate{Placements: []LevelPlacement{testPlacement(3, "new", false)}},
+			want:   []string{"", "", "placed", "movedPastDown", "movedPastDown"},
+		},
+		{
+			name:   "move to legacy in place",
+			update: ListUpdate{Moves: []LevelMove{{Id: "c", Position: 3, Legacy: &legacy}}},
+			want:   []string{"", "", "movedToLegacy c", ""},
+		},
+		{
+			name:   "move from legacy",
+			update: ListUpdate{Moves: []LevelMove{{Id: "d", Position: 1, Legacy: &notLegacy}}},
+			want:   []string{"movedFromLegacy d", "movedPastDown", "movedPastDown", "movedPastDown"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			slots, err := buildListSlots(testListLevels(), test.update)
+			if err != nil {
+				t.Fatalf("buildListSlots() failed: %v", err)
+			}
+			if len(slots) != len(test.want) {
+				t.Fatalf("buildListSlots() returned %d slots, want %d", len(slots), len(test.want))
+			}
+			for i, slot := range slots {
+				got := "placed"
+				if slot.placement == nil {
+					action, cause := slotHistory(slot, i+1)
+					got = strings.TrimSpace(action + " " + cause)
+				}
+				if got != test.want[i] {
+					t.Errorf("position %d history = %q, want %q", i+1, got, test.want[i])
+				}
+			}
+		})
+	}
+}
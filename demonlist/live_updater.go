@@ -0,0 +1,64 @@
+package demonlist
+
+import (
+	"AREDL/stream"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/models"
+)
+
+// RegisterLiveEvents publishes position history entries, accepted records and the submission queue size of every list
+// to the event stream. Model hooks inside a transaction only run after it has been committed, so rolled back changes are never published
+func RegisterLiveEvents(app core.App) {
+	for _, listData := range Lists() {
+		listData := listData
+		app.OnModelAfterCreate(listData.HistoryTableName).Add(func(e *core.ModelEvent) error {
+			record, ok := e.Model.(*models.Record)
+			if !ok {
+				return nil
+			}
+			stream.Publish(stream.Event{
+				Name:    "history",
+				List:    listData.Name,
+				LevelId: record.GetString("level"),
+				Data:    record.PublicExport(),
+			})
+			return nil
+		})
+		publishRecord := func(e *core.ModelEvent) error {
+			record, ok := e.Model.(*models.Record)
+			if !ok {
+				return nil
+			}
+			stream.Publish(stream.Event{
+				Name:    "record",
+				List:    listData.Name,
+				LevelId: record.GetString("level"),
+				UserId:  record.GetString("submitted_by"),
+				Data:    record.PublicExport(),
+			})
+			return nil
+		}
+		app.OnModelAfterCreate(listData.RecordsTableName).Add(publishRecord)
+		app.OnModelAfterUpdate(listData.RecordsTableName).Add(publishRecord)
+		publishQueue := func(e *core.ModelEvent) error {
+			var pending int
+			err := e.Dao.DB().Select("COUNT(*)").
+				From(listData.SubmissionsTableName).
+				Where(SubmissionStatusExp("status", SubmissionQueueStatuses)).
+				Row(&pending)
+			if err != nil {
+				app.Logger().Error("Failed to count submissions for the event stream", "error", err)
+				return nil
+			}
+			stream.Publish(stream.Event{
+				Name: "queue",
+				List: listData.Name,
+				Data: map[string]any{"pending": pending},
+			})
+			return nil
+		}
+		app.OnModelAfterCreate(listData.SubmissionsTableName).Add(publishQueue)
+		app.OnModelAfterUpdate(listData.SubmissionsTableName).Add(publishQueue)
+		app.OnModelAfterDelete(listData.SubmissionsTableName).Add(publishQueue)
+	}
+}
@@ -14,7 +14,6 @@ func MergeUsers(dao *daos.Dao, primaryId, secondaryId string) error {
 		return util.NewErrorResponse(nil, "Cannot merge user with itself")
 	}
 	err := dao.RunInTransaction(func(txDao *daos.Dao) error {
-		aredl := Aredl()
 		primaryUser, err := txDao.FindRecordById(names.TableUsers, primaryId)
 		if err != nil {
 			return err
@@ -23,24 +22,31 @@ func MergeUsers(dao *daos.Dao, primaryId, secondaryId string) error {
 		if err != nil {
 			return err
 		}
-		renassignTables := []struct {
+		type tableField struct {
 			Name  string
 			Field string
-		}{
-			{aredl.SubmissionsTableName, "submitted_by"},
-			{aredl.RecordsTableName, "submitted_by"},
-			{aredl.RecordsTableName, "reviewer"},
-			{aredl.HistoryTableName, "action_by"},
-			{aredl.CreatorTableName, "creator"},
+		}
+		renassignTables := []tableField{
 			{names.TableNameChangeRequests, "user"},
 			{names.TableRoles, "user"},
 		}
-		deleteTables := []struct {
-			Name  string
-			Field string
-		}{
-			{aredl.LeaderboardTableName, "user"},
-			{aredl.Packs.CompletedPacksTableName, "user"},
+		var deleteTables []tableField
+		for _, listData := range Lists() {
+			renassignTables = append(renassignTables,
+				tableField{listData.SubmissionsTableName, "submitted_by"},
+				tableField{listData.SubmissionCommentsTableName, "author"},
+				tableField{listData.RecordsTableName, "submitted_by"},
+				tableField{listData.RecordsTableName, "reviewer"},
+				tableField{listData.RecordTombstonesTableName, "submitted_by"},
+				tableField{listData.ArchivedRecordsTableName, "submitted_by"},
+				tableField{listData.HistoryTableName, "action_by"},
+				tableField{listData.CreatorTableName, "creator"},
+			)
+			deleteTables = append(deleteTables,
+				tableField{listData.LeaderboardTableName, "user"},
+				tableField{listData.LeaderboardHistoryTableName, "user"},
+				tableField{listData.Packs.CompletedPacksTableName, "user"},
+			)
 		}
 		for _, table := range renassignTables {
 			err = mergeTableData(txDao, primaryId, secondaryId, table.Name, table.Field)
@@ -58,13 +64,11 @@ func MergeUsers(dao *daos.Dao, primaryId, secondaryId string) error {
 		if err != nil {
 			return err
 		}
-		err = updateCompletedPacksByUser(txDao, aredl, primaryUser.Id)
-		if err != nil {
-			return err
-		}
-		err = UpdateLeaderboardByUserIds(txDao, aredl, []interface{}{primaryUser.Id})
-		if err != nil {
-			return err
+		for _, listData := range Lists() {
+			err = UpdateLeaderboardAndPacksForUser(txDao, listData, primaryUser.Id)
+			if err != nil {
+				return err
+			}
 		}
 		return nil
 	})
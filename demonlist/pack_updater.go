@@ -3,6 +3,7 @@ package demonlist
 import (
 	"AREDL/names"
 	"AREDL/util"
+	"AREDL/webhook"
 	"fmt"
 	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase/core"
@@ -31,48 +32,70 @@ func updatePackPointsByPackId(dao *daos.Dao, list ListData, packId string) error
 }
 
 func updatePackPoints(dao *daos.Dao, list ListData, condition string, params dbx.Params) error {
-	_, err := dao.DB().NewQuery(fmt.Sprintf(`
-		UPDATE %s 
-		SET points = CASE WHEN EXISTS (
+	list, err := loadPointSettings(dao, list)
+	if err != nil {
+		return err
+	}
+	levelCondition := ""
+	if list.Packs.LegacyPolicy == PackLegacyExclude {
+		levelCondition = "AND l.legacy = false"
+	}
+	points := fmt.Sprintf(`(
+			SELECT ROUND(COALESCE(SUM(l.points), 0)*%v,%d) 
+			FROM %s pl, %v l 
+			WHERE %s.id = pl.pack AND pl.level = l.id %s
+		)`,
+		list.Packs.PackMultiplier,
+		list.PointPrecision,
+		list.Packs.PackLevelTableName,
+		list.LevelTableName,
+		list.Packs.PackTableName,
+		levelCondition)
+	if list.Packs.LegacyPolicy == PackLegacyZero || list.Packs.LegacyPolicy == "" {
+		points = fmt.Sprintf(`CASE WHEN EXISTS (
 			SELECT NULL
 			FROM %s pl, %s l
 			WHERE %s.id = pl.pack AND pl.level = l.id AND l.legacy = true
 		) THEN 0 ELSE
-		(
-			SELECT ROUND(SUM(l.points)*%v,1) 
-			FROM %s pl, %v l 
-			WHERE %s.id = pl.pack AND pl.level = l.id
-		) END 
+		%s END`,
+			list.Packs.PackLevelTableName,
+			list.LevelTableName,
+			list.Packs.PackTableName,
+			points)
+	}
+	_, err = dao.DB().NewQuery(fmt.Sprintf(`
+		UPDATE %s 
+		SET points = %s 
 		%s`,
 		list.Packs.PackTableName,
-		list.Packs.PackLevelTableName,
-		list.LevelTableName,
-		list.Packs.PackTableName,
-		list.Packs.PackMultiplier,
-		list.Packs.PackLevelTableName,
-		list.LevelTableName,
-		list.Packs.PackTableName,
+		points,
 		condition,
 	)).Bind(params).Execute()
 	return err
 }
 
+// UpdateAllCompletedPacks recalculates the completed packs of every user.
+// A pack is completed once the user has a completion of each of its levels, a pack without levels can't be completed
 func UpdateAllCompletedPacks(dao *daos.Dao, list ListData) error {
 	err := dao.RunInTransaction(func(txDao *daos.Dao) error {
 		_, err := txDao.DB().NewQuery(fmt.Sprintf(`
 			DELETE FROM %s 
-			WHERE (
+			WHERE ((
 				SELECT COUNT(*) FROM %s pl 
 				WHERE pl.pack = %s.pack
 			) <> (
 				SELECT COUNT(*) FROM %s pl, %s rs 
-				WHERE pl.pack = %s.pack AND pl.level = rs.level AND rs.submitted_by = user
-			)`,
+				WHERE pl.pack = %s.pack AND pl.level = rs.level AND rs.submitted_by = user AND rs.percentage = 100
+			) OR NOT EXISTS (
+				SELECT NULL FROM %s pl WHERE pl.pack = %s.pack
+			))`,
 			list.Packs.CompletedPacksTableName,
 			list.Packs.PackLevelTableName,
 			list.Packs.CompletedPacksTableName,
 			list.Packs.PackLevelTableName,
 			list.RecordsTableName,
+			list.Packs.CompletedPacksTableName,
+			list.Packs.PackLevelTableName,
 			list.Packs.CompletedPacksTableName)).Execute()
 		if err != nil {
 			return err
@@ -85,14 +108,17 @@ func UpdateAllCompletedPacks(dao *daos.Dao, list ListData) error {
 				SELECT COUNT(*) FROM %s pl WHERE pl.pack = p.id
 			)=(
 				SELECT COUNT(*) FROM %s pl, %s rs 
-				WHERE pl.pack = p.id AND rs.submitted_by = u.id AND rs.level = pl.level
+				WHERE pl.pack = p.id AND rs.submitted_by = u.id AND rs.level = pl.level AND rs.percentage = 100
+			) AND EXISTS (
+				SELECT NULL FROM %s pl WHERE pl.pack = p.id
 			) ON CONFLICT DO NOTHING`,
 			list.Packs.CompletedPacksTableName,
 			names.TableUsers,
 			list.Packs.PackTableName,
 			list.Packs.PackLevelTableName,
 			list.Packs.PackLevelTableName,
-			list.RecordsTableName)).Execute()
+			list.RecordsTableName,
+			list.Packs.PackLevelTableName)).Execute()
 		return err
 	})
 	return err
@@ -102,20 +128,23 @@ func updateCompletedPacksByUser(dao *daos.Dao, list ListData, userId string) err
 	err := dao.RunInTransaction(func(txDao *daos.Dao) error {
 		_, err := txDao.DB().NewQuery(fmt.Sprintf(`
 			DELETE FROM %s 
-			WHERE (
+			WHERE ((
 				SELECT COUNT(*) FROM %s pl 
 				WHERE pl.pack = %s.pack
 			) <> (
 				SELECT COUNT(*) FROM %s pl, %s rs 
-				WHERE pl.pack = %s.pack AND pl.level = rs.level AND rs.submitted_by = user
-			) AND user = {:userId}`,
+				WHERE pl.pack = %s.pack AND pl.level = rs.level AND rs.submitted_by = user AND rs.percentage = 100
+			) OR NOT EXISTS (
+				SELECT NULL FROM %s pl WHERE pl.pack = %s.pack
+			)) AND user = {:userId}`,
 			list.Packs.CompletedPacksTableName,
 			list.Packs.PackLevelTableName,
 			list.Packs.CompletedPacksTableName,
 			list.Packs.PackLevelTableName,
 			list.RecordsTableName,
 			list.Packs.CompletedPacksTableName,
-		)).Bind(dbx.Params{"userId": userId}).Execute()
+			list.Packs.PackLevelTableName,
+			list.Packs.CompletedPacksTableName)).Bind(dbx.Params{"userId": userId}).Execute()
 		if err != nil {
 			return err
 		}
@@ -127,14 +156,17 @@ func updateCompletedPacksByUser(dao *daos.Dao, list ListData, userId string) err
 				SELECT COUNT(*) FROM %s pl WHERE pl.pack = p.id
 			)=(
 				SELECT COUNT(*) FROM %s pl, %s rs 
-				WHERE pl.pack = p.id AND rs.submitted_by = u.id AND rs.level = pl.level
+				WHERE pl.pack = p.id AND rs.submitted_by = u.id AND rs.level = pl.level AND rs.percentage = 100
+			) AND EXISTS (
+				SELECT NULL FROM %s pl WHERE pl.pack = p.id
 			) AND u.id = {:userId} ON CONFLICT DO NOTHING`,
 			list.Packs.CompletedPacksTableName,
 			names.TableUsers,
 			list.Packs.PackTableName,
 			list.Packs.PackLevelTableName,
 			list.Packs.PackLevelTableName,
-			list.RecordsTableName)).Bind(dbx.Params{"userId": userId}).Execute()
+			list.RecordsTableName,
+			list.Packs.PackLevelTableName)).Bind(dbx.Params{"userId": userId}).Execute()
 		return err
 	})
 	return err
@@ -149,19 +181,23 @@ func updateCompletedPacksByPackId(dao *daos.Dao, list ListData, packId string) (
 		var removedUserData []UserData
 		err := txDao.DB().NewQuery(fmt.Sprintf(`
 			DELETE FROM %s
-			WHERE (
+			WHERE ((
 				SELECT COUNT(*) FROM %s pl 
 				WHERE pl.pack = %s.pack
 			) <> (
 				SELECT COUNT(*) FROM %s pl, %s rs 
-				WHERE pl.pack = %s.pack AND pl.level = rs.level AND rs.submitted_by = user
-			) AND pack = {:packId}
+				WHERE pl.pack = %s.pack AND pl.level = rs.level AND rs.submitted_by = user AND rs.percentage = 100
+			) OR NOT EXISTS (
+				SELECT NULL FROM %s pl WHERE pl.pack = %s.pack
+			)) AND pack = {:packId}
 			RETURNING user`,
 			list.Packs.CompletedPacksTableName,
 			list.Packs.PackLevelTableName,
 			list.Packs.CompletedPacksTableName,
 			list.Packs.PackLevelTableName,
 			list.RecordsTableName,
+			list.Packs.CompletedPacksTableName,
+			list.Packs.PackLevelTableName,
 			list.Packs.CompletedPacksTableName)).Bind(dbx.Params{"packId": packId}).All(&removedUserData)
 		if err != nil {
 			return err
@@ -175,26 +211,30 @@ func updateCompletedPacksByPackId(dao *daos.Dao, list ListData, packId string) (
 				SELECT COUNT(*) FROM %s pl WHERE pl.pack = p.id
 			)=(
 				SELECT COUNT(*) FROM %s pl, %s rs 
-				WHERE pl.pack = p.id AND rs.submitted_by = u.id AND rs.level = pl.level
+				WHERE pl.pack = p.id AND rs.submitted_by = u.id AND rs.level = pl.level AND rs.percentage = 100
+			) AND EXISTS (
+				SELECT NULL FROM %s pl WHERE pl.pack = p.id
 			) AND p.id = {:packId} ON CONFLICT DO NOTHING`,
 			list.Packs.CompletedPacksTableName,
 			names.TableUsers,
 			list.Packs.PackTableName,
 			list.Packs.PackLevelTableName,
 			list.Packs.PackLevelTableName,
-			list.RecordsTableName)).Bind(dbx.Params{"packId": packId}).Execute()
+			list.RecordsTableName,
+			list.Packs.PackLevelTableName)).Bind(dbx.Params{"packId": packId}).Execute()
 		return err
 	})
 	return removedUsers, err
 }
 
-func UpsertPack(dao *daos.Dao, app core.App, listData ListData, packData map[string]interface{}) error {
+// UpsertPack creates a new pack or updates the pack with the id in packData and returns the saved pack
+func UpsertPack(dao *daos.Dao, app core.App, listData ListData, packData map[string]interface{}) (*models.Record, error) {
+	var packRecord *models.Record
 	err := dao.RunInTransaction(func(txDao *daos.Dao) error {
 		maxPlacementPos, err := queryMaxPlacementPosition(dao, listData)
 		if err != nil {
 			return util.NewErrorResponse(err, "Failed to query max placement position")
 		}
-		var packRecord *models.Record
 		var oldPos int
 		if packData["id"] != nil {
 			packRecordResult, err := txDao.FindRecordById(listData.Packs.PackTableName, packData["id"].(string))
@@ -285,9 +325,12 @@ func UpsertPack(dao *daos.Dao, app core.App, listData ListData, packData map[str
 				return util.NewErrorResponse(err, "Failed tu update users related to the pack")
 			}
 		}
-		return nil
+		return webhook.Emit(txDao, listData.Name, webhook.EventPackChanged, map[string]any{
+			"action": util.If(packData["id"] == nil, "created", "updated"),
+			"pack":   packRecord.PublicExport(),
+		})
 	})
-	return err
+	return packRecord, err
 }
 
 func DeletePack(dao *daos.Dao, listData ListData, recordId string) error {
@@ -329,7 +372,10 @@ func DeletePack(dao *daos.Dao, listData ListData, recordId string) error {
 		if err != nil {
 			return util.NewErrorResponse(err, "Failed to update users")
 		}
-		return nil
+		return webhook.Emit(txDao, listData.Name, webhook.EventPackChanged, map[string]any{
+			"action": "deleted",
+			"pack":   packRecord.PublicExport(),
+		})
 	})
 	return err
 }
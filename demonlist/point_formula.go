@@ -0,0 +1,169 @@
+package demonlist
+
+import (
+	"AREDL/names"
+	"AREDL/util"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/models"
+	"strconv"
+)
+
+func loadPointFormulaData(dao *daos.Dao, listData ListData) (PointFormulaData, error) {
+	formulaRecord, err := dao.FindFirstRecordByData(names.TablePointFormular, "list", listData.Name)
+	if err != nil {
+		return PointFormulaData{}, util.NewErrorResponse(nil, "failed to load formula data")
+	}
+	return pointFormulaDataFromRecord(formulaRecord), nil
+}
+
+// pointSettingsFromRecord applies the rounding precision and legacy policies stored on the point formula record to the list.
+// Settings that are not set keep the defaults of the list
+func pointSettingsFromRecord(listData ListData, record *models.Record) ListData {
+	if precision, err := strconv.Atoi(record.GetString("precision")); err == nil {
+		listData.PointPrecision = precision
+	}
+	if policy := record.GetString("legacy_policy"); policy != "" {
+		listData.LegacyPolicy = LegacyPolicy(policy)
+	}
+	if policy := record.GetString("pack_legacy_policy"); policy != "" {
+		listData.Packs.LegacyPolicy = PackLegacyPolicy(policy)
+	}
+	return listData
+}
+
+// loadPointSettings returns the list with the point settings stored on its point formula record.
+// They are loaded whenever points are calculated, so a change takes effect without a restart
+func loadPointSettings(dao *daos.Dao, listData ListData) (ListData, error) {
+	formulaRecord, err := dao.FindFirstRecordByData(names.TablePointFormular, "list", listData.Name)
+	if err != nil {
+		return listData, util.NewErrorResponse(err, "failed to load point settings")
+	}
+	return pointSettingsFromRecord(listData, formulaRecord), nil
+}
+
+func queryPointFormulaVariables(dao *daos.Dao, listData ListData) (pointFormulaVariables, error) {
+	levelCount, err := queryMaxPosition(dao, listData, false)
+	if err != nil {
+		return pointFormulaVariables{}, util.NewErrorResponse(nil, "failed to query max pos")
+	}
+	totalLevelCount, err := queryMaxPosition(dao, listData, true)
+	if err != nil {
+		return pointFormulaVariables{}, util.NewErrorResponse(nil, "failed to query max legacy pos")
+	}
+	return pointFormulaVariables{
+		LevelCount:  levelCount - 1,
+		LegacyCount: totalLevelCount - levelCount,
+		TotalCount:  totalLevelCount,
+	}, nil
+}
+
+// RecalculatePoints regenerates the point table of the list and updates the points of all levels, packs and the leaderboard
+func RecalculatePoints(dao *daos.Dao, listData ListData) error {
+	err := dao.RunInTransaction(func(txDao *daos.Dao) error {
+		err := UpdatePointTable(txDao, listData)
+		if err != nil {
+			return err
+		}
+		maxPos, err := queryMaxPosition(txDao, listData, true)
+		if err != nil {
+			return util.NewErrorResponse(nil, "Failed to query max pos")
+		}
+		err = UpdateLevelListPointsByPositionRange(txDao, listData, 1, maxPos)
+		if err != nil {
+			return util.NewErrorResponse(err, "Failed to update list points")
+		}
+		return nil
+	})
+	return err
+}
+
+// ApplyPointFormula sets the formula of the list, adds it as a new version to the formula history and recalculates all points
+func ApplyPointFormula(dao *daos.Dao, app core.App, listData ListData, userId string, data PointFormulaData) error {
+	listData, err := loadPointSettings(dao, listData)
+	if err != nil {
+		return err
+	}
+	err = ValidatePointFormula(dao, listData, data)
+	if err != nil {
+		return util.NewErrorResponse(err, "Invalid point formula")
+	}
+	err = dao.RunInTransaction(func(txDao *daos.Dao) error {
+		formulaRecord, err := txDao.FindFirstRecordByData(names.TablePointFormular, "list", listData.Name)
+		if err != nil {
+			return util.NewErrorResponse(err, "Failed to load formula data")
+		}
+		err = seedPointFormulaHistory(txDao, app, listData, pointFormulaDataFromRecord(formulaRecord))
+		if err != nil {
+			return err
+		}
+		setPointFormulaData(formulaRecord, data)
+		err = txDao.SaveRecord(formulaRecord)
+		if err != nil {
+			return util.NewErrorResponse(err, "Failed to save formula")
+		}
+		err = addPointFormulaHistory(txDao, app, listData, userId, "", data)
+		if err != nil {
+			return err
+		}
+		return RecalculatePoints(txDao, listData)
+	})
+	return err
+}
+
+// RollbackPointFormula reapplies the formula of the given history version. The rollback is added to the history as a new version
+func RollbackPointFormula(dao *daos.Dao, app core.App, listData ListData, userId string, version int) error {
+	err := dao.RunInTransaction(func(txDao *daos.Dao) error {
+		historyRecords, err := txDao.FindRecordsByExpr(names.TablePointFormulaHistory, dbx.HashExp{"list": listData.Name, "version": version})
+		if err != nil || len(historyRecords) != 1 {
+			return util.NewErrorResponse(err, "Formula version not found")
+		}
+		return ApplyPointFormula(txDao, app, listData, userId, pointFormulaDataFromRecord(historyRecords[0]))
+	})
+	return err
+}
+
+// seedPointFormulaHistory adds the formula that was in effect before the first change as the first version,
+// so the history always contains the original formula to roll back to
+func seedPointFormulaHistory(dao *daos.Dao, app core.App, listData ListData, data PointFormulaData) error {
+	var count int
+	err := dao.DB().Select("COUNT(*)").
+		From(names.TablePointFormulaHistory).
+		Where(dbx.HashExp{"list": listData.Name}).
+		Row(&count)
+	if err != nil {
+		return util.NewErrorResponse(err, "Failed to query formula history")
+	}
+	if count > 0 {
+		return nil
+	}
+	return addPointFormulaHistory(dao, app, listData, "", "", data)
+}
+
+// addPointFormulaHistory adds the formula as a new version. Changes made in the admin dashboard are applied by an admin instead of a user
+func addPointFormulaHistory(dao *daos.Dao, app core.App, listData ListData, userId string, adminId string, data PointFormulaData) error {
+	var version int
+	err := dao.DB().Select("COALESCE(MAX(version), 0)").
+		From(names.TablePointFormulaHistory).
+		Where(dbx.HashExp{"list": listData.Name}).
+		Row(&version)
+	if err != nil {
+		return util.NewErrorResponse(err, "Failed to query formula version")
+	}
+	version++
+	_, err = util.AddRecordByCollectionName(dao, app, names.TablePointFormulaHistory, map[string]any{
+		"list":             listData.Name,
+		"version":          version,
+		"formula":          data.Formula,
+		"precalc":          data.Precalc,
+		"legacy_formula":   data.LegacyFormula,
+		"progress_formula": data.ProgressFormula,
+		"applied_by":       userId,
+		"applied_by_admin": adminId,
+	})
+	if err != nil {
+		return util.NewErrorResponse(err, "Failed to add formula to history")
+	}
+	return nil
+}
@@ -0,0 +1,365 @@
+package demonlist
+
+import (
+	"AREDL/util"
+	"fmt"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/models"
+	"gopkg.in/Knetic/govaluate.v2"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+)
+
+// pointFormulaFunctions are the functions that can be used inside point formulas and precalcs
+var pointFormulaFunctions = map[string]govaluate.ExpressionFunction{
+	"sqrt":  mathFunction("sqrt", math.Sqrt),
+	"exp":   mathFunction("exp", math.Exp),
+	"ln":    mathFunction("ln", math.Log),
+	"floor": mathFunction("floor", math.Floor),
+	"ceil":  mathFunction("ceil", math.Ceil),
+	"pow": func(args ...interface{}) (interface{}, error) {
+		values, err := floatArgs("pow", 2, 2, args)
+		if err != nil {
+			return nil, err
+		}
+		return math.Pow(values[0], values[1]), nil
+	},
+	// log(value) uses base 10, log(value, base) uses the given base
+	"log": func(args ...interface{}) (interface{}, error) {
+		values, err := floatArgs("log", 1, 2, args)
+		if err != nil {
+			return nil, err
+		}
+		if len(values) == 2 {
+			return math.Log(values[0]) / math.Log(values[1]), nil
+		}
+		return math.Log10(values[0]), nil
+	},
+	"min": func(args ...interface{}) (interface{}, error) {
+		values, err := floatArgs("min", 1, -1, args)
+		if err != nil {
+			return nil, err
+		}
+		result := values[0]
+		for _, value := range values[1:] {
+			result = math.Min(result, value)
+		}
+		return result, nil
+	},
+	"max": func(args ...interface{}) (interface{}, error) {
+		values, err := floatArgs("max", 1, -1, args)
+		if err != nil {
+			return nil, err
+		}
+		result := values[0]
+		for _, value := range values[1:] {
+			result = math.Max(result, value)
+		}
+		return result, nil
+	},
+	// clamp(value, min, max)
+	"clamp": func(args ...interface{}) (interface{}, error) {
+		values, err := floatArgs("clamp", 3, 3, args)
+		if err != nil {
+			return nil, err
+		}
+		return math.Max(values[1], math.Min(values[2], values[0])), nil
+	},
+	// if(condition, then, else)
+	"if": func(args ...interface{}) (interface{}, error) {
+		if len(args) != 3 {
+			return nil, fmt.Errorf("if exactly takes three arguments")
+		}
+		condition, ok := args[0].(bool)
+		if !ok {
+			return nil, fmt.Errorf("first argument of if must be a condition")
+		}
+		if condition {
+			return args[1], nil
+		}
+		return args[2], nil
+	},
+}
+
+func mathFunction(name string, f func(float64) float64) govaluate.ExpressionFunction {
+	return func(args ...interface{}) (interface{}, error) {
+		values, err := floatArgs(name, 1, 1, args)
+		if err != nil {
+			return nil, err
+		}
+		return f(values[0]), nil
+	}
+}
+
+// floatArgs converts the arguments of a function to float64 and checks their count. A maxCount of -1 allows any number of arguments
+func floatArgs(name string, minCount int, maxCount int, args []interface{}) ([]float64, error) {
+	if len(args) < minCount || (maxCount != -1 && len(args) > maxCount) {
+		switch {
+		case minCount == maxCount:
+			return nil, fmt.Errorf("%s exactly takes %d argument(s)", name, minCount)
+		case maxCount == -1:
+			return nil, fmt.Errorf("%s takes at least %d argument(s)", name, minCount)
+		default:
+			return nil, fmt.Errorf("%s takes %d to %d arguments", name, minCount, maxCount)
+		}
+	}
+	values := make([]float64, len(args))
+	for i, arg := range args {
+		value, ok := arg.(float64)
+		if !ok {
+			return nil, fmt.Errorf("argument %d of %s must be a number", i+1, name)
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+// pointFormulaVariables are the variables available to a point formula.
+// LevelCount is the number of non-legacy levels minus one, LegacyCount the number of legacy levels and
+// TotalCount the number of all levels including legacy. Position and Enjoyment are only available
+// in the formula itself and not in the precalc.
+type pointFormulaVariables struct {
+	LevelCount  int
+	LegacyCount int
+	TotalCount  int
+}
+
+// PointFormulaData holds the formulas of a list as stored in the point formula collection.
+// The legacy formula is only used by lists with the formula legacy policy.
+// The progress formula calculates the points of records below 100%, without it progress records are worth 0 points.
+type PointFormulaData struct {
+	Formula         string
+	Precalc         string
+	LegacyFormula   string
+	ProgressFormula string
+}
+
+func pointFormulaDataFromRecord(record *models.Record) PointFormulaData {
+	return PointFormulaData{
+		Formula:         record.GetString("formula"),
+		Precalc:         record.GetString("precalc"),
+		LegacyFormula:   record.GetString("legacy_formula"),
+		ProgressFormula: record.GetString("progress_formula"),
+	}
+}
+
+func setPointFormulaData(record *models.Record, data PointFormulaData) {
+	record.Set("formula", data.Formula)
+	record.Set("precalc", data.Precalc)
+	record.Set("legacy_formula", data.LegacyFormula)
+	record.Set("progress_formula", data.ProgressFormula)
+}
+
+type pointFormula struct {
+	formula         *govaluate.EvaluableExpression
+	legacyFormula   *govaluate.EvaluableExpression
+	progressFormula *govaluate.EvaluableExpression
+	parameters      map[string]interface{}
+}
+
+// newPointFormula parses the formulas and evaluates the precalc with the given variables.
+// The precalc is a comma separated list of name=expression, each expression can use the results of the ones before it.
+func newPointFormula(data PointFormulaData, variables pointFormulaVariables) (*pointFormula, error) {
+	parameters := map[string]interface{}{
+		"level_count":  float64(variables.LevelCount),
+		"legacy_count": float64(variables.LegacyCount),
+		"total_count":  float64(variables.TotalCount),
+	}
+	for _, precalc := range strings.Split(data.Precalc, ",") {
+		precalc = strings.TrimSpace(precalc)
+		if len(precalc) == 0 {
+			continue
+		}
+		calc := strings.SplitN(precalc, "=", 2)
+		name := strings.TrimSpace(calc[0])
+		if len(calc) != 2 || name == "" {
+			return nil, fmt.Errorf("precalc \"%s\" is not in the format name=expression", precalc)
+		}
+		calcFormula, err := govaluate.NewEvaluableExpressionWithFunctions(calc[1], pointFormulaFunctions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse precalc %s: %w", name, err)
+		}
+		result, err := calcFormula.Evaluate(parameters)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate precalc %s: %w", name, err)
+		}
+		parameters[name] = result
+	}
+	formula, err := govaluate.NewEvaluableExpressionWithFunctions(data.Formula, pointFormulaFunctions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse formula: %w", err)
+	}
+	result := &pointFormula{formula: formula, parameters: parameters}
+	if strings.TrimSpace(data.LegacyFormula) != "" {
+		result.legacyFormula, err = govaluate.NewEvaluableExpressionWithFunctions(data.LegacyFormula, pointFormulaFunctions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse legacy formula: %w", err)
+		}
+	}
+	if strings.TrimSpace(data.ProgressFormula) != "" {
+		result.progressFormula, err = govaluate.NewEvaluableExpressionWithFunctions(data.ProgressFormula, pointFormulaFunctions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse progress formula: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// evaluate calculates the points for the given position and enjoyment of the level at that position
+func (p *pointFormula) evaluate(position int, enjoyment float64) (float64, error) {
+	value, err := p.evaluateExpression(p.formula, position, enjoyment, nil)
+	if err != nil {
+		return 0, fmt.Errorf("formula: %w", err)
+	}
+	return value, nil
+}
+
+// evaluateLegacy calculates the points for a legacy position. Without a legacy formula every legacy position is worth 0 points
+func (p *pointFormula) evaluateLegacy(position int, enjoyment float64) (float64, error) {
+	if p.legacyFormula == nil {
+		return 0, nil
+	}
+	value, err := p.evaluateExpression(p.legacyFormula, position, enjoyment, nil)
+	if err != nil {
+		return 0, fmt.Errorf("legacy formula: %w", err)
+	}
+	return value, nil
+}
+
+// evaluateProgress calculates the points of a progress record on the level at the given position.
+// Besides the usual variables the progress formula can use points (of the level), percentage and percent_to_qualify
+func (p *pointFormula) evaluateProgress(position int, enjoyment float64, points float64, percentage int, percentToQualify int) (float64, error) {
+	if p.progressFormula == nil {
+		return 0, nil
+	}
+	value, err := p.evaluateExpression(p.progressFormula, position, enjoyment, map[string]interface{}{
+		"points":             points,
+		"percentage":         float64(percentage),
+		"percent_to_qualify": float64(percentToQualify),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("progress formula: %w", err)
+	}
+	return value, nil
+}
+
+// evaluateExpression evaluates the expression with a copy of the parameters, so the variables of one evaluation
+// are never visible to another one
+func (p *pointFormula) evaluateExpression(expression *govaluate.EvaluableExpression, position int, enjoyment float64, variables map[string]interface{}) (float64, error) {
+	parameters := maps.Clone(p.parameters)
+	maps.Copy(parameters, variables)
+	parameters["x"] = float64(position)
+	parameters["enjoyment"] = enjoyment
+	result, err := expression.Evaluate(parameters)
+	if err != nil {
+		return 0, fmt.Errorf("failed to evaluate: %w", err)
+	}
+	value, ok := result.(float64)
+	if !ok {
+		return 0, fmt.Errorf("does not result in a number")
+	}
+	return value, nil
+}
+
+// usesEnjoyment reports whether the formula or legacy formula uses the enjoyment of the level at a position.
+// Only then the point table changes when levels swap positions without changing the level counts.
+// Formulas that can't be parsed are treated as using it, so the point table gets rebuilt and reports the error
+func usesEnjoyment(data PointFormulaData) bool {
+	for _, formula := range []string{data.Formula, data.LegacyFormula} {
+		if strings.TrimSpace(formula) == "" {
+			continue
+		}
+		expression, err := govaluate.NewEvaluableExpressionWithFunctions(formula, pointFormulaFunctions)
+		if err != nil || slices.Contains(expression.Vars(), "enjoyment") {
+			return true
+		}
+	}
+	return false
+}
+
+// formulaLevel holds the values of the level at a position that formulas can use
+type formulaLevel struct {
+	Position         int     `db:"position"`
+	Enjoyment        float64 `db:"enjoyment"`
+	PercentToQualify int     `db:"percent_to_qualify"`
+}
+
+// exampleFormulaVariables are used to validate formulas of lists without levels
+var exampleFormulaVariables = pointFormulaVariables{
+	LevelCount:  99,
+	LegacyCount: 10,
+	TotalCount:  110,
+}
+
+// ValidatePointFormula checks that the formulas and precalc can be parsed and result in a finite number for every position
+// of the list. Lists with the formula legacy policy also require a legacy formula.
+func ValidatePointFormula(dao *daos.Dao, listData ListData, data PointFormulaData) error {
+	variables, err := queryPointFormulaVariables(dao, listData)
+	if err != nil {
+		return err
+	}
+	var levels []formulaLevel
+	err = dao.DB().Select("position", "COALESCE(enjoyment, 0) AS enjoyment", "COALESCE(percent_to_qualify, 0) AS percent_to_qualify").
+		From(listData.LevelTableName).
+		All(&levels)
+	if err != nil {
+		return util.NewErrorResponse(err, "failed to load levels")
+	}
+	if variables.TotalCount == 0 {
+		variables = exampleFormulaVariables
+	}
+	return validatePointFormula(listData, data, variables, levels)
+}
+
+// validatePointFormula evaluates the formulas for every position up to the total count using the given levels.
+// Progress formulas are checked at the lowest qualifying percentage and at 99% of every position
+func validatePointFormula(listData ListData, data PointFormulaData, variables pointFormulaVariables, levels []formulaLevel) error {
+	if strings.TrimSpace(data.Formula) == "" {
+		return fmt.Errorf("formula can't be empty")
+	}
+	if listData.LegacyPolicy == LegacyPointsFormula && strings.TrimSpace(data.LegacyFormula) == "" {
+		return fmt.Errorf("legacy formula can't be empty when the list uses the formula legacy policy")
+	}
+	parsedFormula, err := newPointFormula(data, variables)
+	if err != nil {
+		return err
+	}
+	levelAt := make(map[int]formulaLevel, len(levels))
+	for _, level := range levels {
+		levelAt[level.Position] = level
+	}
+	checkFinite := func(value float64, err error, position int, name string) error {
+		if err != nil {
+			return fmt.Errorf("%w at position %d", err, position)
+		}
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return fmt.Errorf("%s does not result in a finite number at position %d", name, position)
+		}
+		return nil
+	}
+	for position := 1; position <= variables.TotalCount; position++ {
+		level := levelAt[position]
+		var points float64
+		var err error
+		if position <= variables.LevelCount+1 {
+			points, err = parsedFormula.evaluate(position, level.Enjoyment)
+			err = checkFinite(points, err, position, "formula")
+		} else if listData.LegacyPolicy == LegacyPointsFormula {
+			points, err = parsedFormula.evaluateLegacy(position, level.Enjoyment)
+			err = checkFinite(points, err, position, "legacy formula")
+		}
+		if err != nil {
+			return err
+		}
+		for _, percentage := range []int{min(max(level.PercentToQualify, 1), 99), 99} {
+			value, err := parsedFormula.evaluateProgress(position, level.Enjoyment, points, percentage, level.PercentToQualify)
+			err = checkFinite(value, err, position, "progress formula")
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
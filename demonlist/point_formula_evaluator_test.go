@@ -0,0 +1,193 @@
+package demonlist
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+var testFormulaVariables = pointFormulaVariables{
+	LevelCount:  9,
+	LegacyCount: 5,
+	TotalCount:  15,
+}
+
+func mustPointFormula(t *testing.T, data PointFormulaData) *pointFormula {
+	t.Helper()
+	formula, err := newPointFormula(data, testFormulaVariables)
+	if err != nil {
+		t.Fatalf("newPointFormula(%+v) failed: %v", data, err)
+	}
+	return formula
+}
+
+func TestPointFormulaEvaluate(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      PointFormulaData
+		position  int
+		enjoyment float64
+		want      float64
+		wantErr   bool
+	}{
+		{name: "position", data: PointFormulaData{Formula: "100 / x"}, position: 4, want: 25},
+		{name: "enjoyment", data: PointFormulaData{Formula: "enjoyment * 2"}, position: 1, enjoyment: 7.5, want: 15},
+		{name: "counts", data: PointFormulaData{Formula: "level_count + legacy_count + total_count"}, position: 1, want: 29},
+		{name: "precalc", data: PointFormulaData{Formula: "a * x", Precalc: "a = 2, b = a + 1"}, position: 3, want: 6},
+		{name: "precalc uses earlier result", data: PointFormulaData{Formula: "b", Precalc: "a = total_count, b = a * 2"}, position: 1, want: 30},
+		{name: "pow", data: PointFormulaData{Formula: "pow(x, 2)"}, position: 3, want: 9},
+		{name: "log base 10", data: PointFormulaData{Formula: "log(100)"}, position: 1, want: 2},
+		{name: "log with base", data: PointFormulaData{Formula: "log(8, 2)"}, position: 1, want: 3},
+		{name: "ln", data: PointFormulaData{Formula: "ln(exp(2))"}, position: 1, want: 2},
+		{name: "min and max", data: PointFormulaData{Formula: "min(x, 5, 3) + max(x, 5, 3)"}, position: 4, want: 8},
+		{name: "floor and ceil", data: PointFormulaData{Formula: "floor(2.7) + ceil(2.2)"}, position: 1, want: 5},
+		{name: "clamp", data: PointFormulaData{Formula: "clamp(x, 2, 5)"}, position: 9, want: 5},
+		{name: "if then", data: PointFormulaData{Formula: "if(x <= 3, 10, 1)"}, position: 2, want: 10},
+		{name: "if else", data: PointFormulaData{Formula: "if(x <= 3, 10, 1)"}, position: 4, want: 1},
+		{name: "unknown variable", data: PointFormulaData{Formula: "y * x"}, position: 1, wantErr: true},
+		{name: "wrong argument count", data: PointFormulaData{Formula: "pow(x)"}, position: 1, wantErr: true},
+		{name: "not a number", data: PointFormulaData{Formula: "x > 1"}, position: 2, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := mustPointFormula(t, test.data).evaluate(test.position, test.enjoyment)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("evaluate() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if !test.wantErr && math.Abs(got-test.want) > 1e-9 {
+				t.Errorf("evaluate() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestPointFormulaEvaluateLegacy(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     PointFormulaData
+		position int
+		want     float64
+	}{
+		{name: "without legacy formula", data: PointFormulaData{Formula: "100"}, position: 12, want: 0},
+		{name: "legacy formula", data: PointFormulaData{Formula: "100", LegacyFormula: "total_count - x"}, position: 12, want: 3},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := mustPointFormula(t, test.data).evaluateLegacy(test.position, 0)
+			if err != nil {
+				t.Fatalf("evaluateLegacy() failed: %v", err)
+			}
+			if got != test.want {
+				t.Errorf("evaluateLegacy() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestPointFormulaEvaluateProgress(t *testing.T) {
+	tests := []struct {
+		name             string
+		data             PointFormulaData
+		points           float64
+		percentage       int
+		percentToQualify int
+		want             float64
+	}{
+		{name: "without progress formula", data: PointFormulaData{Formula: "100"}, points: 100, percentage: 50, want: 0},
+		{name: "share of the points", data: PointFormulaData{Formula: "100", ProgressFormula: "points * percentage / 100"}, points: 80, percentage: 50, want: 40},
+		{
+			name:             "above qualify",
+			data:             PointFormulaData{Formula: "100", ProgressFormula: "points * (percentage - percent_to_qualify) / (100 - percent_to_qualify)"},
+			points:           100,
+			percentage:       75,
+			percentToQualify: 50,
+			want:             50,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := mustPointFormula(t, test.data).evaluateProgress(1, 0, test.points, test.percentage, test.percentToQualify)
+			if err != nil {
+				t.Fatalf("evaluateProgress() failed: %v", err)
+			}
+			if got != test.want {
+				t.Errorf("evaluateProgress() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestPointFormulaProgressVariablesDoNotLeak(t *testing.T) {
+	formula := mustPointFormula(t, PointFormulaData{Formula: "points", ProgressFormula: "points * percentage / 100"})
+	_, err := formula.evaluateProgress(1, 0, 100, 50, 0)
+	if err != nil {
+		t.Fatalf("evaluateProgress() failed: %v", err)
+	}
+	_, err = formula.evaluate(1, 0)
+	if err == nil {
+		t.Fatal("evaluate() used the variables of the progress formula")
+	}
+}
+
+func TestValidatePointFormula(t *testing.T) {
+	levels := []formulaLevel{{Position: 1, Enjoyment: 5, PercentToQualify: 60}, {Position: 2, Enjoyment: 0, PercentToQualify: 50}}
+	tests := []struct {
+		name    string
+		policy  LegacyPolicy
+		data    PointFormulaData
+		wantErr string
+	}{
+		{name: "valid", data: PointFormulaData{Formula: "100 / x", ProgressFormula: "points * percentage / 100"}},
+		{name: "empty formula", data: PointFormulaData{Formula: " "}, wantErr: "can't be empty"},
+		{name: "syntax error", data: PointFormulaData{Formula: "100 / (x"}, wantErr: "failed to parse formula"},
+		{name: "invalid precalc", data: PointFormulaData{Formula: "x", Precalc: "a"}, wantErr: "name=expression"},
+		{name: "division by zero at a later position", data: PointFormulaData{Formula: "100 / (x - 7)"}, wantErr: "position 7"},
+		{name: "zero enjoyment", data: PointFormulaData{Formula: "100 / enjoyment"}, wantErr: "position 2"},
+		{name: "nan", data: PointFormulaData{Formula: "sqrt(5 - x)"}, wantErr: "position 6"},
+		{name: "legacy formula ignored without the formula policy", data: PointFormulaData{Formula: "1", LegacyFormula: "1 / (x - 12)"}},
+		{name: "legacy formula required", policy: LegacyPointsFormula, data: PointFormulaData{Formula: "1"}, wantErr: "legacy formula can't be empty"},
+		{name: "invalid legacy position", policy: LegacyPointsFormula, data: PointFormulaData{Formula: "1", LegacyFormula: "1 / (x - 12)"}, wantErr: "position 12"},
+		{
+			name:    "progress division by zero at the qualify percentage",
+			data:    PointFormulaData{Formula: "1", ProgressFormula: "points / (percentage - percent_to_qualify)"},
+			wantErr: "progress formula does not result in a finite number at position 1",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			listData := Aredl()
+			listData.LegacyPolicy = test.policy
+			err := validatePointFormula(listData, test.data, testFormulaVariables, levels)
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatalf("validatePointFormula() failed: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Fatalf("validatePointFormula() error = %v, want it to contain %q", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestUsesEnjoyment(t *testing.T) {
+	tests := []struct {
+		name string
+		data PointFormulaData
+		want bool
+	}{
+		{name: "position only", data: PointFormulaData{Formula: "100 / x", Precalc: "a = level_count"}, want: false},
+		{name: "formula", data: PointFormulaData{Formula: "100 / x + enjoyment"}, want: true},
+		{name: "legacy formula", data: PointFormulaData{Formula: "100 / x", LegacyFormula: "enjoyment / 10"}, want: true},
+		{name: "progress formula", data: PointFormulaData{Formula: "100 / x", ProgressFormula: "points * enjoyment"}, want: false},
+		{name: "invalid formula", data: PointFormulaData{Formula: "100 / ("}, want: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := usesEnjoyment(test.data); got != test.want {
+				t.Errorf("usesEnjoyment() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,387 @@
+package demonlist
+
+import (
+	"AREDL/names"
+	"AREDL/util"
+	"fmt"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	"math"
+	"sort"
+)
+
+type PositionPointsPreview struct {
+	Position  int     `json:"position"`
+	LevelId   string  `json:"level_id,omitempty"`
+	LevelName string  `json:"level_name,omitempty"`
+	OldPoints float64 `json:"old_points"`
+	NewPoints float64 `json:"new_points"`
+}
+
+type PackPointsPreview struct {
+	Id        string  `json:"id"`
+	Name      string  `json:"name"`
+	OldPoints float64 `json:"old_points"`
+	NewPoints float64 `json:"new_points"`
+}
+
+type LeaderboardPreview struct {
+	UserId     string  `json:"user_id"`
+	GlobalName string  `json:"global_name"`
+	OldRank    int     `json:"old_rank,omitempty"`
+	NewRank    int     `json:"new_rank,omitempty"`
+	OldPoints  float64 `json:"old_points"`
+	NewPoints  float64 `json:"new_points"`
+}
+
+type PointFormulaPreview struct {
+	Positions   []PositionPointsPreview `json:"positions"`
+	Packs       []PackPointsPreview     `json:"packs"`
+	Leaderboard []LeaderboardPreview    `json:"leaderboard"`
+}
+
+type positionPoints struct {
+	Position  int     `db:"position"`
+	LevelId   string  `db:"level_id"`
+	LevelName string  `db:"level_name"`
+	Points    float64 `db:"points"`
+}
+
+type packPoints struct {
+	Id     string  `db:"id"`
+	Name   string  `db:"name"`
+	Points float64 `db:"points"`
+}
+
+type leaderboardEntry struct {
+	UserId     string  `db:"user"`
+	GlobalName string  `db:"global_name"`
+	Rank       int     `db:"rank"`
+	Points     float64 `db:"points"`
+}
+
+type pointState struct {
+	Positions   []positionPoints
+	Packs       []packPoints
+	Leaderboard []leaderboardEntry
+}
+
+// PreviewPointFormula calculates the points the given formulas would result in and returns the changes compared to the
+// current points. The new points are calculated in memory from the current levels, records and completed packs, nothing is written.
+// The leaderboard part contains every user that is in the top leaderboardTop before or after the change.
+func PreviewPointFormula(dao *daos.Dao, listData ListData, data PointFormulaData, leaderboardTop int) (PointFormulaPreview, error) {
+	listData, err := loadPointSettings(dao, listData)
+	if err != nil {
+		return PointFormulaPreview{}, err
+	}
+	err = ValidatePointFormula(dao, listData, data)
+	if err != nil {
+		return PointFormulaPreview{}, util.NewErrorResponse(err, "Invalid point formula")
+	}
+	oldState, err := loadPointState(dao, listData)
+	if err != nil {
+		return PointFormulaPreview{}, err
+	}
+	variables, err := queryPointFormulaVariables(dao, listData)
+	if err != nil {
+		return PointFormulaPreview{}, err
+	}
+	input, err := loadPointInput(dao, listData)
+	if err != nil {
+		return PointFormulaPreview{}, err
+	}
+	newState, err := calculatePointState(listData, data, variables, input)
+	if err != nil {
+		return PointFormulaPreview{}, err
+	}
+	return diffPointStates(oldState, newState, leaderboardTop), nil
+}
+
+type previewLevel struct {
+	Id               string  `db:"id"`
+	Name             string  `db:"name"`
+	Position         int     `db:"position"`
+	Legacy           bool    `db:"legacy"`
+	Enjoyment        float64 `db:"enjoyment"`
+	PercentToQualify int     `db:"percent_to_qualify"`
+	LegacyPoints     float64 `db:"legacy_points"`
+}
+
+type previewPackLevel struct {
+	Pack  string `db:"pack"`
+	Level string `db:"level"`
+}
+
+type previewRecord struct {
+	UserId     string `db:"user"`
+	GlobalName string `db:"global_name"`
+	Level      string `db:"level"`
+	Percentage int    `db:"percentage"`
+}
+
+type previewCompletedPack struct {
+	UserId string `db:"user"`
+	Pack   string `db:"pack"`
+}
+
+// pointInput holds everything the points of a list are calculated from
+type pointInput struct {
+	Levels         []previewLevel
+	Packs          []packPoints
+	PackLevels     []previewPackLevel
+	Records        []previewRecord
+	CompletedPacks []previewCompletedPack
+}
+
+func loadPointInput(dao *daos.Dao, listData ListData) (pointInput, error) {
+	var input pointInput
+	err := dao.DB().Select(
+		"id",
+		"name",
+		"position",
+		"legacy",
+		"COALESCE(enjoyment, 0) AS enjoyment",
+		"COALESCE(percent_to_qualify, 0) AS percent_to_qualify",
+		"COALESCE(legacy_points, 0) AS legacy_points").
+		From(listData.LevelTableName).
+		OrderBy("position").
+		All(&input.Levels)
+	if err != nil {
+		return input, util.NewErrorResponse(err, "Failed to load levels")
+	}
+	err = dao.DB().Select("id", "name").
+		From(listData.Packs.PackTableName).
+		OrderBy("placement_order").
+		All(&input.Packs)
+	if err != nil {
+		return input, util.NewErrorResponse(err, "Failed to load packs")
+	}
+	err = dao.DB().Select("pack", "level").From(listData.Packs.PackLevelTableName).All(&input.PackLevels)
+	if err != nil {
+		return input, util.NewErrorResponse(err, "Failed to load pack levels")
+	}
+	// banned users are never on the leaderboard, so their records are not needed
+	err = dao.DB().Select("rs.submitted_by AS user", "u.global_name AS global_name", "rs.level AS level", "rs.percentage AS percentage").
+		From(listData.RecordsTableName+" rs").
+		InnerJoin(names.TableUsers+" u", dbx.NewExp("u.id = rs.submitted_by")).
+		Where(dbx.NewExp("COALESCE(u.banned_from_list, 0) <> 1")).
+		All(&input.Records)
+	if err != nil {
+		return input, util.NewErrorResponse(err, "Failed to load records")
+	}
+	err = dao.DB().Select("user", "pack").From(listData.Packs.CompletedPacksTableName).All(&input.CompletedPacks)
+	if err != nil {
+		return input, util.NewErrorResponse(err, "Failed to load completed packs")
+	}
+	return input, nil
+}
+
+// calculatePointState calculates the point table, the pack points and the leaderboard the formulas result in.
+// It follows the same rules as RecalculatePoints, including the rounding after every step
+func calculatePointState(listData ListData, data PointFormulaData, variables pointFormulaVariables, input pointInput) (pointState, error) {
+	var state pointState
+	precision := listData.PointPrecision
+	levelAt := make(map[int]previewLevel, len(input.Levels))
+	enjoyments := make(map[int]float64, len(input.Levels))
+	for _, level := range input.Levels {
+		levelAt[level.Position] = level
+		enjoyments[level.Position] = level.Enjoyment
+	}
+	table, err := calculatePointTable(listData, data, variables, enjoyments)
+	if err != nil {
+		return state, err
+	}
+	for i, value := range table {
+		level := levelAt[i+1]
+		state.Positions = append(state.Positions, positionPoints{
+			Position:  i + 1,
+			LevelId:   level.Id,
+			LevelName: level.Name,
+			Points:    roundPoints(value, precision),
+		})
+	}
+
+	levels := make(map[string]previewLevel, len(input.Levels))
+	levelPoints := make(map[string]float64, len(input.Levels))
+	for _, level := range input.Levels {
+		levels[level.Id] = level
+		switch {
+		case level.Legacy && listData.LegacyPolicy == LegacyPointsFrozen:
+			levelPoints[level.Id] = level.LegacyPoints
+		case level.Position >= 1 && level.Position <= len(table):
+			levelPoints[level.Id] = roundPoints(table[level.Position-1], precision)
+		}
+	}
+
+	packLevels := make(map[string][]string)
+	for _, packLevel := range input.PackLevels {
+		packLevels[packLevel.Pack] = append(packLevels[packLevel.Pack], packLevel.Level)
+	}
+	packPointsById := make(map[string]float64, len(input.Packs))
+	for _, pack := range input.Packs {
+		var sum float64
+		hasLegacy := false
+		for _, levelId := range packLevels[pack.Id] {
+			level, exists := levels[levelId]
+			if !exists {
+				continue
+			}
+			hasLegacy = hasLegacy || level.Legacy
+			if level.Legacy && listData.Packs.LegacyPolicy == PackLegacyExclude {
+				continue
+			}
+			sum += levelPoints[levelId]
+		}
+		points := roundPoints(sum*listData.Packs.PackMultiplier, precision)
+		if hasLegacy && (listData.Packs.LegacyPolicy == PackLegacyZero || listData.Packs.LegacyPolicy == "") {
+			points = 0
+		}
+		packPointsById[pack.Id] = points
+		state.Packs = append(state.Packs, packPoints{Id: pack.Id, Name: pack.Name, Points: points})
+	}
+
+	formula, err := newPointFormula(data, variables)
+	if err != nil {
+		return state, util.NewErrorResponse(err, "invalid point formula")
+	}
+	recordPoints := make(map[string]float64)
+	var users []leaderboardEntry
+	for _, record := range input.Records {
+		level, exists := levels[record.Level]
+		if !exists {
+			continue
+		}
+		if _, exists := recordPoints[record.UserId]; !exists {
+			recordPoints[record.UserId] = 0
+			users = append(users, leaderboardEntry{UserId: record.UserId, GlobalName: record.GlobalName})
+		}
+		if record.Percentage >= 100 {
+			recordPoints[record.UserId] += levelPoints[level.Id]
+			continue
+		}
+		value, err := formula.evaluateProgress(level.Position, level.Enjoyment, levelPoints[level.Id], record.Percentage, level.PercentToQualify)
+		if err != nil {
+			return state, util.NewErrorResponse(err, fmt.Sprintf("invalid progress formula at position %d", level.Position))
+		}
+		if value < 0.0 || math.IsNaN(value) || math.IsInf(value, 0) {
+			value = 0.0
+		}
+		recordPoints[record.UserId] += roundPoints(value, precision)
+	}
+	completedPackPoints := make(map[string]float64)
+	for _, completedPack := range input.CompletedPacks {
+		completedPackPoints[completedPack.UserId] += packPointsById[completedPack.Pack]
+	}
+	for _, user := range users {
+		user.Points = roundPoints(roundPoints(recordPoints[user.UserId], precision)+roundPoints(completedPackPoints[user.UserId], precision), precision)
+		if user.Points != 0 {
+			state.Leaderboard = append(state.Leaderboard, user)
+		}
+	}
+	sort.SliceStable(state.Leaderboard, func(i, j int) bool {
+		return state.Leaderboard[i].Points > state.Leaderboard[j].Points
+	})
+	for i := range state.Leaderboard {
+		state.Leaderboard[i].Rank = i + 1
+		if i > 0 && state.Leaderboard[i].Points == state.Leaderboard[i-1].Points {
+			state.Leaderboard[i].Rank = state.Leaderboard[i-1].Rank
+		}
+	}
+	return state, nil
+}
+
+func loadPointState(dao *daos.Dao, listData ListData) (pointState, error) {
+	var state pointState
+	err := dao.DB().NewQuery(fmt.Sprintf(`
+		SELECT p.id AS position, COALESCE(l.id, '') AS level_id, COALESCE(l.name, '') AS level_name, p.points AS points
+		FROM %s p
+		LEFT JOIN %s l ON l.position = p.id
+		ORDER BY p.id`,
+		listData.PointLookupTableName,
+		listData.LevelTableName)).All(&state.Positions)
+	if err != nil {
+		return state, util.NewErrorResponse(err, "Failed to load point table")
+	}
+	err = dao.DB().Select("id", "name", "COALESCE(points, 0) AS points").
+		From(listData.Packs.PackTableName).
+		OrderBy("placement_order").
+		All(&state.Packs)
+	if err != nil {
+		return state, util.NewErrorResponse(err, "Failed to load packs")
+	}
+	err = dao.DB().NewQuery(fmt.Sprintf(`
+		SELECT lb.user AS user, u.global_name AS global_name, lb.rank AS rank, lb.points AS points
+		FROM %s lb
+		JOIN %s u ON u.id = lb.user
+		ORDER BY lb.rank`,
+		listData.LeaderboardTableName,
+		names.TableUsers)).All(&state.Leaderboard)
+	if err != nil {
+		return state, util.NewErrorResponse(err, "Failed to load leaderboard")
+	}
+	return state, nil
+}
+
+func diffPointStates(oldState pointState, newState pointState, leaderboardTop int) PointFormulaPreview {
+	var preview PointFormulaPreview
+
+	oldPositions := make(map[int]positionPoints, len(oldState.Positions))
+	for _, entry := range oldState.Positions {
+		oldPositions[entry.Position] = entry
+	}
+	for _, entry := range newState.Positions {
+		preview.Positions = append(preview.Positions, PositionPointsPreview{
+			Position:  entry.Position,
+			LevelId:   entry.LevelId,
+			LevelName: entry.LevelName,
+			OldPoints: oldPositions[entry.Position].Points,
+			NewPoints: entry.Points,
+		})
+	}
+
+	oldPacks := make(map[string]float64, len(oldState.Packs))
+	for _, pack := range oldState.Packs {
+		oldPacks[pack.Id] = pack.Points
+	}
+	for _, pack := range newState.Packs {
+		preview.Packs = append(preview.Packs, PackPointsPreview{
+			Id:        pack.Id,
+			Name:      pack.Name,
+			OldPoints: oldPacks[pack.Id],
+			NewPoints: pack.Points,
+		})
+	}
+
+	users := make(map[string]*LeaderboardPreview)
+	for _, entry := range oldState.Leaderboard {
+		users[entry.UserId] = &LeaderboardPreview{
+			UserId:     entry.UserId,
+			GlobalName: entry.GlobalName,
+			OldRank:    entry.Rank,
+			OldPoints:  entry.Points,
+		}
+	}
+	for _, entry := range newState.Leaderboard {
+		user, exists := users[entry.UserId]
+		if !exists {
+			user = &LeaderboardPreview{UserId: entry.UserId, GlobalName: entry.GlobalName}
+			users[entry.UserId] = user
+		}
+		user.NewRank = entry.Rank
+		user.NewPoints = entry.Points
+	}
+	isTop := func(rank int) bool { return rank > 0 && rank <= leaderboardTop }
+	for _, user := range users {
+		if isTop(user.OldRank) || isTop(user.NewRank) {
+			preview.Leaderboard = append(preview.Leaderboard, *user)
+		}
+	}
+	// users that dropped off the leaderboard are sorted behind everyone that is still on it
+	sortRank := func(user LeaderboardPreview) int {
+		return util.If(user.NewRank == 0, len(users)+user.OldRank, user.NewRank)
+	}
+	sort.SliceStable(preview.Leaderboard, func(i, j int) bool {
+		return sortRank(preview.Leaderboard[i]) < sortRank(preview.Leaderboard[j])
+	})
+	return preview
+}
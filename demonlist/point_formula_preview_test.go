@@ -0,0 +1,80 @@
+package demonlist
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCalculatePointState(t *testing.T) {
+	listData := ListData{PointPrecision: 1, LegacyPolicy: LegacyPointsZero, Packs: PackData{PackMultiplier: 0.5, LegacyPolicy: PackLegacyZero}}
+	data := PointFormulaData{Formula: "100 / x", ProgressFormula: "points * percentage / 100"}
+	variables := pointFormulaVariables{LevelCount: 2, LegacyCount: 1, TotalCount: 4}
+	input := pointInput{
+		Levels: []previewLevel{
+			{Id: "a", Name: "A", Position: 1},
+			{Id: "b", Name: "B", Position: 2},
+			{Id: "c", Name: "C", Position: 3},
+			{Id: "d", Name: "D", Position: 4, Legacy: true},
+		},
+		Packs:      []packPoints{{Id: "ab", Name: "AB"}, {Id: "cd", Name: "CD"}},
+		PackLevels: []previewPackLevel{{Pack: "ab", Level: "a"}, {Pack: "ab", Level: "b"}, {Pack: "cd", Level: "c"}, {Pack: "cd", Level: "d"}},
+		Records: []previewRecord{
+			{UserId: "u1", GlobalName: "U1", Level: "a", Percentage: 100},
+			{UserId: "u1", GlobalName: "U1", Level: "b", Percentage: 100},
+			{UserId: "u2", GlobalName: "U2", Level: "b", Percentage: 100},
+			{UserId: "u2", GlobalName: "U2", Level: "c", Percentage: 100},
+			{UserId: "u2", GlobalName: "U2", Level: "a", Percentage: 83},
+			{UserId: "u3", GlobalName: "U3", Level: "c", Percentage: 50},
+			{UserId: "u4", GlobalName: "U4", Level: "d", Percentage: 100},
+		},
+		CompletedPacks: []previewCompletedPack{{UserId: "u1", Pack: "ab"}},
+	}
+	state, err := calculatePointState(listData, data, variables, input)
+	if err != nil {
+		t.Fatalf("calculatePointState() failed: %v", err)
+	}
+	wantPositions := []positionPoints{
+		{Position: 1, LevelId: "a", LevelName: "A", Points: 100},
+		{Position: 2, LevelId: "b", LevelName: "B", Points: 50},
+		{Position: 3, LevelId: "c", LevelName: "C", Points: 33.3},
+		{Position: 4, LevelId: "d", LevelName: "D", Points: 0},
+	}
+	if !reflect.DeepEqual(state.Positions, wantPositions) {
+		t.Errorf("positions = %+v, want %+v", state.Positions, wantPositions)
+	}
+	wantPacks := []packPoints{{Id: "ab", Name: "AB", Points: 75}, {Id: "cd", Name: "CD", Points: 0}}
+	if !reflect.DeepEqual(state.Packs, wantPacks) {
+		t.Errorf("packs = %+v, want %+v", state.Packs, wantPacks)
+	}
+	// u4 only has a legacy level worth 0 points and is not on the leaderboard
+	wantLeaderboard := []leaderboardEntry{
+		{UserId: "u1", GlobalName: "U1", Rank: 1, Points: 225},
+		{UserId: "u2", GlobalName: "U2", Rank: 2, Points: 166.3},
+		{UserId: "u3", GlobalName: "U3", Rank: 3, Points: 16.7},
+	}
+	if !reflect.DeepEqual(state.Leaderboard, wantLeaderboard) {
+		t.Errorf("leaderboard = %+v, want %+v", state.Leaderboard, wantLeaderboard)
+	}
+}
+
+func TestCalculatePointStateSharesRanks(t *testing.T) {
+	input := pointInput{
+		Levels: []previewLevel{{Id: "a", Position: 1}, {Id: "b", Position: 2}},
+		Records: []previewRecord{
+			{UserId: "u1", Level: "b", Percentage: 100},
+			{UserId: "u2", Level: "a", Percentage: 100},
+			{UserId: "u3", Level: "b", Percentage: 100},
+		},
+	}
+	state, err := calculatePointState(ListData{}, PointFormulaData{Formula: "10"}, pointFormulaVariables{LevelCount: 1, TotalCount: 2}, input)
+	if err != nil {
+		t.Fatalf("calculatePointState() failed: %v", err)
+	}
+	var ranks []int
+	for _, entry := range state.Leaderboard {
+		ranks = append(ranks, entry.Rank)
+	}
+	if !reflect.DeepEqual(ranks, []int{1, 1, 1}) {
+		t.Errorf("ranks = %v, want every user to share the first rank", ranks)
+	}
+}
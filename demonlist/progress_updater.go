@@ -0,0 +1,86 @@
+package demonlist
+
+import (
+	"AREDL/util"
+	"fmt"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	"math"
+)
+
+// Records with a percentage below 100 are progress records. They are worth the points of the progress formula
+// instead of the level points and do not count towards packs. Completions always have a percentage of 100.
+
+func updateProgressPointsByLevelRange(dao *daos.Dao, listData ListData, minPos int, maxPos int) error {
+	return updateProgressPoints(dao, listData, dbx.Between("l.position", minPos, maxPos))
+}
+
+func updateProgressPointsByUser(dao *daos.Dao, listData ListData, userId string) error {
+	return updateProgressPoints(dao, listData, dbx.HashExp{"rs.submitted_by": userId})
+}
+
+// updateProgressPoints recalculates the points of all progress records matching the condition.
+// The condition can use rs for the records and l for the levels table
+func updateProgressPoints(dao *daos.Dao, listData ListData, condition dbx.Expression) error {
+	err := dao.RunInTransaction(func(txDao *daos.Dao) error {
+		listData, err := loadPointSettings(txDao, listData)
+		if err != nil {
+			return err
+		}
+		var records []struct {
+			Id               string  `db:"id"`
+			Position         int     `db:"position"`
+			Enjoyment        float64 `db:"enjoyment"`
+			Points           float64 `db:"points"`
+			Percentage       int     `db:"percentage"`
+			PercentToQualify int     `db:"percent_to_qualify"`
+		}
+		err = txDao.DB().Select(
+			"rs.id AS id",
+			"l.position AS position",
+			"COALESCE(l.enjoyment, 0) AS enjoyment",
+			"l.points AS points",
+			"rs.percentage AS percentage",
+			"COALESCE(l.percent_to_qualify, 0) AS percent_to_qualify").
+			From(listData.RecordsTableName+" rs").
+			InnerJoin(listData.LevelTableName+" l", dbx.NewExp("rs.level = l.id")).
+			Where(dbx.NewExp("rs.percentage < 100")).
+			AndWhere(condition).
+			All(&records)
+		if err != nil {
+			return util.NewErrorResponse(err, "Failed to load progress records")
+		}
+		if len(records) == 0 {
+			return nil
+		}
+		data, err := loadPointFormulaData(txDao, listData)
+		if err != nil {
+			return err
+		}
+		variables, err := queryPointFormulaVariables(txDao, listData)
+		if err != nil {
+			return err
+		}
+		formula, err := newPointFormula(data, variables)
+		if err != nil {
+			return util.NewErrorResponse(err, "invalid point formula")
+		}
+		for _, record := range records {
+			value, err := formula.evaluateProgress(record.Position, record.Enjoyment, record.Points, record.Percentage, record.PercentToQualify)
+			if err != nil {
+				return util.NewErrorResponse(err, fmt.Sprintf("invalid progress formula at position %d", record.Position))
+			}
+			if value < 0.0 || math.IsNaN(value) || math.IsInf(value, 0) {
+				value = 0.0
+			}
+			_, err = txDao.DB().Update(listData.RecordsTableName,
+				dbx.Params{"points": formatPoints(value, listData.PointPrecision)},
+				dbx.HashExp{"id": record.Id}).Execute()
+			if err != nil {
+				return util.NewErrorResponse(err, "Failed to update progress points")
+			}
+		}
+		return nil
+	})
+	return err
+}
@@ -0,0 +1,152 @@
+package demonlist
+
+import (
+	"AREDL/util"
+	"fmt"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/forms"
+	"github.com/pocketbase/pocketbase/models"
+)
+
+// RemoveRecord deletes the record, moves the records placed after it on the same level up and keeps a tombstone
+// with the reason, so the player can see why the record was removed. Completed packs and the leaderboard of the player are recalculated.
+// Submissions of the player for the level that would update the removed record are turned into submissions for a new record
+func RemoveRecord(dao *daos.Dao, listData ListData, record *models.Record, userId string, reason string) error {
+	err := dao.RunInTransaction(func(txDao *daos.Dao) error {
+		err := deleteRecordPlacement(txDao, listData, record)
+		if err != nil {
+			return err
+		}
+		tombstoneCollection, err := txDao.FindCollectionByNameOrId(listData.RecordTombstonesTableName)
+		if err != nil {
+			return util.NewErrorResponse(err, "Failed to load collection")
+		}
+		tombstone := models.NewRecord(tombstoneCollection)
+		tombstone.Set("level", record.GetString("level"))
+		tombstone.Set("submitted_by", record.GetString("submitted_by"))
+		tombstone.Set("removed_by", userId)
+		tombstone.Set("reason", reason)
+		tombstone.Set("record", record.PublicExport())
+		err = txDao.SaveRecord(tombstone)
+		if err != nil {
+			return util.NewErrorResponse(err, "Failed to save tombstone")
+		}
+		_, err = txDao.DB().Update(listData.SubmissionsTableName, dbx.Params{"is_update": false}, dbx.HashExp{
+			"submitted_by": record.GetString("submitted_by"),
+			"level":        record.GetString("level"),
+			"is_update":    true,
+		}).Execute()
+		if err != nil {
+			return util.NewErrorResponse(err, "Failed to update submissions of the record")
+		}
+		return UpdateLeaderboardAndPacksForUser(txDao, listData, record.GetString("submitted_by"))
+	})
+	return err
+}
+
+// UpdateRecord changes the given fields of the record. Lowering the percentage below 100 demotes a completion to a progress record,
+// which has to reach the percentage to qualify of the level. Completed packs and the leaderboard of the player are recalculated
+func UpdateRecord(dao *daos.Dao, app core.App, listData ListData, record *models.Record, recordData map[string]any) error {
+	err := dao.RunInTransaction(func(txDao *daos.Dao) error {
+		if percentage, ok := recordData["percentage"].(int); ok && percentage < 100 {
+			levelRecord, err := txDao.FindRecordById(listData.LevelTableName, record.GetString("level"))
+			if err != nil {
+				return util.NewErrorResponse(err, "Failed to load level")
+			}
+			percentToQualify := levelRecord.GetInt("percent_to_qualify")
+			if percentToQualify == 0 {
+				return util.NewErrorResponse(nil, "This level only accepts completions")
+			}
+			if percentage < percentToQualify {
+				return util.NewErrorResponse(nil, fmt.Sprintf("Progress has to be at least %v%%", percentToQualify))
+			}
+		}
+		recordForm := forms.NewRecordUpsert(app, record)
+		recordForm.SetDao(txDao)
+		err := recordForm.LoadData(recordData)
+		if err != nil {
+			return util.NewErrorResponse(err, "Failed to load record data")
+		}
+		err = recordForm.Submit()
+		if err != nil {
+			return util.NewErrorResponse(err, "Failed to update record")
+		}
+		return UpdateLeaderboardAndPacksForUser(txDao, listData, record.GetString("submitted_by"))
+	})
+	return err
+}
+
+// ReplaceVerification replaces the verification of the level with a new one, for example after a hacked verification.
+// If the verifier stays the same, only the verification data gets updated. Otherwise, the old verification is removed with the given reason
+// and an existing record of the new verifier becomes the verification. The placement order of the other records is kept in sequence
+// and the leaderboards of both verifiers are recalculated
+func ReplaceVerification(dao *daos.Dao, app core.App, listData ListData, levelId string, userId string, verificationData map[string]any, reason string) (*models.Record, error) {
+	var verification *models.Record
+	err := dao.RunInTransaction(func(txDao *daos.Dao) error {
+		var oldVerification *models.Record
+		verifications, err := txDao.FindRecordsByExpr(listData.RecordsTableName, dbx.HashExp{"level": levelId, "placement_order": 1})
+		if err != nil {
+			return util.NewErrorResponse(err, "Failed to query verification")
+		}
+		if len(verifications) == 1 {
+			oldVerification = verifications[0]
+		}
+		verifierId := verificationData["submitted_by"].(string)
+		if oldVerification != nil && oldVerification.GetString("submitted_by") == verifierId {
+			verification = oldVerification
+			return UpdateRecord(txDao, app, listData, verification, verificationData)
+		}
+		if oldVerification != nil {
+			err = RemoveRecord(txDao, listData, oldVerification, userId, reason)
+			if err != nil {
+				return err
+			}
+		}
+		records, err := txDao.FindRecordsByExpr(listData.RecordsTableName, dbx.HashExp{"level": levelId, "submitted_by": verifierId})
+		if err != nil {
+			return util.NewErrorResponse(err, "Failed to query records of the verifier")
+		}
+		if len(records) == 1 {
+			err = deleteRecordPlacement(txDao, listData, records[0])
+			if err != nil {
+				return err
+			}
+		}
+		_, err = txDao.DB().Update(
+			listData.RecordsTableName,
+			dbx.Params{"placement_order": dbx.NewExp("placement_order + 1")},
+			dbx.HashExp{"level": levelId}).Execute()
+		if err != nil {
+			return util.NewErrorResponse(err, "Failed to update other placement positions")
+		}
+		verificationData["level"] = levelId
+		verificationData["reviewer"] = userId
+		verificationData["placement_order"] = 1
+		verification, err = util.AddRecordByCollectionName(txDao, app, listData.RecordsTableName, verificationData)
+		if err != nil {
+			return util.NewErrorResponse(err, "Failed to add verification")
+		}
+		return UpdateLeaderboardAndPacksForUser(txDao, listData, verifierId)
+	})
+	return verification, err
+}
+
+// deleteRecordPlacement deletes the record and moves the records placed after it on the same level up
+func deleteRecordPlacement(dao *daos.Dao, listData ListData, record *models.Record) error {
+	err := dao.DeleteRecord(record)
+	if err != nil {
+		return util.NewErrorResponse(err, "Failed to delete record")
+	}
+	_, err = dao.DB().Update(
+		listData.RecordsTableName,
+		dbx.Params{"placement_order": dbx.NewExp("placement_order - 1")},
+		dbx.And(
+			dbx.NewExp("placement_order > {:placement}", dbx.Params{"placement": record.GetInt("placement_order")}),
+			dbx.HashExp{"level": record.GetString("level")})).Execute()
+	if err != nil {
+		return util.NewErrorResponse(err, "Failed to update other placement positions")
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package demonlist
+
+import (
+	"AREDL/util"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/tools/types"
+	"time"
+)
+
+// Reviewers claim a submission while they review it, so no other reviewer accepts or rejects it at the same time.
+// Claims expire so a submission does not stay locked when a reviewer abandons it.
+
+// activeSubmissionClaim returns the id of the user that currently holds the claim on the submission or an empty string
+func activeSubmissionClaim(submission *models.Record) string {
+	claimedBy := submission.GetString("claimed_by")
+	if claimedBy == "" || !submission.GetDateTime("claim_expires").Time().After(time.Now()) {
+		return ""
+	}
+	return claimedBy
+}
+
+// CheckSubmissionClaim returns an error if a reviewer other than the given user holds the claim on the submission
+func CheckSubmissionClaim(submission *models.Record, userId string) error {
+	claimedBy := activeSubmissionClaim(submission)
+	if claimedBy != "" && claimedBy != userId {
+		return util.NewErrorResponse(nil, "Submission is claimed by another reviewer")
+	}
+	return nil
+}
+
+// ClaimSubmission claims the submission for the user for the given duration and moves pending submissions to under review.
+// Reviewers can extend their own claim, claims of other reviewers can only be taken over once they expired
+func ClaimSubmission(dao *daos.Dao, listData ListData, submissionId string, userId string, duration time.Duration) (*models.Record, error) {
+	var submission *models.Record
+	err := dao.RunInTransaction(func(txDao *daos.Dao) error {
+		var err error
+		submission, err = txDao.FindRecordById(listData.SubmissionsTableName, submissionId)
+		if err != nil {
+			return util.NewErrorResponse(err, "Could not load submission")
+		}
+		status := SubmissionStatus(submission)
+		if status != SubmissionPending && status != SubmissionUnderReview && status != SubmissionNeedsInfo {
+			return util.NewErrorResponse(nil, "Submission has already been reviewed")
+		}
+		err = CheckSubmissionClaim(submission, userId)
+		if err != nil {
+			return err
+		}
+		expires, err := types.ParseDateTime(time.Now().Add(duration))
+		if err != nil {
+			return util.NewErrorResponse(err, "Failed to calculate claim expiry")
+		}
+		submission.Set("claimed_by", userId)
+		submission.Set("claim_expires", expires)
+		if status == SubmissionPending {
+			return SetSubmissionStatus(txDao, listData, submission, SubmissionUnderReview, userId, "")
+		}
+		err = txDao.SaveRecord(submission)
+		if err != nil {
+			return util.NewErrorResponse(err, "Failed to claim submission")
+		}
+		return nil
+	})
+	return submission, err
+}
+
+// UnclaimSubmission releases the claim of the user on the submission and moves it back to pending if it was under review
+func UnclaimSubmission(dao *daos.Dao, listData ListData, submissionId string, userId string) (*models.Record, error) {
+	var submission *models.Record
+	err := dao.RunInTransaction(func(txDao *daos.Dao) error {
+		var err error
+		submission, err = txDao.FindRecordById(listData.SubmissionsTableName, submissionId)
+		if err != nil {
+			return util.NewErrorResponse(err, "Could not load submission")
+		}
+		if activeSubmissionClaim(submission) != userId {
+			return util.NewErrorResponse(nil, "Submission is not claimed by you")
+		}
+		ClearSubmissionClaim(submission)
+		if SubmissionStatus(submission) == SubmissionUnderReview {
+			return SetSubmissionStatus(txDao, listData, submission, SubmissionPending, userId, "")
+		}
+		err = txDao.SaveRecord(submission)
+		if err != nil {
+			return util.NewErrorResponse(err, "Failed to unclaim submission")
+		}
+		return nil
+	})
+	return submission, err
+}
+
+// ClearSubmissionClaim removes the claim from the submission without saving it
+func ClearSubmissionClaim(submission *models.Record) {
+	submission.Set("claimed_by", "")
+	submission.Set("claim_expires", "")
+}
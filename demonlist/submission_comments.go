@@ -0,0 +1,43 @@
+package demonlist
+
+import (
+	"AREDL/util"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/models"
+)
+
+// AddSubmissionComment adds a message to the comment thread of the submission.
+// Reviewers can ask for more information, which moves the submission out of the queue until the submitter replies.
+// A reply of the submitter to such a request moves the submission back to pending
+func AddSubmissionComment(dao *daos.Dao, listData ListData, submission *models.Record, userId string, message string, fromReviewer bool, needsInfo bool) (*models.Record, error) {
+	var comment *models.Record
+	err := dao.RunInTransaction(func(txDao *daos.Dao) error {
+		collection, err := txDao.FindCollectionByNameOrId(listData.SubmissionCommentsTableName)
+		if err != nil {
+			return util.NewErrorResponse(err, "Failed to load collection")
+		}
+		comment = models.NewRecord(collection)
+		comment.Set("submission", submission.Id)
+		comment.Set("author", userId)
+		comment.Set("message", message)
+		comment.Set("from_reviewer", fromReviewer)
+		err = txDao.SaveRecord(comment)
+		if err != nil {
+			return util.NewErrorResponse(err, "Failed to save comment")
+		}
+		status := SubmissionStatus(submission)
+		switch {
+		case fromReviewer && needsInfo && status != SubmissionNeedsInfo:
+			err = CheckSubmissionClaim(submission, userId)
+			if err != nil {
+				return err
+			}
+			ClearSubmissionClaim(submission)
+			return SetSubmissionStatus(txDao, listData, submission, SubmissionNeedsInfo, userId, "")
+		case !fromReviewer && status == SubmissionNeedsInfo:
+			return SetSubmissionStatus(txDao, listData, submission, SubmissionPending, userId, "")
+		}
+		return nil
+	})
+	return comment, err
+}
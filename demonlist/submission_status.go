@@ -0,0 +1,92 @@
+package demonlist
+
+import (
+	"AREDL/util"
+	"fmt"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/tools/list"
+)
+
+const (
+	SubmissionPending           = "pending"
+	SubmissionUnderReview       = "under_review"
+	SubmissionNeedsInfo         = "needs_info"
+	SubmissionRejectedRetryable = "rejected_retryable"
+	SubmissionRejectedFinal     = "rejected_final"
+	SubmissionAccepted          = "accepted"
+)
+
+// SubmissionQueueStatuses are the statuses of submissions that are waiting for a reviewer
+var SubmissionQueueStatuses = []string{SubmissionPending, SubmissionUnderReview}
+
+// SubmissionStatuses are all statuses a submission can have
+var SubmissionStatuses = []string{SubmissionPending, SubmissionUnderReview, SubmissionNeedsInfo, SubmissionRejectedRetryable, SubmissionRejectedFinal, SubmissionAccepted}
+
+// submissionTransitions lists the statuses each status can change to.
+// Changes back to pending are made by the submitter updating the submission or by a reviewer releasing it.
+// Accepted submissions and final rejections can't be changed anymore, submitting an accepted level again creates a new submission
+var submissionTransitions = map[string][]string{
+	SubmissionPending:           {SubmissionUnderReview, SubmissionNeedsInfo, SubmissionRejectedRetryable, SubmissionRejectedFinal, SubmissionAccepted},
+	SubmissionUnderReview:       {SubmissionPending, SubmissionNeedsInfo, SubmissionRejectedRetryable, SubmissionRejectedFinal, SubmissionAccepted},
+	SubmissionNeedsInfo:         {SubmissionPending, SubmissionUnderReview, SubmissionRejectedRetryable, SubmissionRejectedFinal, SubmissionAccepted},
+	SubmissionRejectedRetryable: {SubmissionPending},
+	SubmissionRejectedFinal:     {},
+	SubmissionAccepted:          {},
+}
+
+// SubmissionStatus returns the status of the submission
+func SubmissionStatus(submission *models.Record) string {
+	return submission.GetString("status")
+}
+
+// SubmissionStatusExp matches submissions that have one of the given statuses in the given column
+func SubmissionStatusExp(column string, statuses []string) dbx.Expression {
+	return dbx.In(column, list.ToInterfaceSlice(statuses)...)
+}
+
+// ValidateSubmissionTransition checks that a submission can change from one status to the other
+func ValidateSubmissionTransition(from string, to string) error {
+	allowed, ok := submissionTransitions[from]
+	if !ok {
+		return fmt.Errorf("unknown submission status %s", from)
+	}
+	if !list.ExistInSlice(to, allowed) {
+		return fmt.Errorf("submission status can't change from %s to %s", from, to)
+	}
+	return nil
+}
+
+// SetSubmissionStatus validates the status change, saves the submission and adds the change to its timeline.
+// The comment is shown to the submitter in the timeline
+func SetSubmissionStatus(dao *daos.Dao, listData ListData, submission *models.Record, status string, userId string, comment string) error {
+	from := SubmissionStatus(submission)
+	err := ValidateSubmissionTransition(from, status)
+	if err != nil {
+		return util.NewErrorResponse(err, "Invalid status change")
+	}
+	err = dao.RunInTransaction(func(txDao *daos.Dao) error {
+		submission.Set("status", status)
+		err := txDao.SaveRecord(submission)
+		if err != nil {
+			return util.NewErrorResponse(err, "Failed to update submission status")
+		}
+		return addSubmissionTimeline(txDao, listData, submission.Id, from, status, userId, comment)
+	})
+	return err
+}
+
+func addSubmissionTimeline(dao *daos.Dao, listData ListData, submissionId string, from string, status string, userId string, comment string) error {
+	_, err := dao.DB().Insert(listData.SubmissionTimelineTableName, dbx.Params{
+		"submission":  submissionId,
+		"from_status": from,
+		"status":      status,
+		"changed_by":  userId,
+		"comment":     comment,
+	}).Execute()
+	if err != nil {
+		return util.NewErrorResponse(err, "Failed to update submission timeline")
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package demonlist
+
+import (
+	"github.com/pocketbase/dbx"
+	"testing"
+)
+
+func TestValidateSubmissionTransition(t *testing.T) {
+	tests := []struct {
+		from    string
+		to      string
+		wantErr bool
+	}{
+		{from: SubmissionPending, to: SubmissionUnderReview},
+		{from: SubmissionPending, to: SubmissionAccepted},
+		{from: SubmissionPending, to: SubmissionRejectedFinal},
+		{from: SubmissionPending, to: SubmissionPending, wantErr: true},
+		{from: SubmissionUnderReview, to: SubmissionPending},
+		{from: SubmissionUnderReview, to: SubmissionNeedsInfo},
+		{from: SubmissionUnderReview, to: SubmissionUnderReview, wantErr: true},
+		{from: SubmissionNeedsInfo, to: SubmissionPending},
+		{from: SubmissionNeedsInfo, to: SubmissionAccepted},
+		{from: SubmissionRejectedRetryable, to: SubmissionPending},
+		{from: SubmissionRejectedRetryable, to: SubmissionAccepted, wantErr: true},
+		{from: SubmissionRejectedRetryable, to: SubmissionUnderReview, wantErr: true},
+		{from: SubmissionRejectedFinal, to: SubmissionPending, wantErr: true},
+		{from: SubmissionRejectedFinal, to: SubmissionAccepted, wantErr: true},
+		{from: SubmissionAccepted, to: SubmissionPending, wantErr: true},
+		{from: SubmissionAccepted, to: SubmissionRejectedFinal, wantErr: true},
+		{from: "unknown", to: SubmissionPending, wantErr: true},
+		{from: SubmissionPending, to: "unknown", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.from+"->"+test.to, func(t *testing.T) {
+			err := ValidateSubmissionTransition(test.from, test.to)
+			if (err != nil) != test.wantErr {
+				t.Errorf("ValidateSubmissionTransition(%q, %q) error = %v, wantErr %v", test.from, test.to, err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestSubmissionTransitionsCoverEveryStatus(t *testing.T) {
+	for _, status := range SubmissionStatuses {
+		if _, ok := submissionTransitions[status]; !ok {
+			t.Errorf("status %s has no transitions", status)
+		}
+	}
+}
+
+func TestSubmissionStatusExp(t *testing.T) {
+	params := dbx.Params{}
+	got := SubmissionStatusExp("status", []string{SubmissionPending, SubmissionUnderReview}).Build(dbx.NewFromDB(nil, "sqlite"), params)
+	if want := "`status` IN ({:p0}, {:p1})"; got != want {
+		t.Errorf("SubmissionStatusExp() = %q, want %q", got, want)
+	}
+	if params["p0"] != SubmissionPending || params["p1"] != SubmissionUnderReview {
+		t.Errorf("SubmissionStatusExp() params = %v, want only the given statuses", params)
+	}
+}
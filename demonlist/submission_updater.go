@@ -2,6 +2,7 @@ package demonlist
 
 import (
 	"AREDL/util"
+	"fmt"
 	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase/core"
 	"github.com/pocketbase/pocketbase/daos"
@@ -9,7 +10,11 @@ import (
 	"github.com/pocketbase/pocketbase/models"
 )
 
-func UpsertSubmission(dao *daos.Dao, app core.App, listData ListData, submissionData map[string]any) error {
+// UpsertSubmission creates a new submission or updates the existing one of the user for the level and returns the saved submission.
+// Updating a submission moves it back to pending. This is not possible after a final rejection or while a reviewer holds a claim on it.
+// Accepted submissions are kept to show the review of their record until the level is submitted again, then they are replaced by a new submission
+func UpsertSubmission(dao *daos.Dao, app core.App, listData ListData, submissionData map[string]any) (*models.Record, error) {
+	var submissionRecord *models.Record
 	err := dao.RunInTransaction(func(txDao *daos.Dao) error {
 		submissions, err := txDao.FindRecordsByExpr(listData.SubmissionsTableName,
 			dbx.Or(
@@ -18,53 +23,95 @@ func UpsertSubmission(dao *daos.Dao, app core.App, listData ListData, submission
 		if err != nil {
 			return util.NewErrorResponse(err, "Failed to query for submissions")
 		}
+		records, err := txDao.FindRecordsByExpr(listData.RecordsTableName,
+			dbx.HashExp{"submitted_by": submissionData["submitted_by"], "level": submissionData["level"]})
+		if err != nil {
+			return util.NewErrorResponse(err, "Failed to query for records")
+		}
+		submissionData["is_update"] = len(records) != 0
+		submissionData["status"] = SubmissionPending
 
+		if len(submissions) == 1 && SubmissionStatus(submissions[0]) == SubmissionAccepted {
+			err = txDao.DeleteRecord(submissions[0])
+			if err != nil {
+				return util.NewErrorResponse(err, "Failed to replace accepted submission")
+			}
+			submissions = nil
+		}
+
+		fromStatus := ""
 		if len(submissions) == 1 {
 			// update submission
-			submissionForm := forms.NewRecordUpsert(app, submissions[0])
-			submissionForm.SetDao(txDao)
-			submissionData["is_update"] = false
-			submissionData["rejected"] = false
-			err = submissionForm.LoadData(submissionData)
-			if err != nil {
-				return util.NewErrorResponse(err, "Failed to load data")
+			submissionRecord = submissions[0]
+			fromStatus = SubmissionStatus(submissionRecord)
+			if fromStatus == SubmissionRejectedFinal {
+				return util.NewErrorResponse(nil, "Submission has been rejected permanently and can't be submitted again")
 			}
-			err = submissionForm.Submit()
-			if err != nil {
-				return util.NewErrorResponse(err, "Failed to submit new submission data")
+			if activeSubmissionClaim(submissionRecord) != "" {
+				return util.NewErrorResponse(nil, "Submission is being reviewed and can't be updated right now")
+			}
+			if fromStatus != SubmissionPending {
+				err = ValidateSubmissionTransition(fromStatus, SubmissionPending)
+				if err != nil {
+					return util.NewErrorResponse(err, "Submission can't be updated")
+				}
 			}
+			ClearSubmissionClaim(submissionRecord)
 		} else if len(submissions) == 0 {
 			// create submission
-			records, err := txDao.FindRecordsByExpr(listData.RecordsTableName,
-				dbx.Or(
-					dbx.HashExp{"id": submissionData["id"]},
-					dbx.HashExp{"submitted_by": submissionData["submitted_by"], "level": submissionData["level"]}))
-			if err != nil {
-				return util.NewErrorResponse(err, "Failed to query for records")
-			}
-			submissionRecord := &models.Record{}
 			submissionCollection, err := txDao.FindCollectionByNameOrId(listData.SubmissionsTableName)
 			if err != nil {
 				return util.NewErrorResponse(err, "Failed to load collection")
 			}
 			submissionRecord = models.NewRecord(submissionCollection)
-			submissionData["is_update"] = len(records) != 0
-			submissionForm := forms.NewRecordUpsert(app, submissionRecord)
-			submissionForm.SetDao(txDao)
-			err = submissionForm.LoadData(submissionData)
-			if err != nil {
-				return util.NewErrorResponse(err, "Failed to load data")
-			}
-			err = submissionForm.Submit()
-			if err != nil {
-				return util.NewErrorResponse(err, "Failed to submit new submission data")
-			}
 		} else {
 			return util.NewErrorResponse(nil, "Invalid state")
 		}
+		submissionForm := forms.NewRecordUpsert(app, submissionRecord)
+		submissionForm.SetDao(txDao)
+		err = submissionForm.LoadData(submissionData)
+		if err != nil {
+			return util.NewErrorResponse(err, "Failed to load data")
+		}
+		err = submissionForm.Submit()
+		if err != nil {
+			return util.NewErrorResponse(err, "Failed to submit new submission data")
+		}
+		if fromStatus != SubmissionPending {
+			return addSubmissionTimeline(txDao, listData, submissionRecord.Id, fromStatus, SubmissionPending, submissionRecord.GetString("submitted_by"), "")
+		}
 		return nil
 	})
-	return err
+	return submissionRecord, err
+}
+
+// ValidateSubmissionPercentage checks that a submission with the given percentage can be made for the level.
+// Progress submissions need to reach the percentage to qualify of the level and have to improve on the existing record of the user
+func ValidateSubmissionPercentage(dao *daos.Dao, listData ListData, levelRecord *models.Record, userId string, percentage int) error {
+	if percentage >= 100 {
+		return nil
+	}
+	percentToQualify := levelRecord.GetInt("percent_to_qualify")
+	if percentToQualify == 0 {
+		return util.NewErrorResponse(nil, "This level only accepts completions")
+	}
+	if percentage < percentToQualify {
+		return util.NewErrorResponse(nil, fmt.Sprintf("Progress has to be at least %v%%", percentToQualify))
+	}
+	records, err := dao.FindRecordsByExpr(listData.RecordsTableName, dbx.HashExp{"submitted_by": userId, "level": levelRecord.Id})
+	if err != nil {
+		return util.NewErrorResponse(err, "Failed to query for records")
+	}
+	if len(records) == 1 {
+		recordPercentage := records[0].GetInt("percentage")
+		if recordPercentage >= 100 {
+			return util.NewErrorResponse(nil, "You already have a completion of this level")
+		}
+		if percentage <= recordPercentage {
+			return util.NewErrorResponse(nil, fmt.Sprintf("Progress has to be higher than your current record of %v%%", recordPercentage))
+		}
+	}
+	return nil
 }
 
 func DeleteSubmission(dao *daos.Dao, listData ListData, submission *models.Record) error {
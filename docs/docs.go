@@ -18,9 +18,105 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/aredl/changelog": {
+            "get": {
+                "description": "Paged changelog of the list, newest first. Every entry is either a single placement, move or removal or a list update that contains multiple changes.\nLevels that moved because of a change are not listed separately, they are only counted in shifted.\nRemoved levels stay part of the changelog.",
+                "produces": [
+                    "application/json",
+                    "application/atom+xml",
+                    "application/rss+xml"
+                ],
+                "tags": [
+                    "aredl"
+                ],
+                "summary": "List changelog",
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 1,
+                        "description": "select page",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 20,
+                        "description": "number of results per page",
+                        "name": "per_page",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "atom",
+                            "rss"
+                        ],
+                        "type": "string",
+                        "default": "json",
+                        "description": "response format",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/aredl.Changelog"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/aredl/events": {
+            "get": {
+                "description": "Server-Sent Events stream of list changes. Events are only sent once the change has been saved.\nhistory: a new position history entry, data is the history entry.\nrecord: a record has been accepted or updated, data is the record.\nqueue: the number of pending submissions changed, data is {\"pending\": count}.\nA comment is sent every 30 seconds to keep the connection alive. Queue events are sent regardless of the filters.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "aredl"
+                ],
+                "summary": "Live event stream",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "only send events of the level with the given internal id",
+                        "name": "level_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only send records of the user with the given internal id",
+                        "name": "user_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/aredl/leaderboard": {
             "get": {
-                "description": "Gives leaderboard as a paged list ordered by rank. Players with zero list points are omitted",
+                "description": "Gives leaderboard as a paged list ordered by rank. Players with zero list points are omitted\nPast leaderboards are loaded from the daily snapshots, names and countries are the current ones.\nWith country only players of that country are listed, ordered by their country rank.",
                 "produces": [
                     "application/json"
                 ],
@@ -57,6 +153,18 @@ const docTemplate = `{
                         "description": "filters names to only contain the given substring",
                         "name": "name_filter",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only players of the given country, ISO 3166-1 alpha-2 code",
+                        "name": "country",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "load the leaderboard of the latest snapshot at or before the given time, format: 2006-01-02 or 2006-01-02 15:04:05",
+                        "name": "at",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -75,6 +183,35 @@ const docTemplate = `{
                 }
             }
         },
+        "/aredl/leaderboard/countries": {
+            "get": {
+                "description": "Gives every country ordered by the total points of its players. Players without a country are not counted",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "aredl"
+                ],
+                "summary": "Country leaderboard",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/aredl.CountryLeaderboardEntry"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/aredl/leaderboard/refresh": {
             "post": {
                 "security": [
@@ -127,7 +264,7 @@ const docTemplate = `{
         },
         "/aredl/levels": {
             "get": {
-                "description": "Gives a list of every placed level ordered by position. To get more details on a level use /aredl/levels/:id",
+                "description": "Gives a list of every placed level ordered by position. To get more details on a level use /aredl/levels/:id\nWith at the list is reconstructed from the position history as it was at the given time. Points are calculated with the point formula of that time, level details are the current ones.",
                 "produces": [
                     "application/json"
                 ],
@@ -135,6 +272,14 @@ const docTemplate = `{
                     "aredl"
                 ],
                 "summary": "Full simple list",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "reconstruct the list at the given time, format: 2006-01-02 or 2006-01-02 15:04:05",
+                        "name": "at",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -222,6 +367,14 @@ const docTemplate = `{
                         "name": "legacy",
                         "in": "query"
                     },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "lowest percentage a progress record needs, progress records are not accepted if not set",
+                        "name": "percent_to_qualify",
+                        "in": "query"
+                    },
                     {
                         "type": "string",
                         "description": "user id of the verifier",
@@ -340,6 +493,61 @@ const docTemplate = `{
                     }
                 }
             },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Removes a level from the list, for example if it turns out to be hacked. All levels below it move up by one position.\nIt automatically updates history, points, packs and leaderboards. The level is kept in the removed levels archive.\nRequires user permission: aredl.manage_levels",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "aredl"
+                ],
+                "summary": "Remove AREDL level",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "internal level id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "reason the level is removed for",
+                        "name": "reason",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "default": true,
+                        "description": "whether the records of the level are archived instead of deleted",
+                        "name": "archive_records",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    }
+                }
+            },
             "patch": {
                 "security": [
                     {
@@ -415,6 +623,14 @@ const docTemplate = `{
                         "description": "whether the level should be placed as legacy",
                         "name": "legacy",
                         "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "lowest percentage a progress record needs, 0 to only accept completions",
+                        "name": "percent_to_qualify",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -438,7 +654,7 @@ const docTemplate = `{
         },
         "/aredl/levels/{id}/history": {
             "get": {
-                "description": "Lists the placement, move \u0026 legacy history of a level by either using its internal or gd id. Possible actions: placed, placedAbove, movedUp, movedDown, movedPastUp, movedPastDown, movedToLegacy, movedFromLegacy",
+                "description": "Lists the placement, move \u0026 legacy history of a level by either using its internal or gd id. Possible actions: placed, placedAbove, movedUp, movedDown, movedPastUp, movedPastDown, movedToLegacy, movedFromLegacy, removed\nThe history of levels that have been removed from the list stays available.",
                 "produces": [
                     "application/json"
                 ],
@@ -481,57 +697,101 @@ const docTemplate = `{
                 }
             }
         },
-        "/aredl/list": {
-            "get": {
-                "description": "Use /aredl/levels instead",
+        "/aredl/levels/{id}/verification": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Replaces the verification of a level. If the verifier stays the same, only the verification data is updated.\nOtherwise, the old verification is removed with the given reason and an existing record of the new verifier becomes the verification.\nThe placement order of the other records and the leaderboards of both verifiers are updated.\nRequires user permission: aredl.manage_levels",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "aredl"
                 ],
-                "summary": "(DEPRECATED) Full simple list",
+                "summary": "Replace AREDL verification",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "internal level id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "reason for the replacement, shown to the old verifier",
+                        "name": "reason",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "user id of the verifier",
+                        "name": "submitted_by",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "format": "url",
+                        "description": "video url of the verification",
+                        "name": "video_url",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "whether verification was done on mobile",
+                        "name": "mobile",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "verification raw footage",
+                        "name": "raw_footage",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/aredl.ListEntry"
-                            }
-                        }
+                        "description": "OK"
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/util.ErrorResponse"
                         }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/aredl/me/records": {
+        "/aredl/list": {
             "get": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    }
-                ],
-                "description": "Lists records ordered by the time they have been updated last.\nRequires user permission: aredl.user_record_list",
+                "description": "Use /aredl/levels instead",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "aredl"
                 ],
-                "summary": "List records",
+                "summary": "(DEPRECATED) Full simple list",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/aredl.Record"
+                                "$ref": "#/definitions/aredl.ListEntry"
                             }
                         }
                     },
@@ -540,18 +800,153 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/util.ErrorResponse"
                         }
-                    },
-                    "403": {
-                        "description": "Forbidden",
-                        "schema": {
-                            "$ref": "#/definitions/util.ErrorResponse"
-                        }
                     }
                 }
             }
         },
-        "/aredl/me/submissions": {
-            "get": {
+        "/aredl/list-updates": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Places and moves multiple levels at once. Positions refer to the list after the update, all other levels keep their order and fill the remaining positions.\nThe final order is validated before anything is changed. All history entries are grouped under the named list update and points are only recalculated once.\nmoves: [{\"id\": \"internal level id\", \"position\": 1, \"legacy\": false}], legacy is optional.\nplacements: [{\"position\": 1, \"legacy\": false, \"level_id\": 1, \"name\": \"\", \"publisher\": \"user id\", \"level_password\": \"\", \"percent_to_qualify\": 0, \"creator_ids\": [], \"verification\": {\"submitted_by\": \"user id\", \"video_url\": \"\", \"mobile\": false, \"raw_footage\": \"\"}}]\nRequires user permission: aredl.manage_levels",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "aredl"
+                ],
+                "summary": "Apply AREDL list update",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "name of the list update",
+                        "name": "name",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "changelog note",
+                        "name": "changelog",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "JSON array of level moves",
+                        "name": "moves",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "JSON array of new levels",
+                        "name": "placements",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/aredl/me/records": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists records ordered by the time they have been updated last.\nRequires user permission: aredl.user_record_list",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "aredl"
+                ],
+                "summary": "List records",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/aredl.Record"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/aredl/me/records/removed": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists the records of the authenticated user that have been removed by staff together with the reason, newest first.\nRequires user permission: aredl.user_record_list",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "aredl"
+                ],
+                "summary": "List removed records",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/aredl.RemovedRecord"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/aredl/me/submissions": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
@@ -626,6 +1021,15 @@ const docTemplate = `{
                         "in": "query",
                         "required": true
                     },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 100,
+                        "description": "reached percentage, below 100 the submission is a progress record. Has to be at least the percentage to qualify of the level",
+                        "name": "percentage",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
                         "description": "ldm gd level id if used",
@@ -672,7 +1076,7 @@ const docTemplate = `{
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Deletes a submission as long as it still is open for review.\nRequires user permission: aredl.user_submission_delete",
+                "description": "Deletes a submission as long as it has not been accepted or rejected permanently and no reviewer is working on it.\nRequires user permission: aredl.user_submission_delete",
                 "produces": [
                     "application/json"
                 ],
@@ -708,55 +1112,37 @@ const docTemplate = `{
                 }
             }
         },
-        "/aredl/names": {
+        "/aredl/me/submissions/{id}/comments": {
             "get": {
-                "description": "Gives a map of important users grouped by their role. This also includes aredl plus members",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists the comment thread of one of the submissions of the authenticated user ordered from oldest to newest.\nRequires user permission: aredl.user_submission_list",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "aredl"
                 ],
-                "summary": "Important users",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "array",
-                                "items": {
-                                    "$ref": "#/definitions/aredl.NameUser"
-                                }
-                            }
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "$ref": "#/definitions/util.ErrorResponse"
-                        }
+                "summary": "Own submission comments",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "internal submission id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
-                }
-            }
-        },
-        "/aredl/packs": {
-            "get": {
-                "description": "Gives a list of all packs",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "aredl"
                 ],
-                "summary": "Aredl packs",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/aredl.Pack"
+                                "$ref": "#/definitions/aredl.SubmissionComment"
                             }
                         }
                     },
@@ -765,6 +1151,12 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/util.ErrorResponse"
                         }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
                     }
                 }
             },
@@ -774,43 +1166,26 @@ const docTemplate = `{
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Creates a new pack and updates all user points that now have the new pack.\nRequires user permission: aredl.manage_packs",
+                "description": "Adds a message to the comment thread of one of the submissions of the authenticated user.\nIf a reviewer asked for more information, the reply moves the submission back into the queue.\nRequires user permission: aredl.user_submit",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "aredl"
                 ],
-                "summary": "Create a new AREDL pack",
+                "summary": "Comment on own submission",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "display name",
-                        "name": "name",
-                        "in": "query",
+                        "description": "internal submission id",
+                        "name": "id",
+                        "in": "path",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "display color",
-                        "name": "color",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "position of pack",
-                        "name": "placement_order",
-                        "in": "query"
-                    },
-                    {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "collectionFormat": "csv",
-                        "description": "list of internal level ids. Pack has to have at least two levels",
-                        "name": "levels",
+                        "description": "message, max 1000 characters",
+                        "name": "message",
                         "in": "query",
                         "required": true
                     }
@@ -834,25 +1209,25 @@ const docTemplate = `{
                 }
             }
         },
-        "/aredl/packs/{id}": {
-            "delete": {
+        "/aredl/me/submissions/{id}/timeline": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Deletes a pack and updates all user points that now have the new pack.\nRequires user permission: aredl.manage_packs",
+                "description": "Lists all status changes of one of the submissions of the authenticated user ordered from oldest to newest.\nRequires user permission: aredl.user_submission_list",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "aredl"
                 ],
-                "summary": "Delete an AREDL pack",
+                "summary": "Own submission timeline",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "internal pack id",
+                        "description": "internal submission id",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -860,7 +1235,13 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/aredl.SubmissionTimelineEntry"
+                            }
+                        }
                     },
                     "400": {
                         "description": "Bad Request",
@@ -875,153 +1256,161 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "patch": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    }
-                ],
-                "description": "Updates a pack and updates all user points that now have or lost the new pack.\nRequires user permission: aredl.manage_packs",
+            }
+        },
+        "/aredl/names": {
+            "get": {
+                "description": "Gives a map of important users grouped by their role. This also includes aredl plus members\nRoles that did not start yet or already expired are left out, roles that are limited in time include their expiry",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "aredl"
                 ],
-                "summary": "Update a AREDL pack",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "internal pack id",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "display name",
-                        "name": "name",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "display color",
-                        "name": "color",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "position of pack",
-                        "name": "placement_order",
-                        "in": "query"
-                    },
-                    {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "collectionFormat": "csv",
-                        "description": "new list of internal level ids. Pack has to have at least two levels",
-                        "name": "levels",
-                        "in": "query"
-                    }
-                ],
+                "summary": "Important users",
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "array",
+                                "items": {
+                                    "$ref": "#/definitions/aredl.NameUser"
+                                }
+                            }
+                        }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/util.ErrorResponse"
                         }
+                    }
+                }
+            }
+        },
+        "/aredl/packs": {
+            "get": {
+                "description": "Gives a list of all packs",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "aredl"
+                ],
+                "summary": "Aredl packs",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/aredl.Pack"
+                            }
+                        }
                     },
-                    "403": {
-                        "description": "Forbidden",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/util.ErrorResponse"
                         }
                     }
                 }
-            }
-        },
-        "/aredl/profiles/{id}": {
-            "get": {
-                "description": "Gives detailed information about a user",
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Creates a new pack and updates all user points that now have the new pack.\nRequires user permission: aredl.manage_packs",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "aredl"
                 ],
-                "summary": "User info",
+                "summary": "Create a new AREDL pack",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "user id",
-                        "name": "id",
-                        "in": "path",
+                        "description": "display name",
+                        "name": "name",
+                        "in": "query",
                         "required": true
                     },
                     {
-                        "type": "boolean",
-                        "description": "if the provided id is a discord id",
-                        "name": "is_discord_id",
+                        "type": "string",
+                        "description": "display color",
+                        "name": "color",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "position of pack",
+                        "name": "placement_order",
                         "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "list of internal level ids. Pack has to have at least two levels",
+                        "name": "levels",
+                        "in": "query",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/aredl.User"
-                        }
+                        "description": "OK"
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/util.ErrorResponse"
                         }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/aredl/submissions": {
-            "get": {
+        "/aredl/packs/{id}": {
+            "delete": {
                 "security": [
                     {
-                        "ApiKeyAuth": [
-                            "authorization"
-                        ]
+                        "ApiKeyAuth": []
                     }
                 ],
-                "description": "Lists submissions ordered by the time they have been updated last.\nRequires user permission: aredl.submission_review",
+                "description": "Deletes a pack and updates all user points that now have the new pack.\nRequires user permission: aredl.manage_packs",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "aredl"
                 ],
-                "summary": "List submissions",
+                "summary": "Delete an AREDL pack",
                 "parameters": [
                     {
-                        "type": "boolean",
-                        "default": false,
-                        "description": "include rejected submissions",
-                        "name": "include_rejected",
-                        "in": "query"
+                        "type": "string",
+                        "description": "internal pack id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/aredl.Submission"
-                            }
-                        }
+                        "description": "OK"
                     },
                     "400": {
                         "description": "Bad Request",
@@ -1036,55 +1425,55 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/aredl/submissions/{id}/accept": {
-            "post": {
+            },
+            "patch": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Requires user permission: aredl.submission_review",
+                "description": "Updates a pack and updates all user points that now have or lost the new pack.\nRequires user permission: aredl.manage_packs",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "aredl"
                 ],
-                "summary": "Accept AREDL submission.",
+                "summary": "Update a AREDL pack",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "internal submission id",
+                        "description": "internal pack id",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "format": "url",
-                        "description": "video url",
-                        "name": "video_url",
+                        "description": "display name",
+                        "name": "name",
                         "in": "query"
                     },
                     {
-                        "type": "boolean",
-                        "description": "whether submisssion was one on mobile",
-                        "name": "mobile",
+                        "type": "string",
+                        "description": "display color",
+                        "name": "color",
                         "in": "query"
                     },
                     {
                         "type": "integer",
-                        "description": "gd id of used ldm",
-                        "name": "ldm_id",
+                        "description": "position of pack",
+                        "name": "placement_order",
                         "in": "query"
                     },
                     {
-                        "type": "string",
-                        "format": "url",
-                        "description": "raw footage",
-                        "name": "raw_footage",
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "new list of internal level ids. Pack has to have at least two levels",
+                        "name": "levels",
                         "in": "query"
                     }
                 ],
@@ -1107,33 +1496,45 @@ const docTemplate = `{
                 }
             }
         },
-        "/aredl/submissions/{id}/reject": {
+        "/aredl/point-formula": {
             "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Requires user permission: aredl.submission_review",
+                "description": "Sets the point formula of the list, adds it to the formula history and recalculates all points.\nFunctions: sqrt, pow, exp, log (base 10 or log(value, base)), ln, min, max, floor, ceil, clamp(value, min, max), if(condition, then, else).\nVariables: level_count (non-legacy levels minus one), legacy_count, total_count and in the formula also x (position) and enjoyment (of the level at position x).\nRequires user permission: aredl.manage_point_formula",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "aredl"
                 ],
-                "summary": "Reject AREDL submission.",
+                "summary": "Apply a point formula",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "internal submission id",
-                        "name": "id",
-                        "in": "path",
+                        "description": "formula that calculates the points of position x",
+                        "name": "formula",
+                        "in": "query",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "rejection reason",
-                        "name": "rejection_reason",
+                        "description": "comma separated list of name=expression that are calculated before the formula",
+                        "name": "precalc",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "formula that calculates the points of legacy position x, only used by lists with the formula legacy policy",
+                        "name": "legacy_formula",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "formula that calculates the points of progress records, can also use points, percentage and percent_to_qualify",
+                        "name": "progress_formula",
                         "in": "query"
                     }
                 ],
@@ -1156,26 +1557,29 @@ const docTemplate = `{
                 }
             }
         },
-        "/me/api-key": {
+        "/aredl/point-formula/history": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Gets the authenticated users api key. If the user does not have one it generates a new one.\nRequires user permission: user_request_api_key",
+                "description": "Lists every point formula that has been applied to the list, newest version first.\nThe first version is the formula that was in effect before the formula was changed for the first time, it has no applier\nRequires user permission: aredl.manage_point_formula",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "global"
+                    "aredl"
                 ],
-                "summary": "Get Api Key",
+                "summary": "Point formula history",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/global.ApiKeyResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/aredl.PointFormulaVersion"
+                            }
                         }
                     },
                     "400": {
@@ -1193,30 +1597,33 @@ const docTemplate = `{
                 }
             }
         },
-        "/me/permissions": {
-            "get": {
+        "/aredl/point-formula/history/{version}/rollback": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Returns all the available permissions to the authenticated user, if there is no authenticaiton provided, the permissions will be empty",
+                "description": "Reapplies the formula of an earlier version and recalculates all points. The rollback is added to the history as a new version.\nRequires user permission: aredl.manage_point_formula",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "global"
+                    "aredl"
+                ],
+                "summary": "Roll back the point formula",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "version of the formula to reapply",
+                        "name": "version",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Get a list of Permissions",
                 "responses": {
                     "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "$ref": "#/definitions/middlewares.PermissionData"
-                            }
-                        }
+                        "description": "OK"
                     },
                     "400": {
                         "description": "Bad Request",
@@ -1233,29 +1640,1577 @@ const docTemplate = `{
                 }
             }
         },
-        "/merge-requests": {
-            "get": {
+        "/aredl/point-formula/preview": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Lists all open merge requests\nRequires user permission: user_merge_review",
+                "description": "Calculates the points the given formula would result in from the current levels and records without saving anything.\nReturns the old and new points of every position, the pack point changes and the rank changes of the top leaderboard players.\nFunctions: sqrt, pow, exp, log (base 10 or log(value, base)), ln, min, max, floor, ceil, clamp(value, min, max), if(condition, then, else).\nVariables: level_count (non-legacy levels minus one), legacy_count, total_count and in the formula also x (position) and enjoyment (of the level at position x).\nRequires user permission: aredl.manage_point_formula",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "global"
+                    "aredl"
+                ],
+                "summary": "Preview a point formula",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "formula that calculates the points of position x",
+                        "name": "formula",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma separated list of name=expression that are calculated before the formula",
+                        "name": "precalc",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "formula that calculates the points of legacy position x, only used by lists with the formula legacy policy",
+                        "name": "legacy_formula",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "formula that calculates the points of progress records, can also use points, percentage and percent_to_qualify",
+                        "name": "progress_formula",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 500,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 50,
+                        "description": "number of top leaderboard players to compare",
+                        "name": "leaderboard_top",
+                        "in": "query"
+                    }
                 ],
-                "summary": "List name merge requests",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/global.NameChangeRequest"
-                            }
+                            "$ref": "#/definitions/demonlist.PointFormulaPreview"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/aredl/profiles/{id}": {
+            "get": {
+                "description": "Gives detailed information about a user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "aredl"
+                ],
+                "summary": "User info",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "if the provided id is a discord id",
+                        "name": "is_discord_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/aredl.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/aredl/profiles/{id}/history": {
+            "get": {
+                "description": "Lists the rank and points of a user over time, oldest first. A new entry is added by the daily snapshot whenever the rank or points of the user changed since the previous one.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "aredl"
+                ],
+                "summary": "Rank history of a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "if the provided id is a discord id",
+                        "name": "is_discord_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/aredl.RankHistoryEntry"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/aredl/records/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Removes a record. The records placed after it on the same level move up and the leaderboard and completed packs of the player get recalculated.\nThe player can see the reason in their removed records.\nRequires user permission: aredl.manage_records",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "aredl"
+                ],
+                "summary": "Remove AREDL record",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "internal record id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "reason the record is removed for, shown to the player",
+                        "name": "reason",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Edits a record. Setting a percentage below 100 demotes a completion to a progress record. The leaderboard and completed packs of the player get recalculated.\nRequires user permission: aredl.manage_records",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "aredl"
+                ],
+                "summary": "Update AREDL record",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "internal record id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "reason for the change",
+                        "name": "reason",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "format": "url",
+                        "description": "display video url",
+                        "name": "video_url",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "whether the record was done on mobile",
+                        "name": "mobile",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "reached percentage",
+                        "name": "percentage",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "gd id of used ldm",
+                        "name": "ldm_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "url",
+                        "description": "raw footage",
+                        "name": "raw_footage",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/aredl/submissions": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": [
+                            "authorization"
+                        ]
+                    }
+                ],
+                "description": "Lists submissions ordered by the time they have been updated last.\nRequires user permission: aredl.submission_review",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "aredl"
+                ],
+                "summary": "List submissions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "JSON array of statuses to include, defaults to [\\",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "only include submissions that are not claimed by a reviewer",
+                        "name": "unclaimed",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/aredl.Submission"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/aredl/submissions/{id}/accept": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Fails if another reviewer holds an active claim on the submission.\nThe submission is kept with the accepted status so the submitter can see its timeline. It is replaced once the level is submitted again.\nRequires user permission: aredl.submission_review",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "aredl"
+                ],
+                "summary": "Accept AREDL submission.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "internal submission id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "format": "url",
+                        "description": "video url",
+                        "name": "video_url",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "whether submisssion was one on mobile",
+                        "name": "mobile",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "reached percentage",
+                        "name": "percentage",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "gd id of used ldm",
+                        "name": "ldm_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "url",
+                        "description": "raw footage",
+                        "name": "raw_footage",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "comment for the submitter that is added to the timeline",
+                        "name": "comment",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/aredl/submissions/{id}/claim": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Marks the submission as being reviewed by the authenticated user. While the claim is active other reviewers cannot accept or reject the submission.\nClaiming an already claimed submission extends the claim. Claims of other reviewers can only be taken over once they expired.\nRequires user permission: aredl.submission_review",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "aredl"
+                ],
+                "summary": "Claim AREDL submission",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "internal submission id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "maximum": 240,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 30,
+                        "description": "minutes until the claim expires",
+                        "name": "duration",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/aredl/submissions/{id}/comments": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists the comment thread of a submission ordered from oldest to newest.\nRequires user permission: aredl.submission_review",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "aredl"
+                ],
+                "summary": "AREDL submission comments",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "internal submission id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/aredl.SubmissionComment"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Adds a reviewer message to the comment thread of a submission.\nWith needs_info the submission is moved out of the queue until the submitter replies. This fails if another reviewer holds an active claim on the submission.\nRequires user permission: aredl.submission_review",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "aredl"
+                ],
+                "summary": "Comment on AREDL submission",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "internal submission id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "message, max 1000 characters",
+                        "name": "message",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "whether the submitter has to reply before the submission is reviewed further",
+                        "name": "needs_info",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/aredl/submissions/{id}/reject": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Rejected submissions can be submitted again unless the rejection is final.\nFails if another reviewer holds an active claim on the submission.\nRequires user permission: aredl.submission_review",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "aredl"
+                ],
+                "summary": "Reject AREDL submission.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "internal submission id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "rejection reason",
+                        "name": "rejection_reason",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "whether the submitter is not allowed to submit the level again",
+                        "name": "final",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/aredl/submissions/{id}/status": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Moves a submission between the review statuses. Use the accept and reject endpoints to finish the review.\nFails if another reviewer holds an active claim on the submission.\nRequires user permission: aredl.submission_review",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "aredl"
+                ],
+                "summary": "Change AREDL submission status",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "internal submission id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "pending",
+                            "under_review",
+                            "needs_info"
+                        ],
+                        "type": "string",
+                        "description": "new status",
+                        "name": "status",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "comment that is shown to the submitter in the timeline",
+                        "name": "comment",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/aredl/submissions/{id}/timeline": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists all status changes of a submission ordered from oldest to newest.\nRequires user permission: aredl.submission_review",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "aredl"
+                ],
+                "summary": "AREDL submission timeline",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "internal submission id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/aredl.SubmissionTimelineEntry"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/aredl/submissions/{id}/unclaim": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Releases the claim of the authenticated user on the submission, so other reviewers can review it.\nRequires user permission: aredl.submission_review",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "aredl"
+                ],
+                "summary": "Unclaim AREDL submission",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "internal submission id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/audit-log": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Paged list of all moderation and list management actions, newest first.\nEvery entry contains the user or admin that performed the action, the affected record and snapshots of it before and after the action.\nRequires user permission: audit_log_view",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "global"
+                ],
+                "summary": "Audit log",
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 1,
+                        "description": "select page",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 200,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 40,
+                        "description": "number of results per page",
+                        "name": "per_page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only actions performed by the given internal user id",
+                        "name": "actor_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only the given action, for example user_banned or pack_updated",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only actions on the given list, use global for actions that are not related to a list",
+                        "name": "list",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only actions on records of the given table",
+                        "name": "target_table",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only actions on the record with the given id",
+                        "name": "target_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only actions at or after the given time, format: 2006-01-02 or 2006-01-02 15:04:05",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only actions before the given time, format: 2006-01-02 or 2006-01-02 15:04:05",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/global.AuditLog"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/api-keys": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists the api keys of the authenticated user. The keys themselves are only shown when they are created or rotated, prefix holds the first characters to recognize them\nRequires user permission: user_request_api_key",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "global"
+                ],
+                "summary": "List api keys",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/global.ApiKey"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Creates a new api key for the authenticated user. The key is only returned once and cannot be requested again.\nScopes limit the key to the given permissions in the format \u003clist\u003e.\u003caction\u003e, for example aredl.user_submit or global.user_request_api_key. A key without scopes has every permission of the user.\nA request authenticated with a scoped api key can only create keys with a subset of its scopes\nRequires user permission: user_request_api_key",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "global"
+                ],
+                "summary": "Create api key",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "name of the key",
+                        "name": "name",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "permissions the key is limited to, json array",
+                        "name": "scopes",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "time the key stops working, format: 2006-01-02 or 2006-01-02 15:04:05",
+                        "name": "expires",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/global.NewApiKey"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/api-keys/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Deletes an api key of the authenticated user, it can't be used afterwards\nRequires user permission: user_request_api_key",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "global"
+                ],
+                "summary": "Revoke api key",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "api key id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/api-keys/{id}/rotate": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Replaces an api key of the authenticated user with a new one. Name, scopes and expiry stay the same, the old key stops working immediately.\nA request authenticated with a scoped api key can only rotate keys with a subset of its scopes\nRequires user permission: user_request_api_key",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "global"
+                ],
+                "summary": "Rotate api key",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "api key id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/global.NewApiKey"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/permissions": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Returns all the available permissions to the authenticated user, if there is no authenticaiton provided, the permissions will be empty.\nRequests authenticated with a scoped api key only get the permissions within the scopes of the key\nPermissions that come from roles limited in time include the time they expire",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "global"
+                ],
+                "summary": "Get a list of Permissions",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "$ref": "#/definitions/middlewares.PermissionData"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/merge-requests": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists all open merge requests\nRequires user permission: user_merge_review",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "global"
+                ],
+                "summary": "List name merge requests",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/global.NameChangeRequest"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Creates a merge request for the user with a placeholder user. Needs to be reviewed by a moderator.\nRequires user permission: user_request_merge",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "global"
+                ],
+                "summary": "Merge Request",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "name of the placeholder user to be merged with",
+                        "name": "placeholder_name",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/merge-requests/{id}/accept": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Accepts and merge request and merges the respective users\nRequires user permission: user_merge_review",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "global"
+                ],
+                "summary": "Accept merge request",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "request id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/merge-requests/{id}/reject": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Rejects merge request\nRequires user permission: user_merge_review",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "global"
+                ],
+                "summary": "Reject merge request",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "request id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/name-change-requests": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists all open name change requests\nRequires user permission: name_change_review",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "global"
+                ],
+                "summary": "List name change requests",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/global.NameChangeRequest"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "ApiKeyAuth": [
+                            "authorization"
+                        ]
+                    }
+                ],
+                "description": "Creates a name change request for the user. Needs to be reviewed by a moderator.\nRequires user permission: user_request_name_change",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "global"
+                ],
+                "summary": "Name Change Request",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "name to change to",
+                        "name": "new_name",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/name-change-requests/{id}/accept": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Accepts a name change request from a user\nRequires user permission: name_change_review",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "global"
+                ],
+                "summary": "Accept name change request",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "request id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/name-change-requests/{id}/reject": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Rejects a name change request from a user\nRequires user permission: name_change_review",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "global"
+                ],
+                "summary": "Reject name change request",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "request id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/permissions": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists the permissions of all roles. A permission can belong to multiple roles, affected roles are the roles of users the permission can be used on.\nRoles also have the permissions of the roles they inherit from, see list roles\nRequires user permission: manage_roles",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "global"
+                ],
+                "summary": "List role permissions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "only permissions of the given role",
+                        "name": "role",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/global.RolePermission"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Gives the roles a permission\nRequires user permission: manage_roles\nAdditionally the user needs to be able to affect the roles with their permission, hold the given permission themselves and be able to affect the affected roles with it.\nPermissions affecting lower ranks can only be granted by users whose permission affects lower ranks as well",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "global"
+                ],
+                "summary": "Create role permission",
+                "parameters": [
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "roles that get the permission",
+                        "name": "roles",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "list the permission belongs to, global for permissions that are not bound to a list",
+                        "name": "list",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "action of the permission",
+                        "name": "action",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "roles of the users the permission can be used on",
+                        "name": "affected_roles",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "the permission can also be used on users whose roles are ranked below the role of the user using it",
+                        "name": "affects_lower_ranks",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/global.RolePermission"
                         }
                     },
                     "400": {
@@ -1271,27 +3226,29 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "post": {
+            }
+        },
+        "/permissions/{id}": {
+            "delete": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Creates a merge request for the user with a placeholder user. Needs to be reviewed by a moderator.\nRequires user permission: user_request_merge",
+                "description": "Takes a permission from its roles\nRequires user permission: manage_roles\nAdditionally the user needs to be able to grant the permission, see create role permission",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "global"
                 ],
-                "summary": "Merge Request",
+                "summary": "Delete role permission",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "name of the placeholder user to be merged with",
-                        "name": "placeholder_name",
-                        "in": "query",
+                        "description": "permission id",
+                        "name": "id",
+                        "in": "path",
                         "required": true
                     }
                 ],
@@ -1312,35 +3269,74 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/merge-requests/{id}/accept": {
-            "post": {
+            },
+            "patch": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Accepts and merge request and merges the respective users\nRequires user permission: user_merge_review",
+                "description": "Changes a permission, fields that are not given stay the same\nRequires user permission: manage_roles\nAdditionally the user needs to be able to grant the permission before and after the change, see create role permission",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "global"
                 ],
-                "summary": "Accept merge request",
+                "summary": "Update role permission",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "request id",
+                        "description": "permission id",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "roles that get the permission",
+                        "name": "roles",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "list the permission belongs to, global for permissions that are not bound to a list",
+                        "name": "list",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "action of the permission",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "roles of the users the permission can be used on",
+                        "name": "affected_roles",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "the permission can also be used on users whose roles are ranked below the role of the user using it",
+                        "name": "affects_lower_ranks",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/global.RolePermission"
+                        }
                     },
                     "400": {
                         "description": "Bad Request",
@@ -1357,33 +3353,43 @@ const docTemplate = `{
                 }
             }
         },
-        "/merge-requests/{id}/reject": {
-            "post": {
+        "/permissions/{list}/{action}/users": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Rejects merge request\nRequires user permission: user_merge_review",
+                "description": "Lists every role that has the permission, directly or inherited from a parent, and every user with one of these roles. Used to audit who has access to an action\nRequires user permission: permission_holders_view",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "global"
                 ],
-                "summary": "Reject merge request",
+                "summary": "Users with a permission",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "request id",
-                        "name": "id",
+                        "description": "list the permission belongs to, global for permissions that are not bound to a list",
+                        "name": "list",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "action of the permission",
+                        "name": "action",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/global.PermissionHolders"
+                        }
                     },
                     "400": {
                         "description": "Bad Request",
@@ -1400,28 +3406,28 @@ const docTemplate = `{
                 }
             }
         },
-        "/name-change-requests": {
+        "/roles": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Lists all open name change requests\nRequires user permission: name_change_review",
+                "description": "Lists all roles with their place in the hierarchy. The default role is given to every user.\nA role has every permission of its parent and the parents of it. Permissions that affect lower ranks can be used on every role with a lower rank than the highest ranked role of the user\nRequires user permission: manage_roles",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "global"
                 ],
-                "summary": "List name change requests",
+                "summary": "List roles",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/global.NameChangeRequest"
+                                "$ref": "#/definitions/middlewares.Role"
                             }
                         }
                     },
@@ -1439,32 +3445,40 @@ const docTemplate = `{
                     }
                 }
             },
-            "put": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
-                    },
-                    {
-                        "ApiKeyAuth": [
-                            "authorization"
-                        ]
                     }
                 ],
-                "description": "Creates a name change request for the user. Needs to be reviewed by a moderator.\nRequires user permission: user_request_name_change",
+                "description": "Creates a new role without permissions. The creator can assign the role and manage its permissions afterward\nAdditionally the user needs to be able to affect the parent with their permission\nAdmins are not bound to the role hierarchy\nRequires user permission: manage_roles",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "global"
                 ],
-                "summary": "Name Change Request",
+                "summary": "Create role",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "name to change to",
-                        "name": "new_name",
+                        "description": "name of the role, only letters, digits and underscores",
+                        "name": "name",
                         "in": "query",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "rank of the role, has to be below your own rank",
+                        "name": "rank",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "role to inherit the permissions from",
+                        "name": "parent",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -1486,26 +3500,26 @@ const docTemplate = `{
                 }
             }
         },
-        "/name-change-requests/{id}/accept": {
-            "post": {
+        "/roles/{role}": {
+            "delete": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Accepts a name change request from a user\nRequires user permission: name_change_review",
+                "description": "Deletes a role and removes it from every user. Permissions that only belong to the role are deleted. The default role cannot be deleted\nRequires user permission: manage_roles\nAdditionally the user needs to be able to affect the role with their permission\nAdmins are not bound to the role hierarchy",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "global"
                 ],
-                "summary": "Accept name change request",
+                "summary": "Delete role",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "request id",
-                        "name": "id",
+                        "description": "name of the role",
+                        "name": "role",
                         "in": "path",
                         "required": true
                     }
@@ -1527,30 +3541,46 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/name-change-requests/{id}/reject": {
-            "post": {
+            },
+            "patch": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Rejects a name change request from a user\nRequires user permission: name_change_review",
+                "description": "Renames a role or changes its place in the hierarchy, fields that are not given stay the same. Users and permissions keep a renamed role under its new name. The default role cannot be renamed\nRequires user permission: manage_roles\nAdditionally the user needs to be able to affect the role and its new parent with their permission\nAdmins are not bound to the role hierarchy",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "global"
                 ],
-                "summary": "Reject name change request",
+                "summary": "Update role",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "request id",
-                        "name": "id",
+                        "description": "name of the role",
+                        "name": "role",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "new name of the role, only letters, digits and underscores",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "rank of the role, has to be below your own rank",
+                        "name": "rank",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "role to inherit the permissions from, empty to remove the parent",
+                        "name": "parent",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -1782,7 +3812,7 @@ const docTemplate = `{
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Promote or demote a user\nRequires user permission: user_change_role\nAdditionally the user needs to be able to affect the user with their permission and give the user the new role",
+                "description": "Promote or demote a user\nRequires user permission: user_change_role\nAdditionally the user needs to be able to affect the user with their permission and give the user the new role\nRoles that are added can be limited to a time range with starts and expires, roles the user already has keep their time range",
                 "produces": [
                     "application/json"
                 ],
@@ -1808,6 +3838,18 @@ const docTemplate = `{
                         "name": "roles",
                         "in": "query",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "time the added roles start, format: 2006-01-02 or 2006-01-02 15:04:05",
+                        "name": "starts",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "time the added roles expire, format: 2006-01-02 or 2006-01-02 15:04:05",
+                        "name": "expires",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -1829,6 +3871,71 @@ const docTemplate = `{
                 }
             }
         },
+        "/users/{id}/role/{role}": {
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Changes the time range of a role the user has. Empty values remove the limit, so an empty expiry makes the role permanent\nRequires user permission: user_change_role\nAdditionally the user needs to be able to affect the user and the role with their permission",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "global"
+                ],
+                "summary": "Change role time range",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "internal user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "role of the user",
+                        "name": "role",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "time the role starts, format: 2006-01-02 or 2006-01-02 15:04:05",
+                        "name": "starts",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "time the role expires, format: 2006-01-02 or 2006-01-02 15:04:05",
+                        "name": "expires",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/middlewares.RoleGrant"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/util.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/users/{id}/unban": {
             "post": {
                 "security": [
@@ -1871,9 +3978,141 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
+        }
+    },
+    "definitions": {
+        "aredl.Changelog": {
+            "type": "object",
+            "properties": {
+                "list": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/aredl.ChangelogEntry"
+                    }
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "pages": {
+                    "type": "integer"
+                }
+            }
+        },
+        "aredl.ChangelogChange": {
+            "type": "object",
+            "properties": {
+                "above": {
+                    "description": "Above is the level directly below the new position",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/aredl.ChangelogLevel"
+                        }
+                    ]
+                },
+                "action": {
+                    "type": "string",
+                    "enum": [
+                        "placed",
+                        "movedUp",
+                        "movedDown",
+                        "movedToLegacy",
+                        "movedFromLegacy",
+                        "removed"
+                    ]
+                },
+                "below": {
+                    "description": "Below is the level directly above the new position, only set for levels that moved down",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/aredl.ChangelogLevel"
+                        }
+                    ]
+                },
+                "level": {
+                    "$ref": "#/definitions/aredl.ChangelogLevel"
+                },
+                "new_position": {
+                    "type": "integer"
+                },
+                "old_position": {
+                    "description": "OldPosition is empty for placements",
+                    "type": "integer"
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "aredl.ChangelogEntry": {
+            "type": "object",
+            "properties": {
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/aredl.ChangelogChange"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "list_update": {
+                    "description": "ListUpdate is set if the entry is a list update that contains multiple changes",
+                    "type": "object",
+                    "properties": {
+                        "changelog": {
+                            "type": "string"
+                        },
+                        "id": {
+                            "type": "string"
+                        },
+                        "name": {
+                            "type": "string"
+                        }
+                    }
+                },
+                "shifted": {
+                    "description": "Shifted is the number of other levels that moved because of the changes",
+                    "type": "integer"
+                },
+                "timestamp": {
+                    "$ref": "#/definitions/types.DateTime"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "aredl.ChangelogLevel": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "level_id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "aredl.CountryLeaderboardEntry": {
+            "type": "object",
+            "properties": {
+                "country": {
+                    "type": "string"
+                },
+                "players": {
+                    "type": "integer"
+                },
+                "points": {
+                    "type": "number"
+                },
+                "rank": {
+                    "type": "integer"
+                }
+            }
+        },
         "aredl.HistoryEntry": {
             "type": "object",
             "properties": {
@@ -1887,10 +4126,12 @@ const docTemplate = `{
                         "movedPastUp",
                         "movedPastDown",
                         "movedToLegacy",
-                        "movedFromLegacy"
+                        "movedFromLegacy",
+                        "removed"
                     ]
                 },
                 "cause": {
+                    "description": "Cause is empty if the level that caused the entry has been removed from the list",
                     "type": "object",
                     "properties": {
                         "id": {
@@ -1904,6 +4145,18 @@ const docTemplate = `{
                         }
                     }
                 },
+                "list_update": {
+                    "description": "ListUpdate is set if the entry was part of a list update",
+                    "type": "object",
+                    "properties": {
+                        "id": {
+                            "type": "string"
+                        },
+                        "name": {
+                            "type": "string"
+                        }
+                    }
+                },
                 "new_position": {
                     "type": "integer"
                 },
@@ -1918,29 +4171,7 @@ const docTemplate = `{
                 "list": {
                     "type": "array",
                     "items": {
-                        "type": "object",
-                        "properties": {
-                            "points": {
-                                "type": "number"
-                            },
-                            "rank": {
-                                "type": "integer"
-                            },
-                            "user": {
-                                "type": "object",
-                                "properties": {
-                                    "country": {
-                                        "type": "string"
-                                    },
-                                    "global_name": {
-                                        "type": "string"
-                                    },
-                                    "id": {
-                                        "type": "string"
-                                    }
-                                }
-                            }
-                        }
+                        "$ref": "#/definitions/aredl.LeaderboardEntry"
                     }
                 },
                 "page": {
@@ -1948,6 +4179,46 @@ const docTemplate = `{
                 },
                 "pages": {
                     "type": "integer"
+                },
+                "snapshot_at": {
+                    "description": "SnapshotAt is the time of the snapshot the leaderboard was loaded from if a past leaderboard was requested",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/types.DateTime"
+                        }
+                    ]
+                }
+            }
+        },
+        "aredl.LeaderboardEntry": {
+            "type": "object",
+            "properties": {
+                "country_rank": {
+                    "description": "CountryRank is the rank within the country of the player, it is empty for players without a country",
+                    "type": "integer"
+                },
+                "points": {
+                    "type": "number"
+                },
+                "rank": {
+                    "type": "integer"
+                },
+                "user": {
+                    "$ref": "#/definitions/aredl.LeaderboardUser"
+                }
+            }
+        },
+        "aredl.LeaderboardUser": {
+            "type": "object",
+            "properties": {
+                "country": {
+                    "type": "string"
+                },
+                "global_name": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
                 }
             }
         },
@@ -1963,9 +4234,15 @@ const docTemplate = `{
                 "custom_song": {
                     "type": "string"
                 },
+                "enjoyment": {
+                    "type": "number"
+                },
                 "id": {
                     "type": "string"
                 },
+                "is_edel_pending": {
+                    "type": "boolean"
+                },
                 "legacy": {
                     "type": "boolean"
                 },
@@ -1984,6 +4261,9 @@ const docTemplate = `{
                         "$ref": "#/definitions/aredl.LevelPack"
                     }
                 },
+                "percent_to_qualify": {
+                    "type": "integer"
+                },
                 "points": {
                     "type": "number"
                 },
@@ -2030,6 +4310,9 @@ const docTemplate = `{
                 "mobile": {
                     "type": "boolean"
                 },
+                "percentage": {
+                    "type": "integer"
+                },
                 "submitted_by": {
                     "$ref": "#/definitions/aredl.LevelUser"
                 },
@@ -2052,9 +4335,15 @@ const docTemplate = `{
         "aredl.ListEntry": {
             "type": "object",
             "properties": {
+                "enjoyment": {
+                    "type": "number"
+                },
                 "id": {
                     "type": "string"
                 },
+                "is_edel_pending": {
+                    "type": "boolean"
+                },
                 "legacy": {
                     "type": "boolean"
                 },
@@ -2064,6 +4353,9 @@ const docTemplate = `{
                 "name": {
                     "type": "string"
                 },
+                "percent_to_qualify": {
+                    "type": "integer"
+                },
                 "points": {
                     "type": "number"
                 },
@@ -2110,14 +4402,17 @@ const docTemplate = `{
                 "mobile": {
                     "type": "boolean"
                 },
+                "percentage": {
+                    "type": "integer"
+                },
                 "priority": {
                     "type": "boolean"
                 },
                 "raw_footage": {
                     "type": "string"
                 },
-                "rejected": {
-                    "type": "boolean"
+                "status": {
+                    "type": "string"
                 },
                 "updated": {
                     "$ref": "#/definitions/types.DateTime"
@@ -2130,6 +4425,14 @@ const docTemplate = `{
         "aredl.NameUser": {
             "type": "object",
             "properties": {
+                "expires": {
+                    "description": "Expires is the time the user loses the role, it is omitted for permanent roles",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/types.DateTime"
+                        }
+                    ]
+                },
                 "global_name": {
                     "type": "string"
                 },
@@ -2181,6 +4484,58 @@ const docTemplate = `{
                 }
             }
         },
+        "aredl.PointFormulaVersion": {
+            "type": "object",
+            "properties": {
+                "applied_by": {
+                    "type": "object",
+                    "properties": {
+                        "global_name": {
+                            "type": "string"
+                        },
+                        "id": {
+                            "type": "string"
+                        }
+                    }
+                },
+                "applied_by_admin": {
+                    "description": "AppliedByAdmin is the id of the admin that changed the formula in the admin dashboard",
+                    "type": "string"
+                },
+                "formula": {
+                    "type": "string"
+                },
+                "legacy_formula": {
+                    "type": "string"
+                },
+                "precalc": {
+                    "type": "string"
+                },
+                "progress_formula": {
+                    "type": "string"
+                },
+                "timestamp": {
+                    "$ref": "#/definitions/types.DateTime"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "aredl.RankHistoryEntry": {
+            "type": "object",
+            "properties": {
+                "points": {
+                    "type": "number"
+                },
+                "rank": {
+                    "type": "integer"
+                },
+                "timestamp": {
+                    "$ref": "#/definitions/types.DateTime"
+                }
+            }
+        },
         "aredl.Record": {
             "type": "object",
             "properties": {
@@ -2210,6 +4565,9 @@ const docTemplate = `{
                 "mobile": {
                     "type": "boolean"
                 },
+                "percentage": {
+                    "type": "integer"
+                },
                 "raw_footage": {
                     "type": "string"
                 },
@@ -2221,12 +4579,64 @@ const docTemplate = `{
                 }
             }
         },
+        "aredl.RemovedRecord": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "$ref": "#/definitions/types.DateTime"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "level": {
+                    "type": "object",
+                    "properties": {
+                        "id": {
+                            "type": "string"
+                        },
+                        "level_id": {
+                            "type": "integer"
+                        },
+                        "name": {
+                            "type": "string"
+                        }
+                    }
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "record": {
+                    "description": "Record is the record as it was when it got removed",
+                    "type": "object"
+                }
+            }
+        },
         "aredl.Submission": {
             "type": "object",
             "properties": {
                 "additional_notes": {
                     "type": "string"
                 },
+                "claim_expires": {
+                    "description": "ClaimExpires is only set while the claim is active",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/types.DateTime"
+                        }
+                    ]
+                },
+                "claimed_by": {
+                    "description": "ClaimedBy is only set while the claim is active",
+                    "type": "object",
+                    "properties": {
+                        "global_name": {
+                            "type": "string"
+                        },
+                        "id": {
+                            "type": "string"
+                        }
+                    }
+                },
                 "created": {
                     "$ref": "#/definitions/types.DateTime"
                 },
@@ -2256,15 +4666,15 @@ const docTemplate = `{
                 "mobile": {
                     "type": "boolean"
                 },
+                "percentage": {
+                    "type": "integer"
+                },
                 "priority": {
                     "type": "boolean"
                 },
                 "raw_footage": {
                     "type": "string"
                 },
-                "rejected": {
-                    "type": "boolean"
-                },
                 "reviewer": {
                     "type": "object",
                     "properties": {
@@ -2276,6 +4686,9 @@ const docTemplate = `{
                         }
                     }
                 },
+                "status": {
+                    "type": "string"
+                },
                 "submitted_by": {
                     "type": "object",
                     "properties": {
@@ -2290,7 +4703,66 @@ const docTemplate = `{
                 "updated": {
                     "$ref": "#/definitions/types.DateTime"
                 },
-                "video_url": {
+                "video_url": {
+                    "type": "string"
+                }
+            }
+        },
+        "aredl.SubmissionComment": {
+            "type": "object",
+            "properties": {
+                "author": {
+                    "type": "object",
+                    "properties": {
+                        "global_name": {
+                            "type": "string"
+                        },
+                        "id": {
+                            "type": "string"
+                        }
+                    }
+                },
+                "created": {
+                    "$ref": "#/definitions/types.DateTime"
+                },
+                "from_reviewer": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "aredl.SubmissionTimelineEntry": {
+            "type": "object",
+            "properties": {
+                "changed_by": {
+                    "type": "object",
+                    "properties": {
+                        "global_name": {
+                            "type": "string"
+                        },
+                        "id": {
+                            "type": "string"
+                        }
+                    }
+                },
+                "comment": {
+                    "type": "string"
+                },
+                "created": {
+                    "$ref": "#/definitions/types.DateTime"
+                },
+                "from_status": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "status": {
                     "type": "string"
                 }
             }
@@ -2466,9 +4938,15 @@ const docTemplate = `{
                             "mobile": {
                                 "type": "boolean"
                             },
+                            "percentage": {
+                                "type": "integer"
+                            },
                             "placement_order": {
                                 "type": "integer"
                             },
+                            "points": {
+                                "type": "number"
+                            },
                             "video_url": {
                                 "type": "string"
                             }
@@ -2483,17 +4961,181 @@ const docTemplate = `{
                 }
             }
         },
-        "global.ApiKeyResponse": {
+        "demonlist.LeaderboardPreview": {
+            "type": "object",
+            "properties": {
+                "global_name": {
+                    "type": "string"
+                },
+                "new_points": {
+                    "type": "number"
+                },
+                "new_rank": {
+                    "type": "integer"
+                },
+                "old_points": {
+                    "type": "number"
+                },
+                "old_rank": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "demonlist.PackPointsPreview": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "new_points": {
+                    "type": "number"
+                },
+                "old_points": {
+                    "type": "number"
+                }
+            }
+        },
+        "demonlist.PointFormulaPreview": {
+            "type": "object",
+            "properties": {
+                "leaderboard": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/demonlist.LeaderboardPreview"
+                    }
+                },
+                "packs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/demonlist.PackPointsPreview"
+                    }
+                },
+                "positions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/demonlist.PositionPointsPreview"
+                    }
+                }
+            }
+        },
+        "demonlist.PositionPointsPreview": {
+            "type": "object",
+            "properties": {
+                "level_id": {
+                    "type": "string"
+                },
+                "level_name": {
+                    "type": "string"
+                },
+                "new_points": {
+                    "type": "number"
+                },
+                "old_points": {
+                    "type": "number"
+                },
+                "position": {
+                    "type": "integer"
+                }
+            }
+        },
+        "global.ApiKey": {
             "type": "object",
             "properties": {
-                "api_key": {
+                "created": {
+                    "$ref": "#/definitions/types.DateTime"
+                },
+                "expires": {
+                    "$ref": "#/definitions/types.DateTime"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_used": {
+                    "$ref": "#/definitions/types.DateTime"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
                     "type": "string"
                 },
-                "newly_generated": {
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "trusted": {
                     "type": "boolean"
                 }
             }
         },
+        "global.AuditLog": {
+            "type": "object",
+            "properties": {
+                "list": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/global.AuditLogEntry"
+                    }
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "pages": {
+                    "type": "integer"
+                }
+            }
+        },
+        "global.AuditLogEntry": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "actor": {
+                    "type": "object",
+                    "properties": {
+                        "global_name": {
+                            "type": "string"
+                        },
+                        "id": {
+                            "type": "string"
+                        }
+                    }
+                },
+                "actor_admin": {
+                    "type": "string"
+                },
+                "after": {
+                    "type": "object"
+                },
+                "before": {
+                    "type": "object"
+                },
+                "created": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "list": {
+                    "type": "string"
+                },
+                "target_id": {
+                    "type": "string"
+                },
+                "target_table": {
+                    "type": "string"
+                }
+            }
+        },
         "global.CreatePlaceholderResponse": {
             "type": "object",
             "properties": {
@@ -2524,6 +5166,110 @@ const docTemplate = `{
                 }
             }
         },
+        "global.NewApiKey": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "$ref": "#/definitions/types.DateTime"
+                },
+                "expires": {
+                    "$ref": "#/definitions/types.DateTime"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "last_used": {
+                    "$ref": "#/definitions/types.DateTime"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "trusted": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "global.PermissionHolder": {
+            "type": "object",
+            "properties": {
+                "global_name": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "global.PermissionHolders": {
+            "type": "object",
+            "properties": {
+                "everyone": {
+                    "description": "Everyone is set if the permission is given to the default role, so every user holds it",
+                    "type": "boolean"
+                },
+                "roles": {
+                    "description": "Roles are the roles that have the permission, including roles inheriting it",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "users": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/global.PermissionHolder"
+                    }
+                }
+            }
+        },
+        "global.RolePermission": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "affected_roles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "affects_lower_ranks": {
+                    "description": "AffectsLowerRanks makes the permission affect every role ranked below the role of the user in addition to the affected roles",
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "list": {
+                    "type": "string"
+                },
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
         "global.UserEntry": {
             "type": "object",
             "properties": {
@@ -2546,6 +5292,46 @@ const docTemplate = `{
                     "items": {
                         "type": "string"
                     }
+                },
+                "affects_lower_ranks": {
+                    "description": "AffectsLowerRanks is set if the affected roles include every role ranked below the user",
+                    "type": "boolean"
+                },
+                "expires": {
+                    "description": "Expires is the time the user loses the permission because their roles giving it expire. It is nil for permanent permissions",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/types.DateTime"
+                        }
+                    ]
+                }
+            }
+        },
+        "middlewares.Role": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "parent": {
+                    "type": "string"
+                },
+                "rank": {
+                    "type": "integer"
+                }
+            }
+        },
+        "middlewares.RoleGrant": {
+            "type": "object",
+            "properties": {
+                "expires": {
+                    "$ref": "#/definitions/types.DateTime"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "starts": {
+                    "$ref": "#/definitions/types.DateTime"
                 }
             }
         },
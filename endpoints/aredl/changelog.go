@@ -0,0 +1,332 @@
+package aredl
+
+import (
+	"AREDL/demonlist"
+	"AREDL/middlewares"
+	"AREDL/util"
+	"database/sql"
+	"errors"
+	"fmt"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/tools/types"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// changelogActions are the history actions of the level that caused a change
+	changelogActions = "'placed', 'movedUp', 'movedDown', 'movedToLegacy', 'movedFromLegacy', 'removed'"
+	// changelogSideEffects are the history actions of levels that moved because of another level
+	changelogSideEffects = "'placedAbove', 'movedPastUp', 'movedPastDown'"
+)
+
+type ChangelogLevel struct {
+	Id      string `json:"id"`
+	Name    string `json:"name"`
+	LevelId int    `json:"level_id"`
+}
+
+type ChangelogChange struct {
+	Action      string         `json:"action" enums:"placed,movedUp,movedDown,movedToLegacy,movedFromLegacy,removed"`
+	Level       ChangelogLevel `json:"level"`
+	NewPosition int            `json:"new_position"`
+	// OldPosition is empty for placements
+	OldPosition int `json:"old_position,omitempty"`
+	// Above is the level directly below the new position
+	Above *ChangelogLevel `json:"above,omitempty"`
+	// Below is the level directly above the new position, only set for levels that moved down
+	Below *ChangelogLevel `json:"below,omitempty"`
+	Text  string          `json:"text"`
+}
+
+type ChangelogEntry struct {
+	Id      string         `json:"id"`
+	Created types.DateTime `json:"timestamp"`
+	Title   string         `json:"title"`
+	// ListUpdate is set if the entry is a list update that contains multiple changes
+	ListUpdate *struct {
+		Id        string `json:"id"`
+		Name      string `json:"name"`
+		Changelog string `json:"changelog"`
+	} `json:"list_update,omitempty"`
+	Changes []ChangelogChange `json:"changes"`
+	// Shifted is the number of other levels that moved because of the changes
+	Shifted int `json:"shifted"`
+}
+
+type Changelog struct {
+	List  []ChangelogEntry `json:"list"`
+	Page  int              `json:"page"`
+	Pages int              `json:"pages"`
+}
+
+type changelogRow struct {
+	Id          string         `db:"id"`
+	Action      string         `db:"action"`
+	NewPosition int            `db:"new_position"`
+	OldPosition int            `db:"old_position"`
+	Created     types.DateTime `db:"created"`
+	Cause       string         `db:"cause"`
+	Level       string         `db:"level"`
+	Name        string         `db:"name"`
+	LevelId     int            `db:"level_id"`
+}
+
+// registerChangelogEndpoint godoc
+//
+//	@Summary		List changelog
+//	@Description	Paged changelog of the list, newest first. Every entry is either a single placement, move or removal or a list update that contains multiple changes.
+//	@Description	Levels that moved because of a change are not listed separately, they are only counted in shifted.
+//	@Description	Removed levels stay part of the changelog.
+//	@Tags			aredl
+//	@Param			page		query	int		false	"select page"					default(1)		minimum(1)
+//	@Param			per_page	query	int		false	"number of results per page"	default(20)		minimum(1)	maximum(100)
+//	@Param			format		query	string	false	"response format"				default(json)	Enums(json, atom, rss)
+//	@Schemes		http https
+//	@Produce		json
+//	@Produce		application/atom+xml
+//	@Produce		application/rss+xml
+//	@Success		200	{object}	Changelog
+//	@Failure		400	{object}	util.ErrorResponse
+//	@Router			/aredl/changelog [get]
+func registerChangelogEndpoint(e *echo.Group, app core.App, listData demonlist.ListData) error {
+	_, err := e.AddRoute(echo.Route{
+		Method: http.MethodGet,
+		Path:   "/changelog",
+		Middlewares: []echo.MiddlewareFunc{
+			apis.ActivityLogger(app),
+			middlewares.RateLimit(app, middlewares.RateLimitExpensive),
+			middlewares.LoadParam(middlewares.LoadData{
+				"page":     middlewares.AddDefault(1, middlewares.LoadInt(false, validation.Min(1))),
+				"per_page": middlewares.AddDefault(20, middlewares.LoadInt(false, validation.Min(1), validation.Max(100))),
+				"format":   middlewares.AddDefault("json", middlewares.LoadString(false, validation.In("json", "atom", "rss"))),
+			}),
+		},
+		Handler: func(c echo.Context) error {
+			page := c.Get("page").(int)
+			perPage := c.Get("per_page").(int)
+			err := app.Dao().RunInTransaction(func(txDao *daos.Dao) error {
+				result, err := loadChangelog(txDao, listData, page, perPage)
+				if err != nil {
+					return err
+				}
+				c.Response().Header().Set("Cache-Control", "public, max-age=1800")
+				format := c.Get("format").(string)
+				if format == "json" {
+					return c.JSON(http.StatusOK, result)
+				}
+				feed := util.Feed{
+					Title:   fmt.Sprintf("%s changelog", strings.ToUpper(listData.Name)),
+					Link:    c.Scheme() + "://" + c.Request().Host + c.Request().URL.Path,
+					Updated: time.Now(),
+				}
+				if len(result.List) > 0 {
+					feed.Updated = result.List[0].Created.Time()
+				}
+				for _, entry := range result.List {
+					content := strings.Join(util.MapSlice(entry.Changes, func(change ChangelogChange) string { return change.Text }), "\n")
+					if entry.ListUpdate != nil && entry.ListUpdate.Changelog != "" {
+						content = entry.ListUpdate.Changelog + "\n\n" + content
+					}
+					feed.Entries = append(feed.Entries, util.FeedEntry{
+						Id:        feed.Link + "#" + entry.Id,
+						Title:     entry.Title,
+						Content:   content,
+						Published: entry.Created.Time(),
+					})
+				}
+				var data []byte
+				var contentType string
+				if format == "atom" {
+					data, err = feed.Atom()
+					contentType = "application/atom+xml; charset=utf-8"
+				} else {
+					data, err = feed.RSS()
+					contentType = "application/rss+xml; charset=utf-8"
+				}
+				if err != nil {
+					return util.NewErrorResponse(err, "Failed to create feed")
+				}
+				return c.Blob(http.StatusOK, contentType, data)
+			})
+			return err
+		},
+	})
+	return err
+}
+
+// loadChangelog loads a page of changelog entries. Changes that are not part of a list update are an entry on their own,
+// their side effects are the history entries with the same cause that were written before the next change of that level
+func loadChangelog(dao *daos.Dao, listData demonlist.ListData, page int, perPage int) (Changelog, error) {
+	result := Changelog{Page: page, List: []ChangelogEntry{}}
+	entriesQuery := fmt.Sprintf(`
+		SELECT id, created, '' AS name, '' AS changelog, FALSE AS is_list_update FROM %s
+		WHERE action IN (%s) AND COALESCE(list_update, '') = ''
+		UNION ALL
+		SELECT id, created, name, changelog, TRUE AS is_list_update FROM %s`,
+		listData.HistoryTableName,
+		changelogActions,
+		listData.ListUpdatesTableName)
+	var entries []struct {
+		Id         string         `db:"id"`
+		Created    types.DateTime `db:"created"`
+		Name       string         `db:"name"`
+		Changelog  string         `db:"changelog"`
+		ListUpdate bool           `db:"is_list_update"`
+	}
+	err := dao.DB().NewQuery(entriesQuery + " ORDER BY created DESC, id DESC LIMIT {:limit} OFFSET {:offset}").
+		Bind(dbx.Params{"limit": perPage, "offset": (page - 1) * perPage}).
+		All(&entries)
+	if err != nil {
+		return result, util.NewErrorResponse(err, "Failed to load changelog")
+	}
+	err = dao.DB().NewQuery(fmt.Sprintf("SELECT (count(*) / %v + 1) FROM (%s)", perPage, entriesQuery)).Row(&result.Pages)
+	if err != nil {
+		return result, util.NewErrorResponse(err, "Failed to calculate page count")
+	}
+
+	for _, entry := range entries {
+		changelogEntry := ChangelogEntry{Id: entry.Id, Created: entry.Created}
+		var rows []changelogRow
+		var scope string
+		params := dbx.Params{}
+		if entry.ListUpdate {
+			changelogEntry.Title = entry.Name
+			changelogEntry.ListUpdate = &struct {
+				Id        string `json:"id"`
+				Name      string `json:"name"`
+				Changelog string `json:"changelog"`
+			}{entry.Id, entry.Name, entry.Changelog}
+			scope = "h.list_update = {:listUpdate}"
+			params["listUpdate"] = entry.Id
+			err = changelogRowQuery(dao, listData, scope+" AND h.cause <> ''", params).All(&rows)
+			if err != nil {
+				return result, util.NewErrorResponse(err, "Failed to load list update changes")
+			}
+			err = dao.DB().NewQuery(fmt.Sprintf("SELECT COUNT(*) FROM %s h WHERE %s AND h.cause = ''", listData.HistoryTableName, scope)).
+				Bind(params).Row(&changelogEntry.Shifted)
+		} else {
+			err = changelogRowQuery(dao, listData, "h.id = {:id}", dbx.Params{"id": entry.Id}).All(&rows)
+			if err != nil || len(rows) == 0 {
+				return result, util.NewErrorResponse(err, "Failed to load change")
+			}
+			// side effects are written after the change itself, the next change of the same level ends them
+			var until string
+			err = dao.DB().NewQuery(fmt.Sprintf(`
+				SELECT COALESCE(MIN(created), '9999') FROM %s
+				WHERE cause = {:cause} AND action IN (%s) AND COALESCE(list_update, '') = '' AND created > {:created}`,
+				listData.HistoryTableName,
+				changelogActions)).Bind(dbx.Params{"cause": rows[0].Cause, "created": rows[0].Created.String()}).Row(&until)
+			if err != nil {
+				return result, util.NewErrorResponse(err, "Failed to load change")
+			}
+			scope = fmt.Sprintf("h.cause = {:cause} AND h.action IN (%s) AND COALESCE(h.list_update, '') = '' AND h.created >= {:from} AND h.created < {:until}", changelogSideEffects)
+			params["cause"] = rows[0].Cause
+			params["from"] = rows[0].Created.String()
+			params["until"] = until
+			err = dao.DB().NewQuery(fmt.Sprintf("SELECT COUNT(*) FROM %s h WHERE %s", listData.HistoryTableName, scope)).
+				Bind(params).Row(&changelogEntry.Shifted)
+		}
+		if err != nil {
+			return result, util.NewErrorResponse(err, "Failed to count moved levels")
+		}
+
+		for _, row := range rows {
+			change := ChangelogChange{
+				Action:      row.Action,
+				Level:       ChangelogLevel{Id: row.Level, Name: row.Name, LevelId: row.LevelId},
+				NewPosition: row.NewPosition,
+			}
+			if row.Action != "placed" {
+				change.OldPosition = row.OldPosition
+			}
+			switch row.Action {
+			case "placed", "movedUp", "movedFromLegacy":
+				change.Above, err = changelogLevelAt(dao, listData, scope, params, row.NewPosition+1)
+			case "movedDown":
+				change.Below, err = changelogLevelAt(dao, listData, scope, params, row.NewPosition-1)
+			}
+			if err != nil {
+				return result, err
+			}
+			change.Text = changelogText(change)
+			changelogEntry.Changes = append(changelogEntry.Changes, change)
+		}
+		if !entry.ListUpdate {
+			changelogEntry.Title = changelogEntry.Changes[0].Text
+		}
+		result.List = append(result.List, changelogEntry)
+	}
+	return result, nil
+}
+
+// changelogRowQuery selects the history entries matching the condition together with their level.
+// The level is looked up in the removed levels if it is not part of the list anymore
+func changelogRowQuery(dao *daos.Dao, listData demonlist.ListData, condition string, params dbx.Params) *dbx.Query {
+	return dao.DB().NewQuery(fmt.Sprintf(`
+		SELECT h.id, h.action, h.new_position, h.created, h.cause, h.level,
+			COALESCE(l.name, r.name, '') AS name,
+			COALESCE(l.level_id, r.level_id, 0) AS level_id,
+			COALESCE((SELECT p.new_position FROM %[1]s p WHERE p.level = h.level AND p.created < h.created ORDER BY p.created DESC LIMIT 1), 0) AS old_position
+		FROM %[1]s h
+		LEFT JOIN %[2]s l ON l.id = h.level
+		LEFT JOIN %[3]s r ON r.id = h.level
+		WHERE %[4]s
+		ORDER BY h.new_position`,
+		listData.HistoryTableName,
+		listData.LevelTableName,
+		listData.RemovedLevelsTableName,
+		condition)).Bind(params)
+}
+
+// changelogLevelAt returns the level of the history entries in scope that ended up at the given position or nil if there is none
+func changelogLevelAt(dao *daos.Dao, listData demonlist.ListData, scope string, params dbx.Params, position int) (*ChangelogLevel, error) {
+	positionParams := dbx.Params{"position": position}
+	for key, value := range params {
+		positionParams[key] = value
+	}
+	var row changelogRow
+	err := changelogRowQuery(dao, listData, scope+" AND h.new_position = {:position}", positionParams).One(&row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, util.NewErrorResponse(err, "Failed to load surrounding level")
+	}
+	return &ChangelogLevel{Id: row.Level, Name: row.Name, LevelId: row.LevelId}, nil
+}
+
+func changelogText(change ChangelogChange) string {
+	var text string
+	name := change.Level.Name
+	switch change.Action {
+	case "placed":
+		text = fmt.Sprintf("%s placed at #%d", name, change.NewPosition)
+	case "movedUp", "movedDown":
+		direction := util.If(change.Action == "movedUp", "up", "down")
+		if change.OldPosition > 0 {
+			text = fmt.Sprintf("%s moved %s from #%d to #%d", name, direction, change.OldPosition, change.NewPosition)
+		} else {
+			text = fmt.Sprintf("%s moved %s to #%d", name, direction, change.NewPosition)
+		}
+	case "movedToLegacy":
+		text = fmt.Sprintf("%s moved to legacy at #%d", name, change.NewPosition)
+	case "movedFromLegacy":
+		text = fmt.Sprintf("%s moved from legacy to #%d", name, change.NewPosition)
+	case "removed":
+		text = fmt.Sprintf("%s removed from the list at #%d", name, change.NewPosition)
+	}
+	if change.Above != nil {
+		text += " above " + change.Above.Name
+	}
+	if change.Below != nil {
+		text += " below " + change.Below.Name
+	}
+	return text
+}
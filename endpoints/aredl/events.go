@@ -0,0 +1,87 @@
+package aredl
+
+import (
+	"AREDL/demonlist"
+	"AREDL/middlewares"
+	"AREDL/stream"
+	"encoding/json"
+	"fmt"
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"net/http"
+	"time"
+)
+
+const eventStreamHeartbeat = 30 * time.Second
+
+// registerEventStreamEndpoint godoc
+//
+//	@Summary		Live event stream
+//	@Description	Server-Sent Events stream of list changes. Events are only sent once the change has been saved.
+//	@Description	history: a new position history entry, data is the history entry.
+//	@Description	record: a record has been accepted or updated, data is the record.
+//	@Description	queue: the number of pending submissions changed, data is {"pending": count}.
+//	@Description	A comment is sent every 30 seconds to keep the connection alive. Queue events are sent regardless of the filters.
+//	@Tags			aredl
+//	@Param			level_id	query	string	false	"only send events of the level with the given internal id"
+//	@Param			user_id		query	string	false	"only send records of the user with the given internal id"
+//	@Schemes		http https
+//	@Produce		text/event-stream
+//	@Success		200
+//	@Failure		400	{object}	util.ErrorResponse
+//	@Router			/aredl/events [get]
+func registerEventStreamEndpoint(e *echo.Group, app core.App, listData demonlist.ListData) error {
+	_, err := e.AddRoute(echo.Route{
+		Method: http.MethodGet,
+		Path:   "/events",
+		Middlewares: []echo.MiddlewareFunc{
+			apis.ActivityLogger(app),
+			middlewares.RateLimit(app, middlewares.RateLimitDefault),
+			middlewares.LoadParam(middlewares.LoadData{
+				"level_id": middlewares.AddDefault("", middlewares.LoadString(false)),
+				"user_id":  middlewares.AddDefault("", middlewares.LoadString(false)),
+			}),
+		},
+		Handler: func(c echo.Context) error {
+			events, unsubscribe := stream.Subscribe(stream.Filter{
+				List:    listData.Name,
+				LevelId: c.Get("level_id").(string),
+				UserId:  c.Get("user_id").(string),
+			})
+			defer unsubscribe()
+
+			c.Response().Header().Set("Content-Type", "text/event-stream")
+			c.Response().Header().Set("Cache-Control", "no-store")
+			c.Response().Header().Set("Connection", "keep-alive")
+			c.Response().WriteHeader(http.StatusOK)
+			c.Response().Flush()
+
+			heartbeat := time.NewTicker(eventStreamHeartbeat)
+			defer heartbeat.Stop()
+			for {
+				select {
+				case <-c.Request().Context().Done():
+					return nil
+				case <-heartbeat.C:
+					_, err := fmt.Fprint(c.Response(), ": heartbeat\n\n")
+					if err != nil {
+						return nil
+					}
+				case event := <-events:
+					data, err := json.Marshal(event.Data)
+					if err != nil {
+						app.Logger().Error("Failed to encode stream event", "event", event.Name, "error", err)
+						continue
+					}
+					_, err = fmt.Fprintf(c.Response(), "event: %s\ndata: %s\n\n", event.Name, data)
+					if err != nil {
+						return nil
+					}
+				}
+				c.Response().Flush()
+			}
+		},
+	})
+	return err
+}
@@ -14,60 +14,91 @@ import (
 	"github.com/pocketbase/pocketbase/apis"
 	"github.com/pocketbase/pocketbase/core"
 	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/tools/types"
 )
 
+type LeaderboardUser struct {
+	Id         string `db:"id" json:"id,omitempty"`
+	GlobalName string `db:"global_name" json:"global_name,omitempty"`
+	Country    string `db:"country" json:"country,omitempty"`
+}
+
+type LeaderboardEntry struct {
+	Rank int `db:"rank" json:"rank,omitempty"`
+	// CountryRank is the rank within the country of the player, it is empty for players without a country
+	CountryRank int             `db:"country_rank" json:"country_rank,omitempty"`
+	Points      float64         `db:"points" json:"points,omitempty"`
+	User        LeaderboardUser `db:"user" json:"user,omitempty" extend:"user,users,id"`
+}
+
 type Leaderboard struct {
-	List []struct {
-		Rank   int     `db:"rank" json:"rank,omitempty"`
-		Points float64 `db:"points" json:"points,omitempty"`
-		User   struct {
-			Id         string `db:"id" json:"id,omitempty"`
-			GlobalName string `db:"global_name" json:"global_name,omitempty"`
-			Country    string `db:"country" json:"country,omitempty"`
-		} `db:"user" json:"user,omitempty" extend:"user,users,id"`
-	} `json:"list"`
-	Page  int `json:"page"`
-	Pages int `json:"pages"`
+	List  []LeaderboardEntry `json:"list"`
+	Page  int                `json:"page"`
+	Pages int                `json:"pages"`
+	// SnapshotAt is the time of the snapshot the leaderboard was loaded from if a past leaderboard was requested
+	SnapshotAt *types.DateTime `json:"snapshot_at,omitempty"`
 }
 
 // registerLeaderboardEndpoint godoc
 //
 //	@Summary		Aredl leaderboard
 //	@Description	Gives leaderboard as a paged list ordered by rank. Players with zero list points are omitted
+//	@Description	Past leaderboards are loaded from the daily snapshots, names and countries are the current ones.
+//	@Description	With country only players of that country are listed, ordered by their country rank.
 //	@Tags			aredl
 //	@Param			page		query	int		false	"select page"	default(1)	minimum(1)
 //	@Param			user_id		query	string	false	"get the page the given user is on instead of the given page, does not work with name filter active"
 //	@Param			per_page	query	int		false	"number of results per page"	default(40)	minimum(1) maximum(200)
 //	@Param			name_filter	query	string	false	"filters names to only contain the given substring"
+//	@Param			country		query	string	false	"only players of the given country, ISO 3166-1 alpha-2 code"
+//	@Param			at			query	string	false	"load the leaderboard of the latest snapshot at or before the given time, format: 2006-01-02 or 2006-01-02 15:04:05"
 //	@Schemes		http https
 //	@Produce		json
 //	@Success		200	{object}	Leaderboard
 //	@Failure		400	{object}	util.ErrorResponse
 //	@Router			/aredl/leaderboard [get]
-func registerLeaderboardEndpoint(e *echo.Group, app core.App) error {
+func registerLeaderboardEndpoint(e *echo.Group, app core.App, listData demonlist.ListData) error {
 	_, err := e.AddRoute(echo.Route{
 		Method: http.MethodGet,
 		Path:   "/leaderboard",
 		Middlewares: []echo.MiddlewareFunc{
 			apis.ActivityLogger(app),
+			middlewares.RateLimit(app, middlewares.RateLimitExpensive),
 			middlewares.LoadParam(middlewares.LoadData{
 				"page":        middlewares.AddDefault(1, middlewares.LoadInt(false, validation.Min(1))),
 				"user_id":     middlewares.LoadString(false),
 				"per_page":    middlewares.AddDefault(40, middlewares.LoadInt(false, validation.Min(1), validation.Max(200))),
 				"name_filter": middlewares.LoadString(false),
+				"country":     middlewares.LoadString(false, validation.Length(2, 2)),
+				"at":          middlewares.LoadString(false),
 			}),
 		},
 		Handler: func(c echo.Context) error {
 			page := c.Get("page").(int)
 			perPage := c.Get("per_page").(int)
-			aredl := demonlist.Aredl()
 			err := app.Dao().RunInTransaction(func(txDao *daos.Dao) error {
-				if c.Get("user_id") != nil {
-					if c.Get("name_filter") != nil {
-						return util.NewErrorResponse(nil, "Cannot use name_filter with user_id")
+				if c.Get("user_id") != nil && c.Get("name_filter") != nil {
+					return util.NewErrorResponse(nil, "Cannot use name_filter with user_id")
+				}
+				if c.Get("at") != nil {
+					if c.Get("country") != nil {
+						return util.NewErrorResponse(nil, "Cannot use country with at")
+					}
+					at, err := types.ParseDateTime(c.Get("at"))
+					if err != nil || at.IsZero() {
+						return util.NewErrorResponse(err, "Invalid time")
+					}
+					result, err := loadLeaderboardAt(txDao, listData, at, page, perPage, c.Get("user_id"), c.Get("name_filter"))
+					if err != nil {
+						return err
 					}
+					c.Response().Header().Set("Cache-Control", "public, max-age=1800")
+					return c.JSON(http.StatusOK, result)
+				}
+				rankColumn := util.If(c.Get("country") != nil, "country_rank", "rank")
+				if c.Get("user_id") != nil {
 					userId := c.Get("user_id").(string)
-					err := txDao.DB().Select(fmt.Sprintf("((rank - 1) / %v) + 1 AS rank", perPage)).From(aredl.LeaderboardTableName).Where(dbx.HashExp{"user": userId}).Row(&page)
+					err := txDao.DB().Select(fmt.Sprintf("((%s - 1) / %v) + 1 AS rank", rankColumn, perPage)).From(listData.LeaderboardTableName).Where(dbx.HashExp{"user": userId}).Row(&page)
 					if util.IsNotNoResultError(err) {
 						return util.NewErrorResponse(err, "Failed to request user page")
 					}
@@ -75,25 +106,31 @@ func registerLeaderboardEndpoint(e *echo.Group, app core.App) error {
 				var result Leaderboard
 				result.Page = page
 				tableNames := map[string]string{
-					"base":  aredl.LeaderboardTableName,
+					"base":  listData.LeaderboardTableName,
 					"users": names.TableUsers,
 				}
 				err := util.LoadFromDb(txDao.DB(), &result.List, tableNames, func(query *dbx.SelectQuery, prefixResolver util.PrefixResolver) {
 					if c.Get("name_filter") != nil {
 						query.Where(dbx.Like(prefixResolver("user.global_name"), c.Get("name_filter").(string)))
 					}
-					query.Offset(int64((page - 1) * perPage)).Limit(int64(perPage)).OrderBy(prefixResolver("rank"))
+					if c.Get("country") != nil {
+						query.AndWhere(dbx.HashExp{prefixResolver("country"): c.Get("country")})
+					}
+					query.Offset(int64((page - 1) * perPage)).Limit(int64(perPage)).OrderBy(prefixResolver(rankColumn))
 				})
 				if err != nil {
 					return util.NewErrorResponse(err, "Failed to load demonlist data")
 				}
 				query := txDao.DB().
 					Select(fmt.Sprintf("(count(*) / %v + 1)", perPage)).
-					From(fmt.Sprintf("%v %v", aredl.LeaderboardTableName, "lb"))
+					From(fmt.Sprintf("%v %v", listData.LeaderboardTableName, "lb"))
 				if c.Get("name_filter") != nil {
 					query.InnerJoin(fmt.Sprintf("%v %v", names.TableUsers, "user"), dbx.NewExp("lb.user = user.id")).
 						Where(dbx.Like("user.global_name", c.Get("name_filter").(string)))
 				}
+				if c.Get("country") != nil {
+					query.AndWhere(dbx.HashExp{"lb.country": c.Get("country")})
+				}
 				err = query.Row(&result.Pages)
 				if err != nil {
 					return util.NewErrorResponse(err, "Failed to calculate page count")
@@ -108,3 +145,65 @@ func registerLeaderboardEndpoint(e *echo.Group, app core.App) error {
 	})
 	return err
 }
+
+// loadLeaderboardAt loads a page of the leaderboard snapshot at the given time. The optional user id selects the page of that user
+// and the optional name filter only keeps players whose current name contains it
+func loadLeaderboardAt(dao *daos.Dao, listData demonlist.ListData, at types.DateTime, page int, perPage int, userId any, nameFilter any) (Leaderboard, error) {
+	result := Leaderboard{Page: page, List: []LeaderboardEntry{}}
+	entries, snapshotAt, err := demonlist.LeaderboardAt(dao, listData, at)
+	if err != nil {
+		return result, err
+	}
+	result.SnapshotAt = &snapshotAt
+	usersQuery := dao.DB().Select("id", "global_name", "country").From(names.TableUsers)
+	if nameFilter != nil {
+		var users []LeaderboardUser
+		err = usersQuery.Where(dbx.Like("global_name", nameFilter.(string))).All(&users)
+		if err != nil {
+			return result, util.NewErrorResponse(err, "Failed to load users")
+		}
+		matching := make(map[string]bool, len(users))
+		for _, user := range users {
+			matching[user.Id] = true
+		}
+		filtered := make([]demonlist.SnapshotLeaderboardEntry, 0)
+		for _, entry := range entries {
+			if matching[entry.User] {
+				filtered = append(filtered, entry)
+			}
+		}
+		entries = filtered
+	}
+	if userId != nil {
+		for _, entry := range entries {
+			if entry.User == userId.(string) {
+				result.Page = (entry.Rank-1)/perPage + 1
+				break
+			}
+		}
+	}
+	result.Pages = len(entries)/perPage + 1
+	start := min((result.Page-1)*perPage, len(entries))
+	entries = entries[start:min(start+perPage, len(entries))]
+	if len(entries) == 0 {
+		return result, nil
+	}
+
+	var users []LeaderboardUser
+	err = usersQuery.Where(dbx.In("id", util.MapSlice(entries, func(entry demonlist.SnapshotLeaderboardEntry) any { return entry.User })...)).All(&users)
+	if err != nil {
+		return result, util.NewErrorResponse(err, "Failed to load users")
+	}
+	usersById := make(map[string]LeaderboardUser, len(users))
+	for _, user := range users {
+		usersById[user.Id] = user
+	}
+	result.List = util.MapSlice(entries, func(entry demonlist.SnapshotLeaderboardEntry) LeaderboardEntry {
+		user, ok := usersById[entry.User]
+		if !ok {
+			user.Id = entry.User
+		}
+		return LeaderboardEntry{Rank: entry.Rank, Points: entry.Points, User: user}
+	})
+	return result, nil
+}
@@ -0,0 +1,52 @@
+package aredl
+
+import (
+	"AREDL/demonlist"
+	"AREDL/middlewares"
+	"AREDL/util"
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"net/http"
+)
+
+type CountryLeaderboardEntry struct {
+	Rank    int     `db:"rank" json:"rank"`
+	Country string  `db:"country" json:"country"`
+	Points  float64 `db:"points" json:"points"`
+	Players int     `db:"players" json:"players"`
+}
+
+// registerCountryLeaderboardEndpoint godoc
+//
+//	@Summary		Country leaderboard
+//	@Description	Gives every country ordered by the total points of its players. Players without a country are not counted
+//	@Tags			aredl
+//	@Schemes		http https
+//	@Produce		json
+//	@Success		200	{object}	[]CountryLeaderboardEntry
+//	@Failure		400	{object}	util.ErrorResponse
+//	@Router			/aredl/leaderboard/countries [get]
+func registerCountryLeaderboardEndpoint(e *echo.Group, app core.App, listData demonlist.ListData) error {
+	_, err := e.AddRoute(echo.Route{
+		Method: http.MethodGet,
+		Path:   "/leaderboard/countries",
+		Middlewares: []echo.MiddlewareFunc{
+			apis.ActivityLogger(app),
+			middlewares.RateLimit(app, middlewares.RateLimitExpensive),
+		},
+		Handler: func(c echo.Context) error {
+			result := []CountryLeaderboardEntry{}
+			err := app.Dao().DB().Select("rank", "country", "points", "players").
+				From(listData.CountryLeaderboardTableName).
+				OrderBy("rank", "country").
+				All(&result)
+			if err != nil {
+				return util.NewErrorResponse(err, "Failed to load country leaderboard")
+			}
+			c.Response().Header().Set("Cache-Control", "public, max-age=1800")
+			return c.JSON(http.StatusOK, result)
+		},
+	})
+	return err
+}
@@ -2,44 +2,55 @@ package aredl
 
 import (
 	"AREDL/demonlist"
+	"AREDL/middlewares"
 	"AREDL/util"
+	"fmt"
 	"github.com/labstack/echo/v5"
 	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase/apis"
 	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/tools/types"
 	"net/http"
 )
 
 type ListEntry struct {
-	Id            string  `db:"id" json:"id,omitempty"`
-	Position      int     `db:"position" json:"position,omitempty"`
-	Name          string  `db:"name" json:"name,omitempty"`
-	Points        float64 `db:"points" json:"points,omitempty"`
-	LevelId       int     `db:"level_id" json:"level_id,omitempty"`
-	TwoPlayer     bool    `db:"two_player" json:"two_player"`
-	Legacy        bool    `db:"legacy" json:"legacy,omitempty"`
-	Enjoyment     float64 `db:"enjoyment" json:"enjoyment,omitempty"`
-	IsEdelPending bool    `db:"is_edel_pending" json:"is_edel_pending,omitempty"`
+	Id               string  `db:"id" json:"id,omitempty"`
+	Position         int     `db:"position" json:"position,omitempty"`
+	Name             string  `db:"name" json:"name,omitempty"`
+	Points           float64 `db:"points" json:"points,omitempty"`
+	LevelId          int     `db:"level_id" json:"level_id,omitempty"`
+	TwoPlayer        bool    `db:"two_player" json:"two_player"`
+	Legacy           bool    `db:"legacy" json:"legacy,omitempty"`
+	PercentToQualify int     `db:"percent_to_qualify" json:"percent_to_qualify,omitempty"`
+	Enjoyment        float64 `db:"enjoyment" json:"enjoyment,omitempty"`
+	IsEdelPending    bool    `db:"is_edel_pending" json:"is_edel_pending,omitempty"`
 }
 
 // registerLevelsEndpoint godoc
 //
 //	@Summary		Full simple list
 //	@Description	Gives a list of every placed level ordered by position. To get more details on a level use /aredl/levels/:id
+//	@Description	With at the list is reconstructed from the position history as it was at the given time. Points are calculated with the point formula of that time, level details are the current ones.
 //	@Tags			aredl
+//	@Param			at	query	string	false	"reconstruct the list at the given time, format: 2006-01-02 or 2006-01-02 15:04:05"
 //	@Schemes		http https
 //	@Produce		json
 //	@Success		200	{object}	[]ListEntry
 //	@Failure		400	{object}	util.ErrorResponse
 //	@Router			/aredl/levels [get]
-func registerLevelsEndpoint(e *echo.Group, app core.App) error {
+func registerLevelsEndpoint(e *echo.Group, app core.App, listData demonlist.ListData) error {
 	_, err := e.AddRoute(echo.Route{
 		Method: http.MethodGet,
 		Path:   "/levels",
 		Middlewares: []echo.MiddlewareFunc{
 			apis.ActivityLogger(app),
+			middlewares.RateLimit(app, middlewares.RateLimitDefault),
+			middlewares.LoadParam(middlewares.LoadData{
+				"at": middlewares.LoadString(false),
+			}),
 		},
-		Handler: levelsHandler(app),
+		Handler: levelsHandler(app, listData),
 	})
 	return err
 }
@@ -54,24 +65,36 @@ func registerLevelsEndpoint(e *echo.Group, app core.App) error {
 //	@Success		200	{object}	[]ListEntry
 //	@Failure		400	{object}	util.ErrorResponse
 //	@Router			/aredl/list [get]
-func registerListEndpoint(e *echo.Group, app core.App) error {
+func registerListEndpoint(e *echo.Group, app core.App, listData demonlist.ListData) error {
 	_, err := e.AddRoute(echo.Route{
 		Method: http.MethodGet,
 		Path:   "/list",
 		Middlewares: []echo.MiddlewareFunc{
 			apis.ActivityLogger(app),
+			middlewares.RateLimit(app, middlewares.RateLimitDefault),
 		},
-		Handler: levelsHandler(app),
+		Handler: levelsHandler(app, listData),
 	})
 	return err
 }
 
-func levelsHandler(app core.App) echo.HandlerFunc {
+func levelsHandler(app core.App, listData demonlist.ListData) echo.HandlerFunc {
 	return func(c echo.Context) error {
-		aredl := demonlist.Aredl()
 		var list []ListEntry
+		if c.Get("at") != nil {
+			at, err := types.ParseDateTime(c.Get("at"))
+			if err != nil || at.IsZero() {
+				return util.NewErrorResponse(err, "Invalid time")
+			}
+			list, err = loadLevelsAt(app.Dao(), listData, at)
+			if err != nil {
+				return err
+			}
+			c.Response().Header().Set("Cache-Control", "public, max-age=1800")
+			return c.JSON(http.StatusOK, list)
+		}
 		tableNames := map[string]string{
-			"base": aredl.LevelTableName,
+			"base": listData.LevelTableName,
 		}
 		err := util.LoadFromDb(app.Dao().DB(), &list, tableNames, func(query *dbx.SelectQuery, prefixResolver util.PrefixResolver) {
 			query.OrderBy(prefixResolver("position"))
@@ -86,3 +109,39 @@ func levelsHandler(app core.App) echo.HandlerFunc {
 		return c.JSON(http.StatusOK, list)
 	}
 }
+
+// loadLevelsAt reconstructs the list at the given time and adds the current details of every level.
+// Details of removed levels are taken from the archive
+func loadLevelsAt(dao *daos.Dao, listData demonlist.ListData, at types.DateTime) ([]ListEntry, error) {
+	levels, err := demonlist.LevelsAt(dao, listData, at)
+	if err != nil {
+		return nil, err
+	}
+	var details []ListEntry
+	err = dao.DB().NewQuery(fmt.Sprintf(`
+		SELECT id, name, level_id, two_player, percent_to_qualify, COALESCE(enjoyment, 0) AS enjoyment, is_edel_pending FROM %s
+		UNION ALL
+		SELECT id, name, level_id,
+			COALESCE(json_extract(level, '$.two_player'), FALSE) AS two_player,
+			COALESCE(json_extract(level, '$.percent_to_qualify'), 0) AS percent_to_qualify,
+			COALESCE(json_extract(level, '$.enjoyment'), 0) AS enjoyment,
+			FALSE AS is_edel_pending
+		FROM %s`,
+		listData.LevelTableName,
+		listData.RemovedLevelsTableName)).All(&details)
+	if err != nil {
+		return nil, util.NewErrorResponse(err, "Failed to load level details")
+	}
+	detailsById := make(map[string]ListEntry, len(details))
+	for _, level := range details {
+		detailsById[level.Id] = level
+	}
+	return util.MapSlice(levels, func(level demonlist.SnapshotLevel) ListEntry {
+		entry := detailsById[level.Id]
+		entry.Id = level.Id
+		entry.Position = level.Position
+		entry.Legacy = level.Legacy
+		entry.Points = level.Points
+		return entry
+	}), nil
+}
@@ -0,0 +1,80 @@
+package aredl
+
+import (
+	"AREDL/audit"
+	"AREDL/demonlist"
+	"AREDL/middlewares"
+	"AREDL/util"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/models"
+	"net/http"
+)
+
+// registerLevelDeleteEndpoint godoc
+//
+//	@Summary		Remove AREDL level
+//	@Description	Removes a level from the list, for example if it turns out to be hacked. All levels below it move up by one position.
+//	@Description	It automatically updates history, points, packs and leaderboards. The level is kept in the removed levels archive.
+//	@Description	Requires user permission: aredl.manage_levels
+//	@Security		ApiKeyAuth
+//	@Tags			aredl
+//	@Param			id				path	string	true	"internal level id"
+//	@Param			reason			query	string	true	"reason the level is removed for"
+//	@Param			archive_records	query	bool	false	"whether the records of the level are archived instead of deleted"	default(true)
+//	@Schemes		http https
+//	@Produce		json
+//	@Success		200
+//	@Failure		400	{object}	util.ErrorResponse
+//	@Failure		403	{object}	util.ErrorResponse
+//	@Router			/aredl/levels/{id} [delete]
+func registerLevelDeleteEndpoint(e *echo.Group, app core.App, listData demonlist.ListData) error {
+	_, err := e.AddRoute(echo.Route{
+		Method: http.MethodDelete,
+		Path:   "/levels/:id",
+		Middlewares: []echo.MiddlewareFunc{
+			apis.ActivityLogger(app),
+			middlewares.RateLimit(app, middlewares.RateLimitDefault),
+			middlewares.CheckBanned(),
+			middlewares.RequirePermissionGroup(app, listData.Name, "manage_levels"),
+			middlewares.LoadParam(middlewares.LoadData{
+				"id":              middlewares.LoadString(true),
+				"reason":          middlewares.LoadString(true, validation.Length(1, 1000)),
+				"archive_records": middlewares.AddDefault(true, middlewares.LoadBool(false)),
+			}),
+		},
+		Handler: func(c echo.Context) error {
+			userRecord, _ := c.Get(apis.ContextAuthRecordKey).(*models.Record)
+			if userRecord == nil {
+				return util.NewErrorResponse(nil, "User not found")
+			}
+			err := app.Dao().RunInTransaction(func(txDao *daos.Dao) error {
+				levelRecord, err := txDao.FindRecordById(listData.LevelTableName, c.Get("id").(string))
+				if err != nil {
+					return util.NewErrorResponse(err, "Level not found")
+				}
+				err = demonlist.RemoveLevel(txDao, app, listData, levelRecord.Id, userRecord.Id, c.Get("reason").(string), c.Get("archive_records").(bool))
+				if err != nil {
+					return err
+				}
+				return audit.Log(txDao, c, audit.Entry{
+					List:        listData.Name,
+					Action:      "level_removed",
+					TargetTable: listData.LevelTableName,
+					TargetId:    levelRecord.Id,
+					Before:      audit.Snapshot(levelRecord),
+					After: map[string]any{
+						"reason":          c.Get("reason").(string),
+						"archive_records": c.Get("archive_records").(bool),
+					},
+				})
+			})
+			c.Response().Header().Set("Cache-Control", "no-store")
+			return err
+		},
+	})
+	return err
+}
@@ -5,6 +5,7 @@ import (
 	"AREDL/middlewares"
 	"AREDL/names"
 	"AREDL/util"
+	"fmt"
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/labstack/echo/v5"
 	"github.com/pocketbase/dbx"
@@ -16,15 +17,20 @@ import (
 )
 
 type HistoryEntry struct {
-	Level       struct{}       `db:"level" json:"-" extend:"level,levels,id"`
-	Action      string         `db:"action" json:"action,omitempty" enums:"placed,placedAbove,movedUp,movedDown,movedPastUp,movedPastDown,movedToLegacy,movedFromLegacy"`
+	Action      string         `db:"action" json:"action,omitempty" enums:"placed,placedAbove,movedUp,movedDown,movedPastUp,movedPastDown,movedToLegacy,movedFromLegacy,removed"`
 	NewPosition int            `db:"new_position" json:"new_position,omitempty"`
 	Created     types.DateTime `db:"created" json:"timestamp,omitempty"`
-	Cause       struct {
+	// Cause is empty if the level that caused the entry has been removed from the list
+	Cause *struct {
 		Id      string `db:"id" json:"id,omitempty"`
 		Name    string `db:"name" json:"name,omitempty"`
 		LevelId int    `db:"level_id" json:"level_id,omitempty"`
 	} `db:"cause" json:"cause,omitempty" extend:"cause,levels,id"`
+	// ListUpdate is set if the entry was part of a list update
+	ListUpdate *struct {
+		Id   string `db:"id" json:"id,omitempty"`
+		Name string `db:"name" json:"name,omitempty"`
+	} `db:"list_update" json:"list_update,omitempty" extend:"list_update,list_updates,id"`
 	//ActionBy *struct {
 	//	Id         *string `db:"id" json:"id,omitempty"`
 	//	GlobalName *string `db:"global_name" json:"global_name,omitempty"`
@@ -34,7 +40,8 @@ type HistoryEntry struct {
 // registerLevelHistoryEndpoint godoc
 //
 //	@Summary		History of a level
-//	@Description	Lists the placement, move & legacy history of a level by either using its internal or gd id. Possible actions: placed, placedAbove, movedUp, movedDown, movedPastUp, movedPastDown, movedToLegacy, movedFromLegacy
+//	@Description	Lists the placement, move & legacy history of a level by either using its internal or gd id. Possible actions: placed, placedAbove, movedUp, movedDown, movedPastUp, movedPastDown, movedToLegacy, movedFromLegacy, removed
+//	@Description	The history of levels that have been removed from the list stays available.
 //	@Tags			aredl
 //	@Param			id			path	string	true	"internal level id or gd level id"
 //	@Param			level_id	query	int		false	"gd level id"	minimum(1)
@@ -43,38 +50,57 @@ type HistoryEntry struct {
 //	@Success		200	{object}	[]HistoryEntry
 //	@Failure		400	{object}	util.ErrorResponse
 //	@Router			/aredl/levels/{id}/history [get]
-func registerLevelHistoryEndpoint(e *echo.Group, app core.App) error {
+func registerLevelHistoryEndpoint(e *echo.Group, app core.App, listData demonlist.ListData) error {
 	_, err := e.AddRoute(echo.Route{
 		Method: http.MethodGet,
 		Path:   "/levels/:id/history",
 		Middlewares: []echo.MiddlewareFunc{
 			apis.ActivityLogger(app),
+			middlewares.RateLimit(app, middlewares.RateLimitDefault),
 			middlewares.LoadParam(middlewares.LoadData{
 				"id":       middlewares.LoadString(false),
 				"level_id": middlewares.LoadInt(false, validation.Min(1)),
 			}),
 		},
 		Handler: func(c echo.Context) error {
-			aredl := demonlist.Aredl()
 			id := c.Get("id").(string)
 			err := app.Dao().RunInTransaction(func(txDao *daos.Dao) error {
 				var result []HistoryEntry
 				tables := map[string]string{
-					"base":   aredl.HistoryTableName,
-					"levels": aredl.LevelTableName,
-					"users":  names.TableUsers,
+					"base":         listData.HistoryTableName,
+					"levels":       listData.LevelTableName,
+					"users":        names.TableUsers,
+					"list_updates": listData.ListUpdatesTableName,
 				}
-				err := util.LoadFromDb(txDao.DB(), &result, tables, func(query *dbx.SelectQuery, prefixResolver util.PrefixResolver) {
-					if util.IsGDId(id) {
-						query.Where(dbx.HashExp{prefixResolver("level.level_id"): id})
-					} else {
-						query.Where(dbx.HashExp{prefixResolver("level.id"): id})
+				if util.IsGDId(id) {
+					// removed levels are looked up in the archive
+					var levelIds []string
+					err := txDao.DB().NewQuery(fmt.Sprintf(`
+						SELECT id FROM %s WHERE level_id = {:levelId}
+						UNION ALL
+						SELECT id FROM %s WHERE level_id = {:levelId}`,
+						listData.LevelTableName,
+						listData.RemovedLevelsTableName)).Bind(dbx.Params{"levelId": id}).Column(&levelIds)
+					if err != nil || len(levelIds) == 0 {
+						return util.NewErrorResponse(err, "Level not found")
 					}
+					id = levelIds[0]
+				}
+				err := util.LoadFromDb(txDao.DB(), &result, tables, func(query *dbx.SelectQuery, prefixResolver util.PrefixResolver) {
+					query.Where(dbx.HashExp{prefixResolver("level"): id})
 					query.OrderBy(prefixResolver("created"))
 				})
 				if err != nil {
 					return util.NewErrorResponse(err, "Failed to load demonlist data")
 				}
+				for i := range result {
+					if result[i].Cause.Id == "" {
+						result[i].Cause = nil
+					}
+					if result[i].ListUpdate.Id == "" {
+						result[i].ListUpdate = nil
+					}
+				}
 
 				c.Response().Header().Set("Cache-Control", "public, max-age=3600")
 				return c.JSON(http.StatusOK, result)
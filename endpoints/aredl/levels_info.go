@@ -23,6 +23,7 @@ type LevelRecord struct {
 	Id          string    `db:"id" json:"id,omitempty"`
 	VideoUrl    string    `db:"video_url" json:"video_url,omitempty"`
 	Mobile      bool      `db:"mobile" json:"mobile,omitempty"`
+	Percentage  int       `db:"percentage" json:"percentage,omitempty"`
 	SubmittedBy LevelUser `db:"submitted_by" json:"submitted_by,omitempty" extend:"submitted_by,users,id"`
 }
 
@@ -34,21 +35,22 @@ type LevelPack struct {
 }
 
 type Level struct {
-	Id            string         `db:"id" json:"id,omitempty"`
-	Position      int            `db:"position" json:"position,omitempty"`
-	Name          string         `db:"name" json:"name,omitempty"`
-	Points        float64        `db:"points" json:"points,omitempty"`
-	Legacy        bool           `db:"legacy" json:"legacy,omitempty"`
-	LevelId       int            `db:"level_id" json:"level_id,omitempty"`
-	LevelPassword string         `db:"level_password" json:"level_password,omitempty"`
-	Enjoyment     float64        `db:"enjoyment" json:"enjoyment,omitempty"`
-	IsEdelPending bool           `db:"is_edel_pending" json:"is_edel_pending,omitempty"`
-	CustomSong    string         `db:"custom_song" json:"custom_song,omitempty"`
-	Publisher     LevelUser      `db:"publisher" json:"publisher,omitempty" extend:"publisher,users,id"`
-	Verification  *LevelRecord   `json:"verification,omitempty" extend:"id,records,submitted_by"`
-	Creators      *[]LevelUser   `json:"creators,omitempty"`
-	Records       *[]LevelRecord `json:"records,omitempty"`
-	Packs         *[]LevelPack   `json:"packs,omitempty"`
+	Id               string         `db:"id" json:"id,omitempty"`
+	Position         int            `db:"position" json:"position,omitempty"`
+	Name             string         `db:"name" json:"name,omitempty"`
+	Points           float64        `db:"points" json:"points,omitempty"`
+	Legacy           bool           `db:"legacy" json:"legacy,omitempty"`
+	LevelId          int            `db:"level_id" json:"level_id,omitempty"`
+	LevelPassword    string         `db:"level_password" json:"level_password,omitempty"`
+	Enjoyment        float64        `db:"enjoyment" json:"enjoyment,omitempty"`
+	IsEdelPending    bool           `db:"is_edel_pending" json:"is_edel_pending,omitempty"`
+	CustomSong       string         `db:"custom_song" json:"custom_song,omitempty"`
+	PercentToQualify int            `db:"percent_to_qualify" json:"percent_to_qualify,omitempty"`
+	Publisher        LevelUser      `db:"publisher" json:"publisher,omitempty" extend:"publisher,users,id"`
+	Verification     *LevelRecord   `json:"verification,omitempty" extend:"id,records,submitted_by"`
+	Creators         *[]LevelUser   `json:"creators,omitempty"`
+	Records          *[]LevelRecord `json:"records,omitempty"`
+	Packs            *[]LevelPack   `json:"packs,omitempty"`
 }
 
 // registerLevelEndpoint godoc
@@ -68,12 +70,13 @@ type Level struct {
 //	@Success		200	{object}	Level
 //	@Failure		400	{object}	util.ErrorResponse
 //	@Router			/aredl/levels/{id} [get]
-func registerLevelEndpoint(e *echo.Group, app core.App) error {
+func registerLevelEndpoint(e *echo.Group, app core.App, listData demonlist.ListData) error {
 	_, err := e.AddRoute(echo.Route{
 		Method: http.MethodGet,
 		Path:   "/levels/:id",
 		Middlewares: []echo.MiddlewareFunc{
 			apis.ActivityLogger(app),
+			middlewares.RateLimit(app, middlewares.RateLimitDefault),
 			middlewares.LoadParam(middlewares.LoadData{
 				"id":           middlewares.LoadString(false),
 				"records":      middlewares.AddDefault(false, middlewares.LoadBool(false)),
@@ -84,12 +87,11 @@ func registerLevelEndpoint(e *echo.Group, app core.App) error {
 			}),
 		},
 		Handler: func(c echo.Context) error {
-			aredl := demonlist.Aredl()
 			err := app.Dao().RunInTransaction(func(txDao *daos.Dao) error {
 				var level Level
 				tables := map[string]string{
-					"base":    aredl.LevelTableName,
-					"records": aredl.RecordsTableName,
+					"base":    listData.LevelTableName,
+					"records": listData.RecordsTableName,
 					"users":   names.TableUsers,
 				}
 				id := c.Get("id").(string)
@@ -115,7 +117,7 @@ func registerLevelEndpoint(e *echo.Group, app core.App) error {
 				if c.Get("creators").(bool) {
 					tables["base"] = tables["users"]
 					err = util.LoadFromDb(txDao.DB(), &level.Creators, tables, func(query *dbx.SelectQuery, prefixResolver util.PrefixResolver) {
-						query.InnerJoin(aredl.CreatorTableName+" c", dbx.NewExp(fmt.Sprintf("%v=c.creator", prefixResolver("id"))))
+						query.InnerJoin(listData.CreatorTableName+" c", dbx.NewExp(fmt.Sprintf("%v=c.creator", prefixResolver("id"))))
 						query.Where(dbx.HashExp{"c.level": level.Id})
 					})
 					if err != nil {
@@ -134,11 +136,11 @@ func registerLevelEndpoint(e *echo.Group, app core.App) error {
 					}
 				}
 				if c.Get("packs").(bool) {
-					tables["base"] = aredl.Packs.PackTableName
+					tables["base"] = listData.Packs.PackTableName
 					err = util.LoadFromDb(txDao.DB(), &level.Packs, tables, func(query *dbx.SelectQuery, prefixResolver util.PrefixResolver) {
 						query.Where(dbx.Exists(dbx.NewExp(fmt.Sprintf(
 							`SELECT NULL FROM %v pl WHERE pl.level = {:levelId} AND pl.pack = %v`,
-							demonlist.Aredl().Packs.PackLevelTableName,
+							listData.Packs.PackLevelTableName,
 							prefixResolver("id")), dbx.Params{"levelId": level.Id})))
 						query.OrderBy(prefixResolver("placement_order"))
 					})
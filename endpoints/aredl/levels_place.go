@@ -1,6 +1,7 @@
 package aredl
 
 import (
+	"AREDL/audit"
 	"AREDL/demonlist"
 	"AREDL/middlewares"
 	"AREDL/util"
@@ -9,6 +10,7 @@ import (
 	"github.com/labstack/echo/v5"
 	"github.com/pocketbase/pocketbase/apis"
 	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
 	"github.com/pocketbase/pocketbase/models"
 	"net/http"
 )
@@ -26,7 +28,8 @@ import (
 //	@Param			name						query	string		true	"displayed name of the level"
 //	@Param			publisher					query	string		true	"publisher user id"
 //	@Param			level_password				query	string		false	"gd level password"
-//	@Param			legacy						query	bool		false	"whether the level should be placed as legacy"	default(false)
+//	@Param			legacy						query	bool		false	"whether the level should be placed as legacy"												default(false)
+//	@Param			percent_to_qualify			query	int			false	"lowest percentage a progress record needs, progress records are not accepted if not set"	minimum(1)	maximum(100)
 //	@Param			verification_submitted_by	query	string		true	"user id of the verifier"
 //	@Param			verification_video_url		query	string		true	"video url of the verification"	format(url)
 //	@Param			verification_mobile			query	bool		true	"whether verification was done on mobile"
@@ -37,23 +40,25 @@ import (
 //	@Failure		400	{object}	util.ErrorResponse
 //	@Failure		403	{object}	util.ErrorResponse
 //	@Router			/aredl/levels [post]
-func registerLevelPlaceEndpoint(e *echo.Group, app core.App) error {
+func registerLevelPlaceEndpoint(e *echo.Group, app core.App, listData demonlist.ListData) error {
 	_, err := e.AddRoute(echo.Route{
 		Method: http.MethodPost,
 		Path:   "/levels",
 		Middlewares: []echo.MiddlewareFunc{
 			apis.ActivityLogger(app),
+			middlewares.RateLimit(app, middlewares.RateLimitDefault),
 			middlewares.CheckBanned(),
-			middlewares.RequirePermissionGroup(app, "aredl", "manage_levels"),
+			middlewares.RequirePermissionGroup(app, listData.Name, "manage_levels"),
 			middlewares.LoadParam(middlewares.LoadData{
 				"creator_ids": middlewares.LoadStringArray(true),
 				"levelData": middlewares.LoadMap("", middlewares.LoadData{
-					"level_id":       middlewares.LoadInt(true, validation.Min(1)),
-					"position":       middlewares.LoadInt(true, validation.Min(1)),
-					"name":           middlewares.LoadString(true),
-					"publisher":      middlewares.LoadString(true),
-					"level_password": middlewares.LoadString(false),
-					"legacy":         middlewares.AddDefault(false, middlewares.LoadBool(false)),
+					"level_id":           middlewares.LoadInt(true, validation.Min(1)),
+					"position":           middlewares.LoadInt(true, validation.Min(1)),
+					"name":               middlewares.LoadString(true),
+					"publisher":          middlewares.LoadString(true),
+					"level_password":     middlewares.LoadString(false),
+					"legacy":             middlewares.AddDefault(false, middlewares.LoadBool(false)),
+					"percent_to_qualify": middlewares.LoadInt(false, validation.Min(1), validation.Max(100)),
 				}),
 				"verificationData": middlewares.LoadMap("verification_", middlewares.LoadData{
 					"submitted_by": middlewares.LoadString(true),
@@ -68,7 +73,6 @@ func registerLevelPlaceEndpoint(e *echo.Group, app core.App) error {
 			if userRecord == nil {
 				return util.NewErrorResponse(nil, "User not found")
 			}
-			aredl := demonlist.Aredl()
 
 			levelData := c.Get("levelData").(map[string]interface{})
 
@@ -77,7 +81,19 @@ func registerLevelPlaceEndpoint(e *echo.Group, app core.App) error {
 
 			creatorIds := c.Get("creator_ids").([]string)
 
-			err := demonlist.PlaceLevel(app.Dao(), app, userRecord.Id, aredl, levelData, verificationData, creatorIds)
+			err := app.Dao().RunInTransaction(func(txDao *daos.Dao) error {
+				levelRecord, err := demonlist.PlaceLevel(txDao, app, userRecord.Id, listData, levelData, verificationData, creatorIds)
+				if err != nil {
+					return err
+				}
+				return audit.Log(txDao, c, audit.Entry{
+					List:        listData.Name,
+					Action:      "level_placed",
+					TargetTable: listData.LevelTableName,
+					TargetId:    levelRecord.Id,
+					After:       audit.Snapshot(levelRecord),
+				})
+			})
 
 			c.Response().Header().Set("Cache-Control", "no-store")
 			return err
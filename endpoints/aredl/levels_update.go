@@ -1,12 +1,15 @@
 package aredl
 
 import (
+	"AREDL/audit"
 	"AREDL/demonlist"
 	"AREDL/middlewares"
+	"AREDL/util"
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/labstack/echo/v5"
 	"github.com/pocketbase/pocketbase/apis"
 	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
 	"github.com/pocketbase/pocketbase/models"
 	"net/http"
 )
@@ -18,40 +21,43 @@ import (
 //	@Description	Requires user permission: aredl.manage_levels
 //	@Security		ApiKeyAuth
 //	@Tags			aredl
-//	@Param			id				path	string		true	"internal level id"
-//	@Param			creator_ids		query	[]string	false	"list of all creators using their internal user ids"
-//	@Param			level_id		query	int			false	"gd level id"												minimum(1)
-//	@Param			position		query	int			false	"position to move to if different form current position"	minimum(1)
-//	@Param			name			query	string		false	"displayed name of the level"
-//	@Param			publisher		query	string		false	"publisher user id"
-//	@Param			level_password	query	string		false	"gd level password"
-//	@Param			custom_song		query	string		false	"reference to custom song"
-//	@Param			legacy			query	bool		false	"whether the level should be placed as legacy"
+//	@Param			id					path	string		true	"internal level id"
+//	@Param			creator_ids			query	[]string	false	"list of all creators using their internal user ids"
+//	@Param			level_id			query	int			false	"gd level id"												minimum(1)
+//	@Param			position			query	int			false	"position to move to if different form current position"	minimum(1)
+//	@Param			name				query	string		false	"displayed name of the level"
+//	@Param			publisher			query	string		false	"publisher user id"
+//	@Param			level_password		query	string		false	"gd level password"
+//	@Param			custom_song			query	string		false	"reference to custom song"
+//	@Param			legacy				query	bool		false	"whether the level should be placed as legacy"
+//	@Param			percent_to_qualify	query	int			false	"lowest percentage a progress record needs, 0 to only accept completions"	minimum(0)	maximum(100)
 //	@Schemes		http https
 //	@Produce		json
 //	@Success		200
 //	@Failure		400	{object}	util.ErrorResponse
 //	@Failure		403	{object}	util.ErrorResponse
 //	@Router			/aredl/levels/{id} [patch]
-func registerLevelUpdateEndpoint(e *echo.Group, app core.App) error {
+func registerLevelUpdateEndpoint(e *echo.Group, app core.App, listData demonlist.ListData) error {
 	_, err := e.AddRoute(echo.Route{
 		Method: http.MethodPatch,
 		Path:   "/levels/:id",
 		Middlewares: []echo.MiddlewareFunc{
 			apis.ActivityLogger(app),
-			middlewares.RequirePermissionGroup(app, "aredl", "manage_levels"),
+			middlewares.RateLimit(app, middlewares.RateLimitDefault),
+			middlewares.RequirePermissionGroup(app, listData.Name, "manage_levels"),
 			middlewares.LoadParam(middlewares.LoadData{
 				"id":          middlewares.LoadString(true),
 				"creator_ids": middlewares.LoadStringArray(false),
 				"levelData": middlewares.LoadMap("", middlewares.LoadData{
-					"level_id":       middlewares.LoadInt(false),
-					"name":           middlewares.LoadString(false),
-					"verification":   middlewares.LoadString(false),
-					"publisher":      middlewares.LoadString(false),
-					"level_password": middlewares.LoadString(false),
-					"custom_song":    middlewares.LoadString(false),
-					"legacy":         middlewares.LoadBool(false),
-					"position":       middlewares.LoadInt(false, validation.Min(1)),
+					"level_id":           middlewares.LoadInt(false),
+					"name":               middlewares.LoadString(false),
+					"verification":       middlewares.LoadString(false),
+					"publisher":          middlewares.LoadString(false),
+					"level_password":     middlewares.LoadString(false),
+					"custom_song":        middlewares.LoadString(false),
+					"legacy":             middlewares.LoadBool(false),
+					"position":           middlewares.LoadInt(false, validation.Min(1)),
+					"percent_to_qualify": middlewares.LoadInt(false, validation.Min(0), validation.Max(100)),
 				}),
 			}),
 		},
@@ -60,10 +66,31 @@ func registerLevelUpdateEndpoint(e *echo.Group, app core.App) error {
 			if userRecord == nil {
 				return apis.NewApiError(http.StatusInternalServerError, "User not found", nil)
 			}
-			aredl := demonlist.Aredl()
 			levelData := c.Get("levelData").(map[string]interface{})
 			c.Response().Header().Set("Cache-Control", "no-store")
-			return demonlist.UpdateLevel(app.Dao(), app, c.Get("id").(string), userRecord.Id, aredl, levelData, c.Get("creator_ids"))
+			return app.Dao().RunInTransaction(func(txDao *daos.Dao) error {
+				levelRecord, err := txDao.FindRecordById(listData.LevelTableName, c.Get("id").(string))
+				if err != nil {
+					return util.NewErrorResponse(err, "Level not found")
+				}
+				before := audit.Snapshot(levelRecord)
+				err = demonlist.UpdateLevel(txDao, app, levelRecord.Id, userRecord.Id, listData, levelData, c.Get("creator_ids"))
+				if err != nil {
+					return err
+				}
+				levelRecord, err = txDao.FindRecordById(listData.LevelTableName, levelRecord.Id)
+				if err != nil {
+					return util.NewErrorResponse(err, "Level not found")
+				}
+				return audit.Log(txDao, c, audit.Entry{
+					List:        listData.Name,
+					Action:      "level_updated",
+					TargetTable: listData.LevelTableName,
+					TargetId:    levelRecord.Id,
+					Before:      before,
+					After:       audit.Snapshot(levelRecord),
+				})
+			})
 		},
 	})
 	return err
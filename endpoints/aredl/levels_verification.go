@@ -0,0 +1,99 @@
+package aredl
+
+import (
+	"AREDL/audit"
+	"AREDL/demonlist"
+	"AREDL/middlewares"
+	"AREDL/util"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/models"
+	"net/http"
+)
+
+// registerLevelVerificationEndpoint godoc
+//
+//	@Summary		Replace AREDL verification
+//	@Description	Replaces the verification of a level. If the verifier stays the same, only the verification data is updated.
+//	@Description	Otherwise, the old verification is removed with the given reason and an existing record of the new verifier becomes the verification.
+//	@Description	The placement order of the other records and the leaderboards of both verifiers are updated.
+//	@Description	Requires user permission: aredl.manage_levels
+//	@Security		ApiKeyAuth
+//	@Tags			aredl
+//	@Param			id				path	string	true	"internal level id"
+//	@Param			reason			query	string	true	"reason for the replacement, shown to the old verifier"
+//	@Param			submitted_by	query	string	true	"user id of the verifier"
+//	@Param			video_url		query	string	true	"video url of the verification"	format(url)
+//	@Param			mobile			query	bool	true	"whether verification was done on mobile"
+//	@Param			raw_footage		query	string	false	"verification raw footage"
+//	@Schemes		http https
+//	@Produce		json
+//	@Success		200
+//	@Failure		400	{object}	util.ErrorResponse
+//	@Failure		403	{object}	util.ErrorResponse
+//	@Router			/aredl/levels/{id}/verification [put]
+func registerLevelVerificationEndpoint(e *echo.Group, app core.App, listData demonlist.ListData) error {
+	_, err := e.AddRoute(echo.Route{
+		Method: http.MethodPut,
+		Path:   "/levels/:id/verification",
+		Middlewares: []echo.MiddlewareFunc{
+			apis.ActivityLogger(app),
+			middlewares.RateLimit(app, middlewares.RateLimitDefault),
+			middlewares.CheckBanned(),
+			middlewares.RequirePermissionGroup(app, listData.Name, "manage_levels"),
+			middlewares.LoadParam(middlewares.LoadData{
+				"id":     middlewares.LoadString(true),
+				"reason": middlewares.LoadString(true, validation.Length(1, 1000)),
+				"verificationData": middlewares.LoadMap("", middlewares.LoadData{
+					"submitted_by": middlewares.LoadString(true),
+					"video_url":    middlewares.LoadString(true, is.URL),
+					"mobile":       middlewares.LoadBool(true),
+					"raw_footage":  middlewares.LoadString(false),
+				}),
+			}),
+		},
+		Handler: func(c echo.Context) error {
+			userRecord, _ := c.Get(apis.ContextAuthRecordKey).(*models.Record)
+			if userRecord == nil {
+				return util.NewErrorResponse(nil, "User not found")
+			}
+			verificationData := c.Get("verificationData").(map[string]interface{})
+			verificationData["percentage"] = 100
+
+			err := app.Dao().RunInTransaction(func(txDao *daos.Dao) error {
+				levelRecord, err := txDao.FindRecordById(listData.LevelTableName, c.Get("id").(string))
+				if err != nil {
+					return util.NewErrorResponse(err, "Level not found")
+				}
+				var before map[string]any
+				verifications, err := txDao.FindRecordsByExpr(listData.RecordsTableName, dbx.HashExp{"level": levelRecord.Id, "placement_order": 1})
+				if err != nil {
+					return util.NewErrorResponse(err, "Failed to query verification")
+				}
+				if len(verifications) == 1 {
+					before = audit.Snapshot(verifications[0])
+				}
+				verification, err := demonlist.ReplaceVerification(txDao, app, listData, levelRecord.Id, userRecord.Id, verificationData, c.Get("reason").(string))
+				if err != nil {
+					return err
+				}
+				return audit.Log(txDao, c, audit.Entry{
+					List:        listData.Name,
+					Action:      "verification_replaced",
+					TargetTable: listData.LevelTableName,
+					TargetId:    levelRecord.Id,
+					Before:      before,
+					After:       audit.Snapshot(verification),
+				})
+			})
+			c.Response().Header().Set("Cache-Control", "no-store")
+			return err
+		},
+	})
+	return err
+}
@@ -1,6 +1,7 @@
 package aredl
 
 import (
+	"AREDL/audit"
 	"AREDL/demonlist"
 	"AREDL/middlewares"
 	"AREDL/util"
@@ -27,34 +28,41 @@ import (
 //	@Failure		400	{object}	util.ErrorResponse
 //	@Failure		403	{object}	util.ErrorResponse
 //	@Router			/aredl/leaderboard/refresh [post]
-func registerUpdateListEndpoint(e *echo.Group, app core.App) error {
+func registerUpdateListEndpoint(e *echo.Group, app core.App, listData demonlist.ListData) error {
 	_, err := e.AddRoute(echo.Route{
 		Method: http.MethodPost,
 		Path:   "/leaderboard/refresh",
 		Middlewares: []echo.MiddlewareFunc{
 			apis.ActivityLogger(app),
-			middlewares.RequirePermissionGroup(app, "aredl", "update_listpoints"),
+			middlewares.RateLimit(app, middlewares.RateLimitDefault),
+			middlewares.RequirePermissionGroup(app, listData.Name, "update_listpoints"),
 			middlewares.LoadParam(middlewares.LoadData{
 				"min_position": middlewares.LoadInt(true, validation.Min(1)),
 				"max_position": middlewares.LoadInt(true, validation.Min(1)),
 			}),
 		},
 		Handler: func(c echo.Context) error {
-			aredl := demonlist.Aredl()
 			err := app.Dao().RunInTransaction(func(txDao *daos.Dao) error {
-				err := demonlist.UpdateAllCompletedPacks(txDao, aredl)
+				err := demonlist.UpdateAllCompletedPacks(txDao, listData)
 				if err != nil {
 					return util.NewErrorResponse(err, "Failed to update completed packs")
 				}
-				err = demonlist.UpdatePointTable(txDao, aredl)
+				err = demonlist.UpdatePointTable(txDao, listData)
 				if err != nil {
 					return util.NewErrorResponse(err, "Failed to update point table")
 				}
-				err = demonlist.UpdateLevelListPointsByPositionRange(txDao, aredl, c.Get("min_position").(int), c.Get("max_position").(int))
+				err = demonlist.UpdateLevelListPointsByPositionRange(txDao, listData, c.Get("min_position").(int), c.Get("max_position").(int))
 				if err != nil {
 					return util.NewErrorResponse(err, "Failed to update list points")
 				}
-				return nil
+				return audit.Log(txDao, c, audit.Entry{
+					List:   listData.Name,
+					Action: "list_updated",
+					After: map[string]any{
+						"min_position": c.Get("min_position"),
+						"max_position": c.Get("max_position"),
+					},
+				})
 			})
 			c.Response().Header().Set("Cache-Control", "no-store")
 			return err
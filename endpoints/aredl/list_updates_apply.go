@@ -0,0 +1,86 @@
+package aredl
+
+import (
+	"AREDL/audit"
+	"AREDL/demonlist"
+	"AREDL/middlewares"
+	"AREDL/util"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/models"
+	"net/http"
+)
+
+// registerListUpdateApplyEndpoint godoc
+//
+//	@Summary		Apply AREDL list update
+//	@Description	Places and moves multiple levels at once. Positions refer to the list after the update, all other levels keep their order and fill the remaining positions.
+//	@Description	The final order is validated before anything is changed. All history entries are grouped under the named list update and points are only recalculated once.
+//	@Description	moves: [{"id": "internal level id", "position": 1, "legacy": false}], legacy is optional.
+//	@Description	placements: [{"position": 1, "legacy": false, "level_id": 1, "name": "", "publisher": "user id", "level_password": "", "percent_to_qualify": 0, "creator_ids": [], "verification": {"submitted_by": "user id", "video_url": "", "mobile": false, "raw_footage": ""}}]
+//	@Description	Requires user permission: aredl.manage_levels
+//	@Security		ApiKeyAuth
+//	@Tags			aredl
+//	@Param			name		query	string	true	"name of the list update"
+//	@Param			changelog	query	string	false	"changelog note"
+//	@Param			moves		query	string	false	"JSON array of level moves"
+//	@Param			placements	query	string	false	"JSON array of new levels"
+//	@Schemes		http https
+//	@Produce		json
+//	@Success		200
+//	@Failure		400	{object}	util.ErrorResponse
+//	@Failure		403	{object}	util.ErrorResponse
+//	@Router			/aredl/list-updates [post]
+func registerListUpdateApplyEndpoint(e *echo.Group, app core.App, listData demonlist.ListData) error {
+	_, err := e.AddRoute(echo.Route{
+		Method: http.MethodPost,
+		Path:   "/list-updates",
+		Middlewares: []echo.MiddlewareFunc{
+			apis.ActivityLogger(app),
+			middlewares.RateLimit(app, middlewares.RateLimitDefault),
+			middlewares.CheckBanned(),
+			middlewares.RequirePermissionGroup(app, listData.Name, "manage_levels"),
+			middlewares.LoadParam(middlewares.LoadData{
+				"name":       middlewares.LoadString(true, validation.Length(1, 100)),
+				"changelog":  middlewares.AddDefault("", middlewares.LoadString(false)),
+				"moves":      middlewares.AddDefault([]demonlist.LevelMove{}, middlewares.LoadJson[[]demonlist.LevelMove](false)),
+				"placements": middlewares.AddDefault([]demonlist.LevelPlacement{}, middlewares.LoadJson[[]demonlist.LevelPlacement](false)),
+			}),
+		},
+		Handler: func(c echo.Context) error {
+			userRecord, _ := c.Get(apis.ContextAuthRecordKey).(*models.Record)
+			if userRecord == nil {
+				return util.NewErrorResponse(nil, "User not found")
+			}
+			update := demonlist.ListUpdate{
+				Name:       c.Get("name").(string),
+				Changelog:  c.Get("changelog").(string),
+				Moves:      c.Get("moves").([]demonlist.LevelMove),
+				Placements: c.Get("placements").([]demonlist.LevelPlacement),
+			}
+			err := app.Dao().RunInTransaction(func(txDao *daos.Dao) error {
+				listUpdateRecord, err := demonlist.ApplyListUpdate(txDao, app, listData, userRecord.Id, update)
+				if err != nil {
+					return err
+				}
+				return audit.Log(txDao, c, audit.Entry{
+					List:        listData.Name,
+					Action:      "list_update_applied",
+					TargetTable: listData.ListUpdatesTableName,
+					TargetId:    listUpdateRecord.Id,
+					After: map[string]any{
+						"list_update": audit.Snapshot(listUpdateRecord),
+						"moves":       update.Moves,
+						"placements":  update.Placements,
+					},
+				})
+			})
+			c.Response().Header().Set("Cache-Control", "no-store")
+			return err
+		},
+	})
+	return err
+}
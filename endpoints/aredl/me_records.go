@@ -25,6 +25,7 @@ type Record struct {
 	} `db:"level" json:"level,omitempty" extend:"level,levels,id"`
 	VideoUrl   string `db:"video_url" json:"video_url,omitempty"`
 	Mobile     bool   `db:"mobile" json:"mobile,omitempty"`
+	Percentage int    `db:"percentage" json:"percentage,omitempty"`
 	LdmId      int    `db:"ldm_id" json:"ldm_id,omitempty"`
 	RawFootage string `db:"raw_footage" json:"raw_footage,omitempty"`
 }
@@ -42,17 +43,17 @@ type Record struct {
 //	@Failure		400	{object}	util.ErrorResponse
 //	@Failure		403	{object}	util.ErrorResponse
 //	@Router			/aredl/me/records [get]
-func registerRecordList(e *echo.Group, app core.App) error {
+func registerRecordList(e *echo.Group, app core.App, listData demonlist.ListData) error {
 	_, err := e.AddRoute(echo.Route{
 		Method: http.MethodGet,
 		Path:   "/me/records",
 		Middlewares: []echo.MiddlewareFunc{
 			apis.ActivityLogger(app),
+			middlewares.RateLimit(app, middlewares.RateLimitDefault),
 			middlewares.CheckBanned(),
-			middlewares.RequirePermissionGroup(app, "aredl", "user_record_list"),
+			middlewares.RequirePermissionGroup(app, listData.Name, "user_record_list"),
 		},
 		Handler: func(c echo.Context) error {
-			aredl := demonlist.Aredl()
 			err := app.Dao().RunInTransaction(func(txDao *daos.Dao) error {
 				userRecord, _ := c.Get(apis.ContextAuthRecordKey).(*models.Record)
 				if userRecord == nil {
@@ -60,8 +61,8 @@ func registerRecordList(e *echo.Group, app core.App) error {
 				}
 				var records []Record
 				tables := map[string]string{
-					"base":   aredl.RecordsTableName,
-					"levels": aredl.LevelTableName,
+					"base":   listData.RecordsTableName,
+					"levels": listData.LevelTableName,
 				}
 				err := util.LoadFromDb(app.Dao().DB(), &records, tables, func(query *dbx.SelectQuery, prefixResolver util.PrefixResolver) {
 					query.Where(dbx.HashExp{prefixResolver("submitted_by"): userRecord.Id})
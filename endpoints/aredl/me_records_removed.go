@@ -0,0 +1,74 @@
+package aredl
+
+import (
+	"AREDL/demonlist"
+	"AREDL/middlewares"
+	"AREDL/util"
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/tools/types"
+	"net/http"
+)
+
+type RemovedRecord struct {
+	Id      string         `db:"id" json:"id"`
+	Created types.DateTime `db:"created" json:"created"`
+	Level   struct {
+		Id      string `db:"id" json:"id,omitempty"`
+		Name    string `db:"name" json:"name,omitempty"`
+		LevelId int    `db:"level_id" json:"level_id,omitempty"`
+	} `db:"level" json:"level,omitempty" extend:"level,levels,id"`
+	Reason string `db:"reason" json:"reason"`
+	// Record is the record as it was when it got removed
+	Record types.JsonRaw `db:"record" json:"record" swaggertype:"object"`
+}
+
+// registerRemovedRecordList godoc
+//
+//	@Summary		List removed records
+//	@Description	Lists the records of the authenticated user that have been removed by staff together with the reason, newest first.
+//	@Description	Requires user permission: aredl.user_record_list
+//	@Security		ApiKeyAuth
+//	@Tags			aredl
+//	@Schemes		http https
+//	@Produce		json
+//	@Success		200	{object}	[]RemovedRecord
+//	@Failure		400	{object}	util.ErrorResponse
+//	@Failure		403	{object}	util.ErrorResponse
+//	@Router			/aredl/me/records/removed [get]
+func registerRemovedRecordList(e *echo.Group, app core.App, listData demonlist.ListData) error {
+	_, err := e.AddRoute(echo.Route{
+		Method: http.MethodGet,
+		Path:   "/me/records/removed",
+		Middlewares: []echo.MiddlewareFunc{
+			apis.ActivityLogger(app),
+			middlewares.RateLimit(app, middlewares.RateLimitDefault),
+			middlewares.CheckBanned(),
+			middlewares.RequirePermissionGroup(app, listData.Name, "user_record_list"),
+		},
+		Handler: func(c echo.Context) error {
+			userRecord, _ := c.Get(apis.ContextAuthRecordKey).(*models.Record)
+			if userRecord == nil {
+				return util.NewErrorResponse(nil, "Could not load user")
+			}
+			var removed []RemovedRecord
+			tables := map[string]string{
+				"base":   listData.RecordTombstonesTableName,
+				"levels": listData.LevelTableName,
+			}
+			err := util.LoadFromDb(app.Dao().DB(), &removed, tables, func(query *dbx.SelectQuery, prefixResolver util.PrefixResolver) {
+				query.Where(dbx.HashExp{prefixResolver("submitted_by"): userRecord.Id})
+				query.OrderBy(prefixResolver("created") + " DESC")
+			})
+			if err != nil {
+				return util.NewErrorResponse(err, "could not load removed records")
+			}
+			c.Response().Header().Set("Cache-Control", "no-store")
+			return c.JSON(200, removed)
+		},
+	})
+	return err
+}
@@ -25,8 +25,9 @@ type MeSubmission struct {
 	} `db:"level" json:"level,omitempty" extend:"level,levels,id"`
 	VideoUrl        string `db:"video_url" json:"video_url,omitempty"`
 	Mobile          bool   `db:"mobile" json:"mobile,omitempty"`
+	Percentage      int    `db:"percentage" json:"percentage,omitempty"`
 	LdmId           int    `db:"ldm_id" json:"ldm_id,omitempty"`
-	Rejected        bool   `db:"rejected" json:"rejected"`
+	Status          string `db:"status" json:"status"`
 	IdUpdate        bool   `db:"is_update" json:"is_update"`
 	RawFootage      string `db:"raw_footage" json:"raw_footage,omitempty"`
 	AdditionalNotes string `db:"additional_notes" json:"additional_notes"`
@@ -46,17 +47,17 @@ type MeSubmission struct {
 //	@Failure		400	{object}	util.ErrorResponse
 //	@Failure		403	{object}	util.ErrorResponse
 //	@Router			/aredl/me/submissions [get]
-func registerMeSubmissionList(e *echo.Group, app core.App) error {
+func registerMeSubmissionList(e *echo.Group, app core.App, listData demonlist.ListData) error {
 	_, err := e.AddRoute(echo.Route{
 		Method: http.MethodGet,
 		Path:   "/me/submissions",
 		Middlewares: []echo.MiddlewareFunc{
 			apis.ActivityLogger(app),
+			middlewares.RateLimit(app, middlewares.RateLimitDefault),
 			middlewares.CheckBanned(),
-			middlewares.RequirePermissionGroup(app, "aredl", "user_submission_list"),
+			middlewares.RequirePermissionGroup(app, listData.Name, "user_submission_list"),
 		},
 		Handler: func(c echo.Context) error {
-			aredl := demonlist.Aredl()
 			err := app.Dao().RunInTransaction(func(txDao *daos.Dao) error {
 				userRecord, _ := c.Get(apis.ContextAuthRecordKey).(*models.Record)
 				if userRecord == nil {
@@ -64,8 +65,8 @@ func registerMeSubmissionList(e *echo.Group, app core.App) error {
 				}
 				var submissions []MeSubmission
 				tables := map[string]string{
-					"base":   aredl.SubmissionsTableName,
-					"levels": aredl.LevelTableName,
+					"base":   listData.SubmissionsTableName,
+					"levels": listData.LevelTableName,
 				}
 				err := util.LoadFromDb(app.Dao().DB(), &submissions, tables, func(query *dbx.SelectQuery, prefixResolver util.PrefixResolver) {
 					query.Where(dbx.HashExp{prefixResolver("submitted_by"): userRecord.Id})
@@ -82,3 +83,15 @@ func registerMeSubmissionList(e *echo.Group, app core.App) error {
 	})
 	return err
 }
+
+// findOwnSubmission loads the submission with the given id and makes sure it was submitted by the user
+func findOwnSubmission(dao *daos.Dao, listData demonlist.ListData, userId string, submissionId string) (*models.Record, error) {
+	submissionRecord, err := dao.FindRecordById(listData.SubmissionsTableName, submissionId)
+	if err != nil {
+		return nil, util.NewErrorResponse(err, "Submission was not found")
+	}
+	if submissionRecord.GetString("submitted_by") != userId {
+		return nil, util.NewErrorResponse(nil, "Submission does not belong to the user")
+	}
+	return submissionRecord, nil
+}
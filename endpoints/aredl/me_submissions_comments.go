@@ -0,0 +1,59 @@
+package aredl
+
+import (
+	"AREDL/demonlist"
+	"AREDL/middlewares"
+	"AREDL/util"
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/models"
+	"net/http"
+)
+
+// registerMeSubmissionCommentsEndpoint godoc
+//
+//	@Summary		Own submission comments
+//	@Description	Lists the comment thread of one of the submissions of the authenticated user ordered from oldest to newest.
+//	@Description	Requires user permission: aredl.user_submission_list
+//	@Security		ApiKeyAuth
+//	@Tags			aredl
+//	@Param			id	path	string	true	"internal submission id"
+//	@Schemes		http https
+//	@Produce		json
+//	@Success		200	{object}	[]SubmissionComment
+//	@Failure		400	{object}	util.ErrorResponse
+//	@Failure		403	{object}	util.ErrorResponse
+//	@Router			/aredl/me/submissions/{id}/comments [get]
+func registerMeSubmissionCommentsEndpoint(e *echo.Group, app core.App, listData demonlist.ListData) error {
+	_, err := e.AddRoute(echo.Route{
+		Method: http.MethodGet,
+		Path:   "/me/submissions/:id/comments",
+		Middlewares: []echo.MiddlewareFunc{
+			apis.ActivityLogger(app),
+			middlewares.RateLimit(app, middlewares.RateLimitDefault),
+			middlewares.CheckBanned(),
+			middlewares.RequirePermissionGroup(app, listData.Name, "user_submission_list"),
+			middlewares.LoadParam(middlewares.LoadData{
+				"id": middlewares.LoadString(true),
+			}),
+		},
+		Handler: func(c echo.Context) error {
+			userRecord, _ := c.Get(apis.ContextAuthRecordKey).(*models.Record)
+			if userRecord == nil {
+				return util.NewErrorResponse(nil, "Could not load user")
+			}
+			submissionRecord, err := findOwnSubmission(app.Dao(), listData, userRecord.Id, c.Get("id").(string))
+			if err != nil {
+				return err
+			}
+			comments, err := loadSubmissionComments(app.Dao(), listData, submissionRecord.Id)
+			if err != nil {
+				return err
+			}
+			c.Response().Header().Set("Cache-Control", "no-store")
+			return c.JSON(200, comments)
+		},
+	})
+	return err
+}
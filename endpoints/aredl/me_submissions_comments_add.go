@@ -0,0 +1,64 @@
+package aredl
+
+import (
+	"AREDL/demonlist"
+	"AREDL/middlewares"
+	"AREDL/util"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/models"
+	"net/http"
+)
+
+// registerMeSubmissionCommentAddEndpoint godoc
+//
+//	@Summary		Comment on own submission
+//	@Description	Adds a message to the comment thread of one of the submissions of the authenticated user.
+//	@Description	If a reviewer asked for more information, the reply moves the submission back into the queue.
+//	@Description	Requires user permission: aredl.user_submit
+//	@Security		ApiKeyAuth
+//	@Tags			aredl
+//	@Param			id		path	string	true	"internal submission id"
+//	@Param			message	query	string	true	"message, max 1000 characters"
+//	@Schemes		http https
+//	@Produce		json
+//	@Success		200
+//	@Failure		400	{object}	util.ErrorResponse
+//	@Failure		403	{object}	util.ErrorResponse
+//	@Router			/aredl/me/submissions/{id}/comments [post]
+func registerMeSubmissionCommentAddEndpoint(e *echo.Group, app core.App, listData demonlist.ListData) error {
+	_, err := e.AddRoute(echo.Route{
+		Method: http.MethodPost,
+		Path:   "/me/submissions/:id/comments",
+		Middlewares: []echo.MiddlewareFunc{
+			apis.ActivityLogger(app),
+			middlewares.RateLimit(app, middlewares.RateLimitDefault),
+			middlewares.CheckBanned(),
+			middlewares.RequirePermissionGroup(app, listData.Name, "user_submit"),
+			middlewares.LoadParam(middlewares.LoadData{
+				"id":      middlewares.LoadString(true),
+				"message": middlewares.LoadString(true, validation.Length(1, 1000)),
+			}),
+		},
+		Handler: func(c echo.Context) error {
+			userRecord, _ := c.Get(apis.ContextAuthRecordKey).(*models.Record)
+			if userRecord == nil {
+				return util.NewErrorResponse(nil, "Could not load user")
+			}
+			err := app.Dao().RunInTransaction(func(txDao *daos.Dao) error {
+				submissionRecord, err := findOwnSubmission(txDao, listData, userRecord.Id, c.Get("id").(string))
+				if err != nil {
+					return err
+				}
+				_, err = demonlist.AddSubmissionComment(txDao, listData, submissionRecord, userRecord.Id, c.Get("message").(string), false, false)
+				return err
+			})
+			c.Response().Header().Set("Cache-Control", "no-store")
+			return err
+		},
+	})
+	return err
+}
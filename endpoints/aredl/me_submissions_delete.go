@@ -1,6 +1,7 @@
 package aredl
 
 import (
+	"AREDL/audit"
 	"AREDL/demonlist"
 	"AREDL/middlewares"
 	"AREDL/util"
@@ -15,7 +16,7 @@ import (
 // registerSubmissionWithdrawEndpoint godoc
 //
 //	@Summary		Delete submission
-//	@Description	Deletes a submission as long as it still is open for review.
+//	@Description	Deletes a submission as long as it has not been accepted or rejected permanently and no reviewer is working on it.
 //	@Description	Requires user permission: aredl.user_submission_delete
 //	@Security		ApiKeyAuth
 //	@Tags			aredl
@@ -26,14 +27,15 @@ import (
 //	@Failure		400	{object}	util.ErrorResponse
 //	@Failure		403	{object}	util.ErrorResponse
 //	@Router			/aredl/me/submissions/{id} [delete]
-func registerSubmissionWithdrawEndpoint(e *echo.Group, app core.App) error {
+func registerSubmissionWithdrawEndpoint(e *echo.Group, app core.App, listData demonlist.ListData) error {
 	_, err := e.AddRoute(echo.Route{
 		Method: http.MethodDelete,
 		Path:   "/me/submissions/:id",
 		Middlewares: []echo.MiddlewareFunc{
 			apis.ActivityLogger(app),
+			middlewares.RateLimit(app, middlewares.RateLimitDefault),
 			middlewares.CheckBanned(),
-			middlewares.RequirePermissionGroup(app, "aredl", "user_submission_delete"),
+			middlewares.RequirePermissionGroup(app, listData.Name, "user_submission_delete"),
 			middlewares.LoadParam(middlewares.LoadData{
 				"id": middlewares.LoadString(true),
 			}),
@@ -44,18 +46,23 @@ func registerSubmissionWithdrawEndpoint(e *echo.Group, app core.App) error {
 				if userRecord == nil {
 					return util.NewErrorResponse(nil, "Could not load user")
 				}
-				aredl := demonlist.Aredl()
-				submissionRecord, err := txDao.FindRecordById(aredl.SubmissionsTableName, c.Get("id").(string))
+				submissionRecord, err := txDao.FindRecordById(listData.SubmissionsTableName, c.Get("id").(string))
 				if err != nil {
 					return util.NewErrorResponse(err, "Submission was not found")
 				}
 				if submissionRecord.GetString("submitted_by") != userRecord.Id {
 					return util.NewErrorResponse(err, "Submission does not belong to the user")
 				}
-				if submissionRecord.GetBool("rejected") {
-					return util.NewErrorResponse(err, "Submission was already processed")
+				status := demonlist.SubmissionStatus(submissionRecord)
+				if status == demonlist.SubmissionAccepted || status == demonlist.SubmissionRejectedFinal {
+					return util.NewErrorResponse(nil, "Submission was already processed")
 				}
-				err = demonlist.DeleteSubmission(txDao, aredl, submissionRecord)
+				err = demonlist.CheckSubmissionClaim(submissionRecord, userRecord.Id)
+				if err != nil {
+					return err
+				}
+				before := audit.Snapshot(submissionRecord)
+				err = demonlist.DeleteSubmission(txDao, listData, submissionRecord)
 				if err != nil {
 					return err
 				}
@@ -63,7 +70,13 @@ func registerSubmissionWithdrawEndpoint(e *echo.Group, app core.App) error {
 				if err != nil {
 					return util.NewErrorResponse(err, "Failed to delete submission")
 				}
-				return nil
+				return audit.Log(txDao, c, audit.Entry{
+					List:        listData.Name,
+					Action:      "submission_withdrawn",
+					TargetTable: listData.SubmissionsTableName,
+					TargetId:    submissionRecord.Id,
+					Before:      before,
+				})
 			})
 			c.Response().Header().Set("Cache-Control", "no-store")
 			return err
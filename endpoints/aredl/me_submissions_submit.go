@@ -1,12 +1,14 @@
 package aredl
 
 import (
+	"AREDL/audit"
 	"AREDL/demonlist"
 	"AREDL/middlewares"
 	"AREDL/util"
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/go-ozzo/ozzo-validation/v4/is"
 	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase/apis"
 	"github.com/pocketbase/pocketbase/core"
 	"github.com/pocketbase/pocketbase/daos"
@@ -26,6 +28,7 @@ import (
 //	@Param			level				query	string	true	"internal level id"
 //	@Param			video_url			query	string	true	"display video url"	format(url)
 //	@Param			mobile				query	bool	true	"whether submission was done on mobile"
+//	@Param			percentage			query	int		false	"reached percentage, below 100 the submission is a progress record. Has to be at least the percentage to qualify of the level"	default(100)	minimum(1)	maximum(100)
 //	@Param			ldm_id				query	int		false	"ldm gd level id if used"
 //	@Param			raw_footage			query	string	false	"raw footage"	format(url)
 //	@Param			additional_notes	query	string	false	"additional notes the user wants to add to a submission. Max 100 characters"
@@ -35,19 +38,21 @@ import (
 //	@Failure		400	{object}	util.ErrorResponse
 //	@Failure		403	{object}	util.ErrorResponse
 //	@Router			/aredl/me/submissions [put]
-func registerSubmissionEndpoint(e *echo.Group, app core.App) error {
+func registerSubmissionEndpoint(e *echo.Group, app core.App, listData demonlist.ListData) error {
 	_, err := e.AddRoute(echo.Route{
 		Method: http.MethodPut,
 		Path:   "/me/submissions",
 		Middlewares: []echo.MiddlewareFunc{
 			apis.ActivityLogger(app),
+			middlewares.RateLimit(app, middlewares.RateLimitDefault),
 			middlewares.CheckBanned(),
-			middlewares.RequirePermissionGroup(app, "aredl", "user_submit"),
+			middlewares.RequirePermissionGroup(app, listData.Name, "user_submit"),
 			middlewares.LoadParam(middlewares.LoadData{
 				"submissionData": middlewares.LoadMap("", middlewares.LoadData{
 					"level":            middlewares.LoadString(true),
 					"video_url":        middlewares.LoadString(true, is.URL),
 					"mobile":           middlewares.LoadBool(true),
+					"percentage":       middlewares.AddDefault(100, middlewares.LoadInt(false, validation.Min(1), validation.Max(100))),
 					"ldm_id":           middlewares.LoadInt(false),
 					"raw_footage":      middlewares.LoadString(false, is.URL),
 					"additional_notes": middlewares.LoadString(false, validation.Match(regexp.MustCompile("^([a-zA-Z0-9 ._]{0,100}$)"))),
@@ -56,7 +61,6 @@ func registerSubmissionEndpoint(e *echo.Group, app core.App) error {
 		},
 		Handler: func(c echo.Context) error {
 			err := app.Dao().RunInTransaction(func(txDao *daos.Dao) error {
-				aredl := demonlist.Aredl()
 				userRecord := c.Get(apis.ContextAuthRecordKey).(*models.Record)
 				if userRecord == nil {
 					return util.NewErrorResponse(nil, "User not found")
@@ -64,17 +68,39 @@ func registerSubmissionEndpoint(e *echo.Group, app core.App) error {
 				submissionData := c.Get("submissionData").(map[string]interface{})
 				submissionData["submitted_by"] = userRecord.Id
 				// verify that submitted level exists
-				_, err := txDao.FindRecordById(aredl.LevelTableName, submissionData["level"].(string))
+				levelRecord, err := txDao.FindRecordById(listData.LevelTableName, submissionData["level"].(string))
 				if err != nil {
 					return apis.NewBadRequestError("Invalid level", nil)
 				}
-				hasPriority, _, err := middlewares.GetPermission(txDao, userRecord.Id, "aredl", "priority")
+				err = demonlist.ValidateSubmissionPercentage(txDao, listData, levelRecord, userRecord.Id, submissionData["percentage"].(int))
+				if err != nil {
+					return err
+				}
+				hasPriority, _, err := middlewares.GetPermission(txDao, userRecord.Id, listData.Name, "priority")
 				if err != nil {
 					return util.NewErrorResponse(err, "Failed to load priority")
 				}
 				submissionData["priority"] = hasPriority
-				err = demonlist.UpsertSubmission(txDao, app, aredl, submissionData)
-				return err
+				var before map[string]any
+				existingSubmissions, err := txDao.FindRecordsByExpr(listData.SubmissionsTableName, dbx.HashExp{"submitted_by": userRecord.Id, "level": levelRecord.Id})
+				if err != nil {
+					return util.NewErrorResponse(err, "Failed to query for submissions")
+				}
+				if len(existingSubmissions) == 1 {
+					before = audit.Snapshot(existingSubmissions[0])
+				}
+				submissionRecord, err := demonlist.UpsertSubmission(txDao, app, listData, submissionData)
+				if err != nil {
+					return err
+				}
+				return audit.Log(txDao, c, audit.Entry{
+					List:        listData.Name,
+					Action:      "submission_submitted",
+					TargetTable: listData.SubmissionsTableName,
+					TargetId:    submissionRecord.Id,
+					Before:      before,
+					After:       audit.Snapshot(submissionRecord),
+				})
 			})
 			c.Response().Header().Set("Cache-Control", "no-store")
 			return err
@@ -1,6 +1,7 @@
 package aredl
 
 import (
+	"AREDL/demonlist"
 	"AREDL/middlewares"
 	"AREDL/names"
 	"AREDL/util"
@@ -8,35 +9,41 @@ import (
 	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase/apis"
 	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
 	"net/http"
 )
 
 type NameUser struct {
 	Id         string `db:"id" json:"id"`
 	GlobalName string `db:"global_name" json:"global_name"`
+	// Expires is the time the user loses the role, it is omitted for permanent roles
+	Expires *types.DateTime `json:"expires,omitempty"`
 }
 
 type UserRole struct {
-	User NameUser `json:"user" extend:"user,users,id" db:"user"`
-	Role string   `db:"role" json:"role,omitempty"`
+	User    NameUser       `json:"user" extend:"user,users,id" db:"user"`
+	Role    string         `db:"role" json:"role,omitempty"`
+	Expires types.DateTime `db:"expires" json:"-"`
 }
 
 // registerNamesEndpoint godoc
 //
 //	@Summary		Important users
 //	@Description	Gives a map of important users grouped by their role. This also includes aredl plus members
+//	@Description	Roles that did not start yet or already expired are left out, roles that are limited in time include their expiry
 //	@Tags			aredl
 //	@Schemes		http https
 //	@Produce		json
 //	@Success		200	{object}	map[string][]NameUser
 //	@Failure		400	{object}	util.ErrorResponse
 //	@Router			/aredl/names [get]
-func registerNamesEndpoint(e *echo.Group, app core.App) error {
+func registerNamesEndpoint(e *echo.Group, app core.App, listData demonlist.ListData) error {
 	_, err := e.AddRoute(echo.Route{
 		Method: http.MethodGet,
 		Path:   "/names",
 		Middlewares: []echo.MiddlewareFunc{
 			apis.ActivityLogger(app),
+			middlewares.RateLimit(app, middlewares.RateLimitDefault),
 			middlewares.LoadParam(middlewares.LoadData{}),
 		},
 		Handler: func(c echo.Context) error {
@@ -45,7 +52,9 @@ func registerNamesEndpoint(e *echo.Group, app core.App) error {
 				"base":  names.TableRoles,
 				"users": names.TableUsers,
 			}
-			err := util.LoadFromDb(app.Dao().DB(), &users, tableNames, func(query *dbx.SelectQuery, prefixResolver util.PrefixResolver) {})
+			err := util.LoadFromDb(app.Dao().DB(), &users, tableNames, func(query *dbx.SelectQuery, prefixResolver util.PrefixResolver) {
+				query.Where(middlewares.ActiveRoleGrantExp(prefixResolver("starts"), prefixResolver("expires"), types.NowDateTime()))
+			})
 			if err != nil {
 				return util.NewErrorResponse(err, "failed to query data")
 			}
@@ -55,6 +64,10 @@ func registerNamesEndpoint(e *echo.Group, app core.App) error {
 				if !exists {
 					list = make([]NameUser, 0)
 				}
+				if !user.Expires.IsZero() {
+					expires := user.Expires
+					user.User.Expires = &expires
+				}
 				result[user.Role] = append(list, user.User)
 			}
 			c.Response().Header().Set("Cache-Control", "public, max-age=3600")
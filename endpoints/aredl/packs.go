@@ -2,6 +2,7 @@ package aredl
 
 import (
 	"AREDL/demonlist"
+	"AREDL/middlewares"
 	"AREDL/util"
 	"github.com/labstack/echo/v5"
 	"github.com/pocketbase/dbx"
@@ -36,19 +37,19 @@ type Pack struct {
 //	@Success		200	{object}	[]Pack
 //	@Failure		400	{object}	util.ErrorResponse
 //	@Router			/aredl/packs [get]
-func registerPackEndpoint(e *echo.Group, app core.App) error {
+func registerPackEndpoint(e *echo.Group, app core.App, listData demonlist.ListData) error {
 	_, err := e.AddRoute(echo.Route{
 		Method: http.MethodGet,
 		Path:   "/packs",
 		Middlewares: []echo.MiddlewareFunc{
 			apis.ActivityLogger(app),
+			middlewares.RateLimit(app, middlewares.RateLimitDefault),
 		},
 		Handler: func(c echo.Context) error {
-			aredl := demonlist.Aredl()
 			err := app.Dao().RunInTransaction(func(txDao *daos.Dao) error {
 				var result []Pack
 				tableNames := map[string]string{
-					"base": aredl.Packs.PackTableName,
+					"base": listData.Packs.PackTableName,
 				}
 				err := util.LoadFromDb(txDao.DB(), &result, tableNames, func(query *dbx.SelectQuery, prefixResolver util.PrefixResolver) {
 					query.OrderBy(prefixResolver("placement_order"))
@@ -58,10 +59,10 @@ func registerPackEndpoint(e *echo.Group, app core.App) error {
 				}
 
 				output := result[:0]
-				tableNames["base"] = aredl.LevelTableName
+				tableNames["base"] = listData.LevelTableName
 				for _, pack := range result {
 					err = util.LoadFromDb(txDao.DB(), &pack.Levels, tableNames, func(query *dbx.SelectQuery, prefixResolver util.PrefixResolver) {
-						query.InnerJoin(demonlist.Aredl().Packs.PackLevelTableName+" pl", dbx.NewExp(prefixResolver("id")+" = pl.level"))
+						query.InnerJoin(listData.Packs.PackLevelTableName+" pl", dbx.NewExp(prefixResolver("id")+" = pl.level"))
 						query.Where(dbx.HashExp{"pl.pack": pack.Id})
 					})
 					if err != nil {
@@ -1,11 +1,13 @@
 package aredl
 
 import (
+	"AREDL/audit"
 	"AREDL/demonlist"
 	"AREDL/middlewares"
 	"github.com/labstack/echo/v5"
 	"github.com/pocketbase/pocketbase/apis"
 	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
 	"net/http"
 )
 
@@ -26,14 +28,15 @@ import (
 //	@Failure		400	{object}	util.ErrorResponse
 //	@Failure		403	{object}	util.ErrorResponse
 //	@Router			/aredl/packs [post]
-func registerPackCreate(e *echo.Group, app core.App) error {
+func registerPackCreate(e *echo.Group, app core.App, listData demonlist.ListData) error {
 	_, err := e.AddRoute(echo.Route{
 		Method: http.MethodPost,
 		Path:   "/packs",
 		Middlewares: []echo.MiddlewareFunc{
 			apis.ActivityLogger(app),
+			middlewares.RateLimit(app, middlewares.RateLimitDefault),
 			middlewares.CheckBanned(),
-			middlewares.RequirePermissionGroup(app, "aredl", "manage_packs"),
+			middlewares.RequirePermissionGroup(app, listData.Name, "manage_packs"),
 			middlewares.LoadParam(middlewares.LoadData{
 				"packData": middlewares.LoadMap("", middlewares.LoadData{
 					"name":            middlewares.LoadString(true),
@@ -44,9 +47,24 @@ func registerPackCreate(e *echo.Group, app core.App) error {
 			}),
 		},
 		Handler: func(c echo.Context) error {
-			aredl := demonlist.Aredl()
 			packData := c.Get("packData").(map[string]interface{})
-			err := demonlist.UpsertPack(app.Dao(), app, aredl, packData)
+			err := app.Dao().RunInTransaction(func(txDao *daos.Dao) error {
+				packRecord, err := demonlist.UpsertPack(txDao, app, listData, packData)
+				if err != nil {
+					return err
+				}
+				after, err := packSnapshot(txDao, listData, packRecord)
+				if err != nil {
+					return err
+				}
+				return audit.Log(txDao, c, audit.Entry{
+					List:        listData.Name,
+					Action:      "pack_created",
+					TargetTable: listData.Packs.PackTableName,
+					TargetId:    packRecord.Id,
+					After:       after,
+				})
+			})
 			c.Response().Header().Set("Cache-Control", "no-store")
 			return err
 		},
@@ -1,11 +1,14 @@
 package aredl
 
 import (
+	"AREDL/audit"
 	"AREDL/demonlist"
 	"AREDL/middlewares"
+	"AREDL/util"
 	"github.com/labstack/echo/v5"
 	"github.com/pocketbase/pocketbase/apis"
 	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
 	"net/http"
 )
 
@@ -23,21 +26,41 @@ import (
 //	@Failure		400	{object}	util.ErrorResponse
 //	@Failure		403	{object}	util.ErrorResponse
 //	@Router			/aredl/packs/{id} [delete]
-func registerPackDelete(e *echo.Group, app core.App) error {
+func registerPackDelete(e *echo.Group, app core.App, listData demonlist.ListData) error {
 	_, err := e.AddRoute(echo.Route{
 		Method: http.MethodDelete,
 		Path:   "/packs/:id",
 		Middlewares: []echo.MiddlewareFunc{
 			apis.ActivityLogger(app),
+			middlewares.RateLimit(app, middlewares.RateLimitDefault),
 			middlewares.CheckBanned(),
-			middlewares.RequirePermissionGroup(app, "aredl", "manage_packs"),
+			middlewares.RequirePermissionGroup(app, listData.Name, "manage_packs"),
 			middlewares.LoadParam(middlewares.LoadData{
 				"id": middlewares.LoadString(true),
 			}),
 		},
 		Handler: func(c echo.Context) error {
-			aredl := demonlist.Aredl()
-			err := demonlist.DeletePack(app.Dao(), aredl, c.Get("id").(string))
+			err := app.Dao().RunInTransaction(func(txDao *daos.Dao) error {
+				packRecord, err := txDao.FindRecordById(listData.Packs.PackTableName, c.Get("id").(string))
+				if err != nil {
+					return util.NewErrorResponse(err, "Could not find pack")
+				}
+				before, err := packSnapshot(txDao, listData, packRecord)
+				if err != nil {
+					return err
+				}
+				err = demonlist.DeletePack(txDao, listData, packRecord.Id)
+				if err != nil {
+					return err
+				}
+				return audit.Log(txDao, c, audit.Entry{
+					List:        listData.Name,
+					Action:      "pack_deleted",
+					TargetTable: listData.Packs.PackTableName,
+					TargetId:    packRecord.Id,
+					Before:      before,
+				})
+			})
 			c.Response().Header().Set("Cache-Control", "no-store")
 			return err
 		},
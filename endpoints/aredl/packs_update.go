@@ -1,11 +1,16 @@
 package aredl
 
 import (
+	"AREDL/audit"
 	"AREDL/demonlist"
 	"AREDL/middlewares"
+	"AREDL/util"
 	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase/apis"
 	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/models"
 	"net/http"
 )
 
@@ -27,14 +32,15 @@ import (
 //	@Failure		400	{object}	util.ErrorResponse
 //	@Failure		403	{object}	util.ErrorResponse
 //	@Router			/aredl/packs/{id} [patch]
-func registerPackUpdate(e *echo.Group, app core.App) error {
+func registerPackUpdate(e *echo.Group, app core.App, listData demonlist.ListData) error {
 	_, err := e.AddRoute(echo.Route{
 		Method: http.MethodPatch,
 		Path:   "/packs/:id",
 		Middlewares: []echo.MiddlewareFunc{
 			apis.ActivityLogger(app),
+			middlewares.RateLimit(app, middlewares.RateLimitDefault),
 			middlewares.CheckBanned(),
-			middlewares.RequirePermissionGroup(app, "aredl", "manage_packs"),
+			middlewares.RequirePermissionGroup(app, listData.Name, "manage_packs"),
 			middlewares.LoadParam(middlewares.LoadData{
 				"packData": middlewares.LoadMap("", middlewares.LoadData{
 					"id":              middlewares.LoadString(true),
@@ -46,12 +52,51 @@ func registerPackUpdate(e *echo.Group, app core.App) error {
 			}),
 		},
 		Handler: func(c echo.Context) error {
-			aredl := demonlist.Aredl()
 			packData := c.Get("packData").(map[string]interface{})
-			err := demonlist.UpsertPack(app.Dao(), app, aredl, packData)
+			err := app.Dao().RunInTransaction(func(txDao *daos.Dao) error {
+				packRecord, err := txDao.FindRecordById(listData.Packs.PackTableName, packData["id"].(string))
+				if err != nil {
+					return util.NewErrorResponse(err, "Failed to fetch pack")
+				}
+				before, err := packSnapshot(txDao, listData, packRecord)
+				if err != nil {
+					return err
+				}
+				packRecord, err = demonlist.UpsertPack(txDao, app, listData, packData)
+				if err != nil {
+					return err
+				}
+				after, err := packSnapshot(txDao, listData, packRecord)
+				if err != nil {
+					return err
+				}
+				return audit.Log(txDao, c, audit.Entry{
+					List:        listData.Name,
+					Action:      "pack_updated",
+					TargetTable: listData.Packs.PackTableName,
+					TargetId:    packRecord.Id,
+					Before:      before,
+					After:       after,
+				})
+			})
 			c.Response().Header().Set("Cache-Control", "no-store")
 			return err
 		},
 	})
 	return err
 }
+
+// packSnapshot exports the pack together with the ids of its levels for the audit log
+func packSnapshot(dao *daos.Dao, listData demonlist.ListData, packRecord *models.Record) (map[string]any, error) {
+	var levels []string
+	err := dao.DB().Select("level").
+		From(listData.Packs.PackLevelTableName).
+		Where(dbx.HashExp{"pack": packRecord.Id}).
+		Column(&levels)
+	if err != nil {
+		return nil, util.NewErrorResponse(err, "Failed to fetch pack levels")
+	}
+	snapshot := audit.Snapshot(packRecord)
+	snapshot["levels"] = levels
+	return snapshot, nil
+}
@@ -0,0 +1,97 @@
+package aredl
+
+import (
+	"AREDL/audit"
+	"AREDL/demonlist"
+	"AREDL/middlewares"
+	"AREDL/names"
+	"AREDL/util"
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/models"
+	"net/http"
+)
+
+// registerPointFormulaApplyEndpoint godoc
+//
+//	@Summary		Apply a point formula
+//	@Description	Sets the point formula of the list, adds it to the formula history and recalculates all points.
+//	@Description	Functions: sqrt, pow, exp, log (base 10 or log(value, base)), ln, min, max, floor, ceil, clamp(value, min, max), if(condition, then, else).
+//	@Description	Variables: level_count (non-legacy levels minus one), legacy_count, total_count and in the formula also x (position) and enjoyment (of the level at position x).
+//	@Description	Requires user permission: aredl.manage_point_formula
+//	@Security		ApiKeyAuth
+//	@Tags			aredl
+//	@Param			formula				query	string	true	"formula that calculates the points of position x"
+//	@Param			precalc				query	string	false	"comma separated list of name=expression that are calculated before the formula"
+//	@Param			legacy_formula		query	string	false	"formula that calculates the points of legacy position x, only used by lists with the formula legacy policy"
+//	@Param			progress_formula	query	string	false	"formula that calculates the points of progress records, can also use points, percentage and percent_to_qualify"
+//	@Schemes		http https
+//	@Produce		json
+//	@Success		200
+//	@Failure		400	{object}	util.ErrorResponse
+//	@Failure		403	{object}	util.ErrorResponse
+//	@Router			/aredl/point-formula [post]
+func registerPointFormulaApplyEndpoint(e *echo.Group, app core.App, listData demonlist.ListData) error {
+	_, err := e.AddRoute(echo.Route{
+		Method: http.MethodPost,
+		Path:   "/point-formula",
+		Middlewares: []echo.MiddlewareFunc{
+			apis.ActivityLogger(app),
+			middlewares.RateLimit(app, middlewares.RateLimitDefault),
+			middlewares.CheckBanned(),
+			middlewares.RequirePermissionGroup(app, listData.Name, "manage_point_formula"),
+			middlewares.LoadParam(middlewares.LoadData{
+				"formula":          middlewares.LoadString(true),
+				"precalc":          middlewares.AddDefault("", middlewares.LoadString(false)),
+				"legacy_formula":   middlewares.AddDefault("", middlewares.LoadString(false)),
+				"progress_formula": middlewares.AddDefault("", middlewares.LoadString(false)),
+			}),
+		},
+		Handler: func(c echo.Context) error {
+			userRecord, _ := c.Get(apis.ContextAuthRecordKey).(*models.Record)
+			if userRecord == nil {
+				return util.NewErrorResponse(nil, "User not found")
+			}
+			err := auditPointFormula(app, c, listData, "point_formula_applied", func(txDao *daos.Dao) error {
+				return demonlist.ApplyPointFormula(txDao, app, listData, userRecord.Id, demonlist.PointFormulaData{
+					Formula:         c.Get("formula").(string),
+					Precalc:         c.Get("precalc").(string),
+					LegacyFormula:   c.Get("legacy_formula").(string),
+					ProgressFormula: c.Get("progress_formula").(string),
+				})
+			})
+			c.Response().Header().Set("Cache-Control", "no-store")
+			return err
+		},
+	})
+	return err
+}
+
+// auditPointFormula runs the formula change inside a transaction and adds the old and new formula to the audit log
+func auditPointFormula(app core.App, c echo.Context, listData demonlist.ListData, action string, change func(txDao *daos.Dao) error) error {
+	return app.Dao().RunInTransaction(func(txDao *daos.Dao) error {
+		formulaRecord, err := txDao.FindFirstRecordByData(names.TablePointFormular, "list", listData.Name)
+		if err != nil {
+			return util.NewErrorResponse(err, "Failed to load formula data")
+		}
+		before := audit.Snapshot(formulaRecord)
+		err = change(txDao)
+		if err != nil {
+			return err
+		}
+		formulaRecord, err = txDao.FindRecordById(names.TablePointFormular, formulaRecord.Id)
+		if err != nil {
+			return util.NewErrorResponse(err, "Failed to load formula data")
+		}
+		return audit.Log(txDao, c, audit.Entry{
+			List:        listData.Name,
+			Action:      action,
+			TargetTable: names.TablePointFormular,
+			TargetId:    formulaRecord.Id,
+			Before:      before,
+			After:       audit.Snapshot(formulaRecord),
+		})
+	})
+}
@@ -0,0 +1,77 @@
+package aredl
+
+import (
+	"AREDL/demonlist"
+	"AREDL/middlewares"
+	"AREDL/names"
+	"AREDL/util"
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/tools/types"
+	"net/http"
+)
+
+type PointFormulaVersion struct {
+	Version         int            `db:"version" json:"version"`
+	Formula         string         `db:"formula" json:"formula"`
+	Precalc         string         `db:"precalc" json:"precalc"`
+	LegacyFormula   string         `db:"legacy_formula" json:"legacy_formula,omitempty"`
+	ProgressFormula string         `db:"progress_formula" json:"progress_formula,omitempty"`
+	Created         types.DateTime `db:"created" json:"timestamp"`
+	AppliedBy       *struct {
+		Id         string `db:"id" json:"id,omitempty"`
+		GlobalName string `db:"global_name" json:"global_name,omitempty"`
+	} `db:"applied_by" json:"applied_by,omitempty" extend:"applied_by,users,id"`
+	// AppliedByAdmin is the id of the admin that changed the formula in the admin dashboard
+	AppliedByAdmin string `db:"applied_by_admin" json:"applied_by_admin,omitempty"`
+}
+
+// registerPointFormulaHistoryEndpoint godoc
+//
+//	@Summary		Point formula history
+//	@Description	Lists every point formula that has been applied to the list, newest version first.
+//	@Description	The first version is the formula that was in effect before the formula was changed for the first time, it has no applier
+//	@Description	Requires user permission: aredl.manage_point_formula
+//	@Security		ApiKeyAuth
+//	@Tags			aredl
+//	@Schemes		http https
+//	@Produce		json
+//	@Success		200	{object}	[]PointFormulaVersion
+//	@Failure		400	{object}	util.ErrorResponse
+//	@Failure		403	{object}	util.ErrorResponse
+//	@Router			/aredl/point-formula/history [get]
+func registerPointFormulaHistoryEndpoint(e *echo.Group, app core.App, listData demonlist.ListData) error {
+	_, err := e.AddRoute(echo.Route{
+		Method: http.MethodGet,
+		Path:   "/point-formula/history",
+		Middlewares: []echo.MiddlewareFunc{
+			apis.ActivityLogger(app),
+			middlewares.RateLimit(app, middlewares.RateLimitDefault),
+			middlewares.CheckBanned(),
+			middlewares.RequirePermissionGroup(app, listData.Name, "manage_point_formula"),
+		},
+		Handler: func(c echo.Context) error {
+			err := app.Dao().RunInTransaction(func(txDao *daos.Dao) error {
+				var result []PointFormulaVersion
+				tables := map[string]string{
+					"base":  names.TablePointFormulaHistory,
+					"users": names.TableUsers,
+				}
+				err := util.LoadFromDb(txDao.DB(), &result, tables, func(query *dbx.SelectQuery, prefixResolver util.PrefixResolver) {
+					query.Where(dbx.HashExp{prefixResolver("list"): listData.Name})
+					query.OrderBy(prefixResolver("version") + " DESC")
+				})
+				if err != nil {
+					return util.NewErrorResponse(err, "Failed to load formula history")
+				}
+				c.Response().Header().Set("Cache-Control", "no-store")
+				return c.JSON(http.StatusOK, result)
+			})
+			return err
+		},
+	})
+	return err
+}
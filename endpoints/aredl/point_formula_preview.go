@@ -0,0 +1,70 @@
+package aredl
+
+import (
+	"AREDL/demonlist"
+	"AREDL/middlewares"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"net/http"
+)
+
+// registerPointFormulaPreviewEndpoint godoc
+//
+//	@Summary		Preview a point formula
+//	@Description	Calculates the points the given formula would result in from the current levels and records without saving anything.
+//	@Description	Returns the old and new points of every position, the pack point changes and the rank changes of the top leaderboard players.
+//	@Description	Functions: sqrt, pow, exp, log (base 10 or log(value, base)), ln, min, max, floor, ceil, clamp(value, min, max), if(condition, then, else).
+//	@Description	Variables: level_count (non-legacy levels minus one), legacy_count, total_count and in the formula also x (position) and enjoyment (of the level at position x).
+//	@Description	Requires user permission: aredl.manage_point_formula
+//	@Security		ApiKeyAuth
+//	@Tags			aredl
+//	@Param			formula				query	string	true	"formula that calculates the points of position x"
+//	@Param			precalc				query	string	false	"comma separated list of name=expression that are calculated before the formula"
+//	@Param			legacy_formula		query	string	false	"formula that calculates the points of legacy position x, only used by lists with the formula legacy policy"
+//	@Param			progress_formula	query	string	false	"formula that calculates the points of progress records, can also use points, percentage and percent_to_qualify"
+//	@Param			leaderboard_top		query	int		false	"number of top leaderboard players to compare"	default(50)	minimum(1)	maximum(500)
+//	@Schemes		http https
+//	@Produce		json
+//	@Success		200	{object}	demonlist.PointFormulaPreview
+//	@Failure		400	{object}	util.ErrorResponse
+//	@Failure		403	{object}	util.ErrorResponse
+//	@Router			/aredl/point-formula/preview [post]
+func registerPointFormulaPreviewEndpoint(e *echo.Group, app core.App, listData demonlist.ListData) error {
+	_, err := e.AddRoute(echo.Route{
+		Method: http.MethodPost,
+		Path:   "/point-formula/preview",
+		Middlewares: []echo.MiddlewareFunc{
+			apis.ActivityLogger(app),
+			middlewares.RateLimit(app, middlewares.RateLimitDefault),
+			middlewares.CheckBanned(),
+			middlewares.RequirePermissionGroup(app, listData.Name, "manage_point_formula"),
+			middlewares.LoadParam(middlewares.LoadData{
+				"formula":          middlewares.LoadString(true),
+				"precalc":          middlewares.AddDefault("", middlewares.LoadString(false)),
+				"legacy_formula":   middlewares.AddDefault("", middlewares.LoadString(false)),
+				"progress_formula": middlewares.AddDefault("", middlewares.LoadString(false)),
+				"leaderboard_top":  middlewares.AddDefault(50, middlewares.LoadInt(false, validation.Min(1), validation.Max(500))),
+			}),
+		},
+		Handler: func(c echo.Context) error {
+			preview, err := demonlist.PreviewPointFormula(
+				app.Dao(),
+				listData,
+				demonlist.PointFormulaData{
+					Formula:         c.Get("formula").(string),
+					Precalc:         c.Get("precalc").(string),
+					LegacyFormula:   c.Get("legacy_formula").(string),
+					ProgressFormula: c.Get("progress_formula").(string),
+				},
+				c.Get("leaderboard_top").(int))
+			if err != nil {
+				return err
+			}
+			c.Response().Header().Set("Cache-Control", "no-store")
+			return c.JSON(http.StatusOK, preview)
+		},
+	})
+	return err
+}
@@ -0,0 +1,56 @@
+package aredl
+
+import (
+	"AREDL/demonlist"
+	"AREDL/middlewares"
+	"AREDL/util"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/models"
+	"net/http"
+)
+
+// registerPointFormulaRollbackEndpoint godoc
+//
+//	@Summary		Roll back the point formula
+//	@Description	Reapplies the formula of an earlier version and recalculates all points. The rollback is added to the history as a new version.
+//	@Description	Requires user permission: aredl.manage_point_formula
+//	@Security		ApiKeyAuth
+//	@Tags			aredl
+//	@Param			version	path	int	true	"version of the formula to reapply"
+//	@Schemes		http https
+//	@Produce		json
+//	@Success		200
+//	@Failure		400	{object}	util.ErrorResponse
+//	@Failure		403	{object}	util.ErrorResponse
+//	@Router			/aredl/point-formula/history/{version}/rollback [post]
+func registerPointFormulaRollbackEndpoint(e *echo.Group, app core.App, listData demonlist.ListData) error {
+	_, err := e.AddRoute(echo.Route{
+		Method: http.MethodPost,
+		Path:   "/point-formula/history/:version/rollback",
+		Middlewares: []echo.MiddlewareFunc{
+			apis.ActivityLogger(app),
+			middlewares.RateLimit(app, middlewares.RateLimitDefault),
+			middlewares.CheckBanned(),
+			middlewares.RequirePermissionGroup(app, listData.Name, "manage_point_formula"),
+			middlewares.LoadParam(middlewares.LoadData{
+				"version": middlewares.LoadInt(true, validation.Min(1)),
+			}),
+		},
+		Handler: func(c echo.Context) error {
+			userRecord, _ := c.Get(apis.ContextAuthRecordKey).(*models.Record)
+			if userRecord == nil {
+				return util.NewErrorResponse(nil, "User not found")
+			}
+			err := auditPointFormula(app, c, listData, "point_formula_rolled_back", func(txDao *daos.Dao) error {
+				return demonlist.RollbackPointFormula(txDao, app, listData, userRecord.Id, c.Get("version").(int))
+			})
+			c.Response().Header().Set("Cache-Control", "no-store")
+			return err
+		},
+	})
+	return err
+}
@@ -42,9 +42,11 @@ type User struct {
 		Points float64 `db:"points" json:"points"`
 	} `json:"packs,omitempty"`
 	Records []struct {
-		VideoUrl       string `db:"video_url" json:"video_url,omitempty"`
-		Mobile         bool   `db:"mobile" json:"mobile,omitempty"`
-		PlacementOrder int    `db:"placement_order" json:"placement_order"`
+		VideoUrl       string  `db:"video_url" json:"video_url,omitempty"`
+		Mobile         bool    `db:"mobile" json:"mobile,omitempty"`
+		PlacementOrder int     `db:"placement_order" json:"placement_order"`
+		Percentage     int     `db:"percentage" json:"percentage,omitempty"`
+		Points         float64 `db:"points" json:"points,omitempty"`
 		Level          struct {
 			Id        string  `db:"id" json:"id,omitempty"`
 			Position  int     `db:"position" json:"position,omitempty"`
@@ -90,19 +92,19 @@ type User struct {
 //	@Success		200	{object}	User
 //	@Failure		400	{object}	util.ErrorResponse
 //	@Router			/aredl/profiles/{id} [get]
-func registerUserEndpoint(e *echo.Group, app core.App) error {
+func registerUserEndpoint(e *echo.Group, app core.App, listData demonlist.ListData) error {
 	_, err := e.AddRoute(echo.Route{
 		Method: http.MethodGet,
 		Path:   "/profiles/:id",
 		Middlewares: []echo.MiddlewareFunc{
 			apis.ActivityLogger(app),
+			middlewares.RateLimit(app, middlewares.RateLimitExpensive),
 			middlewares.LoadParam(middlewares.LoadData{
 				"id":            middlewares.LoadString(true),
 				"is_discord_id": middlewares.AddDefault(false, middlewares.LoadBool(false)),
 			}),
 		},
 		Handler: func(c echo.Context) error {
-			aredl := demonlist.Aredl()
 			userId := c.Get("id").(string)
 			err := app.Dao().RunInTransaction(func(txDao *daos.Dao) error {
 				var user User
@@ -119,17 +121,17 @@ func registerUserEndpoint(e *echo.Group, app core.App) error {
 				if err != nil {
 					return util.NewErrorResponse(err, "Failed to load user data")
 				}
-				tableNames["base"] = aredl.Packs.PackTableName
+				tableNames["base"] = listData.Packs.PackTableName
 				err = util.LoadFromDb(txDao.DB(), &user.CompletedPacks, tableNames, func(query *dbx.SelectQuery, prefixResolver util.PrefixResolver) {
-					query.InnerJoin(demonlist.Aredl().Packs.CompletedPacksTableName+" cp", dbx.NewExp(prefixResolver("id")+" = cp.pack"))
+					query.InnerJoin(listData.Packs.CompletedPacksTableName+" cp", dbx.NewExp(prefixResolver("id")+" = cp.pack"))
 					query.Where(dbx.HashExp{"cp.user": user.Id})
 					query.OrderBy(prefixResolver("placement_order"))
 				})
 				if err != nil {
 					return util.NewErrorResponse(err, "Failed to load user packs")
 				}
-				tableNames["base"] = aredl.RecordsTableName
-				tableNames["levels"] = aredl.LevelTableName
+				tableNames["base"] = listData.RecordsTableName
+				tableNames["levels"] = listData.LevelTableName
 				err = util.LoadFromDb(txDao.DB(), &user.Records, tableNames, func(query *dbx.SelectQuery, prefixResolver util.PrefixResolver) {
 					query.Where(dbx.HashExp{prefixResolver("submitted_by"): user.Id})
 					query.OrderBy(prefixResolver("level.position"))
@@ -137,8 +139,8 @@ func registerUserEndpoint(e *echo.Group, app core.App) error {
 				if err != nil {
 					return util.NewErrorResponse(err, "Failed to load user levels")
 				}
-				tableNames["base"] = aredl.LevelTableName
-				tableNames["creators"] = aredl.CreatorTableName
+				tableNames["base"] = listData.LevelTableName
+				tableNames["creators"] = listData.CreatorTableName
 				err = util.LoadFromDb(txDao.DB(), &user.CreatedLevels, tableNames, func(query *dbx.SelectQuery, prefixResolver util.PrefixResolver) {
 					query.Where(dbx.HashExp{prefixResolver("creators.creator"): userId})
 					query.OrderBy(prefixResolver("position"))
@@ -153,7 +155,7 @@ func registerUserEndpoint(e *echo.Group, app core.App) error {
 				if err != nil {
 					return util.NewErrorResponse(err, "Failed to load published levels")
 				}
-				tableNames["base"] = aredl.LeaderboardTableName
+				tableNames["base"] = listData.LeaderboardTableName
 				err = util.LoadFromDb(txDao.DB(), &user.Rank, tableNames, func(query *dbx.SelectQuery, prefixResolver util.PrefixResolver) {
 					query.Where(dbx.HashExp{prefixResolver("user"): user.Id})
 				})
@@ -165,7 +167,10 @@ func registerUserEndpoint(e *echo.Group, app core.App) error {
 					Role string `db:"role"`
 				}
 				var roleData []RoleData
-				err = app.Dao().DB().Select("role").From(names.TableRoles).Where(dbx.HashExp{"user": userId}).All(&roleData)
+				err = app.Dao().DB().Select("role").From(names.TableRoles).
+					Where(dbx.HashExp{"user": userId}).
+					AndWhere(middlewares.ActiveRoleGrantExp("starts", "expires", types.NowDateTime())).
+					All(&roleData)
 				if err != nil {
 					return util.NewErrorResponse(err, "Failed to load roles")
 				}
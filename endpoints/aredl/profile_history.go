@@ -0,0 +1,72 @@
+package aredl
+
+import (
+	"AREDL/demonlist"
+	"AREDL/middlewares"
+	"AREDL/names"
+	"AREDL/util"
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/tools/types"
+	"net/http"
+)
+
+type RankHistoryEntry struct {
+	Created types.DateTime `db:"created" json:"timestamp"`
+	Rank    int            `db:"rank" json:"rank"`
+	Points  float64        `db:"points" json:"points"`
+}
+
+// registerUserHistoryEndpoint godoc
+//
+//	@Summary		Rank history of a user
+//	@Description	Lists the rank and points of a user over time, oldest first. A new entry is added by the daily snapshot whenever the rank or points of the user changed since the previous one.
+//	@Tags			aredl
+//	@Param			id				path	string	true	"user id"
+//	@Param			is_discord_id	query	bool	false	"if the provided id is a discord id"
+//	@Schemes		http https
+//	@Produce		json
+//	@Success		200	{object}	[]RankHistoryEntry
+//	@Failure		400	{object}	util.ErrorResponse
+//	@Router			/aredl/profiles/{id}/history [get]
+func registerUserHistoryEndpoint(e *echo.Group, app core.App, listData demonlist.ListData) error {
+	_, err := e.AddRoute(echo.Route{
+		Method: http.MethodGet,
+		Path:   "/profiles/:id/history",
+		Middlewares: []echo.MiddlewareFunc{
+			apis.ActivityLogger(app),
+			middlewares.RateLimit(app, middlewares.RateLimitExpensive),
+			middlewares.LoadParam(middlewares.LoadData{
+				"id":            middlewares.LoadString(true),
+				"is_discord_id": middlewares.AddDefault(false, middlewares.LoadBool(false)),
+			}),
+		},
+		Handler: func(c echo.Context) error {
+			userId := c.Get("id").(string)
+			err := app.Dao().RunInTransaction(func(txDao *daos.Dao) error {
+				if c.Get("is_discord_id").(bool) {
+					err := txDao.DB().Select("id").From(names.TableUsers).Where(dbx.HashExp{"discord_id": userId}).Row(&userId)
+					if err != nil {
+						return util.NewErrorResponse(err, "User not found")
+					}
+				}
+				result := []RankHistoryEntry{}
+				err := txDao.DB().Select("created", "rank", "points").
+					From(listData.LeaderboardHistoryTableName).
+					Where(dbx.HashExp{"user": userId}).
+					OrderBy("created").
+					All(&result)
+				if err != nil {
+					return util.NewErrorResponse(err, "Failed to load rank history")
+				}
+				c.Response().Header().Set("Cache-Control", "public, max-age=1800")
+				return c.JSON(http.StatusOK, result)
+			})
+			return err
+		},
+	})
+	return err
+}
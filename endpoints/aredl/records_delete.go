@@ -0,0 +1,77 @@
+package aredl
+
+import (
+	"AREDL/audit"
+	"AREDL/demonlist"
+	"AREDL/middlewares"
+	"AREDL/util"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/models"
+	"net/http"
+)
+
+// registerRecordDeleteEndpoint godoc
+//
+//	@Summary		Remove AREDL record
+//	@Description	Removes a record. The records placed after it on the same level move up and the leaderboard and completed packs of the player get recalculated.
+//	@Description	The player can see the reason in their removed records.
+//	@Description	Requires user permission: aredl.manage_records
+//	@Security		ApiKeyAuth
+//	@Tags			aredl
+//	@Param			id		path	string	true	"internal record id"
+//	@Param			reason	query	string	true	"reason the record is removed for, shown to the player"
+//	@Schemes		http https
+//	@Produce		json
+//	@Success		200
+//	@Failure		400	{object}	util.ErrorResponse
+//	@Failure		403	{object}	util.ErrorResponse
+//	@Router			/aredl/records/{id} [delete]
+func registerRecordDeleteEndpoint(e *echo.Group, app core.App, listData demonlist.ListData) error {
+	_, err := e.AddRoute(echo.Route{
+		Method: http.MethodDelete,
+		Path:   "/records/:id",
+		Middlewares: []echo.MiddlewareFunc{
+			apis.ActivityLogger(app),
+			middlewares.RateLimit(app, middlewares.RateLimitDefault),
+			middlewares.CheckBanned(),
+			middlewares.RequirePermissionGroup(app, listData.Name, "manage_records"),
+			middlewares.LoadParam(middlewares.LoadData{
+				"id":     middlewares.LoadString(true),
+				"reason": middlewares.LoadString(true, validation.Length(1, 1000)),
+			}),
+		},
+		Handler: func(c echo.Context) error {
+			userRecord, _ := c.Get(apis.ContextAuthRecordKey).(*models.Record)
+			if userRecord == nil {
+				return util.NewErrorResponse(nil, "User not found")
+			}
+			err := app.Dao().RunInTransaction(func(txDao *daos.Dao) error {
+				record, err := txDao.FindRecordById(listData.RecordsTableName, c.Get("id").(string))
+				if err != nil {
+					return util.NewErrorResponse(err, "Record not found")
+				}
+				before := audit.Snapshot(record)
+				reason := c.Get("reason").(string)
+				err = demonlist.RemoveRecord(txDao, listData, record, userRecord.Id, reason)
+				if err != nil {
+					return err
+				}
+				return audit.Log(txDao, c, audit.Entry{
+					List:        listData.Name,
+					Action:      "record_removed",
+					TargetTable: listData.RecordsTableName,
+					TargetId:    record.Id,
+					Before:      before,
+					After:       map[string]any{"reason": reason},
+				})
+			})
+			c.Response().Header().Set("Cache-Control", "no-store")
+			return err
+		},
+	})
+	return err
+}
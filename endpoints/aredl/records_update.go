@@ -0,0 +1,94 @@
+package aredl
+
+import (
+	"AREDL/audit"
+	"AREDL/demonlist"
+	"AREDL/middlewares"
+	"AREDL/util"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/models"
+	"net/http"
+)
+
+// registerRecordUpdateEndpoint godoc
+//
+//	@Summary		Update AREDL record
+//	@Description	Edits a record. Setting a percentage below 100 demotes a completion to a progress record. The leaderboard and completed packs of the player get recalculated.
+//	@Description	Requires user permission: aredl.manage_records
+//	@Security		ApiKeyAuth
+//	@Tags			aredl
+//	@Param			id			path	string	true	"internal record id"
+//	@Param			reason		query	string	true	"reason for the change"
+//	@Param			video_url	query	string	false	"display video url"	format(url)
+//	@Param			mobile		query	bool	false	"whether the record was done on mobile"
+//	@Param			percentage	query	int		false	"reached percentage"	minimum(1)	maximum(100)
+//	@Param			ldm_id		query	int		false	"gd id of used ldm"
+//	@Param			raw_footage	query	string	false	"raw footage"	format(url)
+//	@Schemes		http https
+//	@Produce		json
+//	@Success		200
+//	@Failure		400	{object}	util.ErrorResponse
+//	@Failure		403	{object}	util.ErrorResponse
+//	@Router			/aredl/records/{id} [patch]
+func registerRecordUpdateEndpoint(e *echo.Group, app core.App, listData demonlist.ListData) error {
+	_, err := e.AddRoute(echo.Route{
+		Method: http.MethodPatch,
+		Path:   "/records/:id",
+		Middlewares: []echo.MiddlewareFunc{
+			apis.ActivityLogger(app),
+			middlewares.RateLimit(app, middlewares.RateLimitDefault),
+			middlewares.CheckBanned(),
+			middlewares.RequirePermissionGroup(app, listData.Name, "manage_records"),
+			middlewares.LoadParam(middlewares.LoadData{
+				"id":     middlewares.LoadString(true),
+				"reason": middlewares.LoadString(true, validation.Length(1, 1000)),
+				"recordData": middlewares.LoadMap("", middlewares.LoadData{
+					"video_url":   middlewares.LoadString(false, is.URL),
+					"mobile":      middlewares.LoadBool(false),
+					"percentage":  middlewares.LoadInt(false, validation.Min(1), validation.Max(100)),
+					"ldm_id":      middlewares.LoadInt(false, validation.Min(1)),
+					"raw_footage": middlewares.LoadString(false, is.URL),
+				}),
+			}),
+		},
+		Handler: func(c echo.Context) error {
+			userRecord, _ := c.Get(apis.ContextAuthRecordKey).(*models.Record)
+			if userRecord == nil {
+				return util.NewErrorResponse(nil, "User not found")
+			}
+			recordData := c.Get("recordData").(map[string]interface{})
+			if len(recordData) == 0 {
+				return util.NewErrorResponse(nil, "Nothing to update")
+			}
+			err := app.Dao().RunInTransaction(func(txDao *daos.Dao) error {
+				record, err := txDao.FindRecordById(listData.RecordsTableName, c.Get("id").(string))
+				if err != nil {
+					return util.NewErrorResponse(err, "Record not found")
+				}
+				before := audit.Snapshot(record)
+				err = demonlist.UpdateRecord(txDao, app, listData, record, recordData)
+				if err != nil {
+					return err
+				}
+				after := audit.Snapshot(record)
+				after["reason"] = c.Get("reason").(string)
+				return audit.Log(txDao, c, audit.Entry{
+					List:        listData.Name,
+					Action:      "record_updated",
+					TargetTable: listData.RecordsTableName,
+					TargetId:    record.Id,
+					Before:      before,
+					After:       after,
+				})
+			})
+			c.Response().Header().Set("Cache-Control", "no-store")
+			return err
+		},
+	})
+	return err
+}
@@ -1,33 +1,72 @@
 package aredl
 
 import (
+	"AREDL/demonlist"
 	"AREDL/util"
+	"github.com/labstack/echo/v5"
 	"github.com/pocketbase/pocketbase/core"
 )
 
-// RegisterEndpoints registers all routes that are under pathPrefix
+type listEndpoint func(e *echo.Group, app core.App, listData demonlist.ListData) error
+
+// RegisterEndpoints registers all list routes for every registered list under /api/{list}.
+// The documentation uses aredl as the list, the routes of other lists are identical
 func RegisterEndpoints(app core.App) {
-	util.RegisterEndpoints(app, "/api/aredl",
-		registerListEndpoint,
-		registerLevelsEndpoint,
-		registerLevelEndpoint,
-		registerLevelHistoryEndpoint,
-		registerLeaderboardEndpoint,
-		registerUserEndpoint,
-		registerPackEndpoint,
-		registerNamesEndpoint,
-		registerMeSubmissionList,
-		registerSubmissionWithdrawEndpoint,
-		registerSubmissionEndpoint,
-		registerLevelPlaceEndpoint,
-		registerLevelUpdateEndpoint,
-		registerPackCreate,
-		registerPackDelete,
-		registerPackUpdate,
-		registerRecordList,
-		registerSubmissionList,
-		registerSubmissionAcceptEndpoint,
-		registerSubmissionRejectEndpoint,
-		registerUpdateListEndpoint,
-	)
+	for _, listData := range demonlist.Lists() {
+		util.RegisterEndpoints(app, "/api/"+listData.Name, forList(listData,
+			registerListEndpoint,
+			registerLevelsEndpoint,
+			registerLevelEndpoint,
+			registerLevelHistoryEndpoint,
+			registerChangelogEndpoint,
+			registerLeaderboardEndpoint,
+			registerCountryLeaderboardEndpoint,
+			registerUserEndpoint,
+			registerUserHistoryEndpoint,
+			registerPackEndpoint,
+			registerNamesEndpoint,
+			registerMeSubmissionList,
+			registerSubmissionWithdrawEndpoint,
+			registerMeSubmissionTimelineEndpoint,
+			registerMeSubmissionCommentsEndpoint,
+			registerMeSubmissionCommentAddEndpoint,
+			registerSubmissionEndpoint,
+			registerLevelPlaceEndpoint,
+			registerLevelUpdateEndpoint,
+			registerLevelVerificationEndpoint,
+			registerLevelDeleteEndpoint,
+			registerListUpdateApplyEndpoint,
+			registerPackCreate,
+			registerPackDelete,
+			registerPackUpdate,
+			registerRecordList,
+			registerRemovedRecordList,
+			registerRecordDeleteEndpoint,
+			registerRecordUpdateEndpoint,
+			registerSubmissionList,
+			registerSubmissionAcceptEndpoint,
+			registerSubmissionRejectEndpoint,
+			registerSubmissionClaimEndpoint,
+			registerSubmissionUnclaimEndpoint,
+			registerSubmissionStatusEndpoint,
+			registerSubmissionTimelineEndpoint,
+			registerSubmissionCommentsEndpoint,
+			registerSubmissionCommentAddEndpoint,
+			registerUpdateListEndpoint,
+			registerPointFormulaPreviewEndpoint,
+			registerPointFormulaHistoryEndpoint,
+			registerPointFormulaApplyEndpoint,
+			registerPointFormulaRollbackEndpoint,
+			registerEventStreamEndpoint,
+		)...)
+	}
+}
+
+// forList binds the given list to the endpoints so they can be registered like any other endpoint
+func forList(listData demonlist.ListData, endpoints ...listEndpoint) []func(e *echo.Group, app core.App) error {
+	return util.MapSlice(endpoints, func(endpoint listEndpoint) func(e *echo.Group, app core.App) error {
+		return func(e *echo.Group, app core.App) error {
+			return endpoint(e, app, listData)
+		}
+	})
 }
@@ -7,11 +7,13 @@ import (
 	"AREDL/util"
 	"net/http"
 
+	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/labstack/echo/v5"
 	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase/apis"
 	"github.com/pocketbase/pocketbase/core"
 	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/tools/list"
 	"github.com/pocketbase/pocketbase/tools/types"
 )
 
@@ -26,8 +28,9 @@ type Submission struct {
 	} `db:"level" json:"level,omitempty" extend:"level,levels,id"`
 	VideoUrl        string `db:"video_url" json:"video_url,omitempty"`
 	Mobile          bool   `db:"mobile" json:"mobile,omitempty"`
+	Percentage      int    `db:"percentage" json:"percentage,omitempty"`
 	LdmId           int    `db:"ldm_id" json:"ldm_id,omitempty"`
-	Rejected        bool   `db:"rejected" json:"rejected"`
+	Status          string `db:"status" json:"status"`
 	IsUpdate        bool   `db:"is_update" json:"is_update"`
 	RawFootage      string `db:"raw_footage" json:"raw_footage,omitempty"`
 	AdditionalNotes string `db:"additional_notes" json:"additional_notes"`
@@ -40,6 +43,13 @@ type Submission struct {
 		GlobalName string `db:"global_name" json:"global_name"`
 	} `db:"submitted_by" json:"submitted_by" extend:"submitted_by,users,id"`
 	Priority bool `db:"priority" json:"priority"`
+	// ClaimedBy is only set while the claim is active
+	ClaimedBy *struct {
+		Id         string `db:"id" json:"id"`
+		GlobalName string `db:"global_name" json:"global_name"`
+	} `db:"claimed_by" json:"claimed_by,omitempty" extend:"claimed_by,users,id"`
+	// ClaimExpires is only set while the claim is active
+	ClaimExpires *types.DateTime `db:"claim_expires" json:"claim_expires,omitempty"`
 }
 
 // registerSubmissionList godoc
@@ -48,7 +58,8 @@ type Submission struct {
 //	@Description	Lists submissions ordered by the time they have been updated last.
 //	@Description	Requires user permission: aredl.submission_review
 //	@Tags			aredl
-//	@Param			include_rejected	query	bool	false	"include rejected submissions" default(false)
+//	@Param			status		query	string	false	"JSON array of statuses to include, defaults to [\"pending\", \"under_review\"]"
+//	@Param			unclaimed	query	bool	false	"only include submissions that are not claimed by a reviewer"	default(false)
 //	@Security		ApiKeyAuth[authorization]
 //	@Schemes		http https
 //	@Produce		json
@@ -56,36 +67,48 @@ type Submission struct {
 //	@Failure		400	{object}	util.ErrorResponse
 //	@Failure		403	{object}	util.ErrorResponse
 //	@Router			/aredl/submissions [get]
-func registerSubmissionList(e *echo.Group, app core.App) error {
+func registerSubmissionList(e *echo.Group, app core.App, listData demonlist.ListData) error {
 	_, err := e.AddRoute(echo.Route{
 		Method: http.MethodGet,
 		Path:   "/submissions",
 		Middlewares: []echo.MiddlewareFunc{
 			apis.ActivityLogger(app),
+			middlewares.RateLimit(app, middlewares.RateLimitDefault),
 			middlewares.CheckBanned(),
-			middlewares.RequirePermissionGroup(app, "aredl", "submission_review"),
+			middlewares.RequirePermissionGroup(app, listData.Name, "submission_review"),
 			middlewares.LoadParam(middlewares.LoadData{
-				"include_rejected": middlewares.AddDefault(false, middlewares.LoadBool(false)),
+				"status":    middlewares.AddDefault(demonlist.SubmissionQueueStatuses, middlewares.LoadStringArray(false, validation.In(list.ToInterfaceSlice(demonlist.SubmissionStatuses)...))),
+				"unclaimed": middlewares.AddDefault(false, middlewares.LoadBool(false)),
 			}),
 		},
 		Handler: func(c echo.Context) error {
-			aredl := demonlist.Aredl()
+			now := types.NowDateTime()
 			err := app.Dao().RunInTransaction(func(txDao *daos.Dao) error {
 				var submissions []Submission
 				tables := map[string]string{
-					"base":   aredl.SubmissionsTableName,
-					"levels": aredl.LevelTableName,
+					"base":   listData.SubmissionsTableName,
+					"levels": listData.LevelTableName,
 					"users":  names.TableUsers,
 				}
 				err := util.LoadFromDb(app.Dao().DB(), &submissions, tables, func(query *dbx.SelectQuery, prefixResolver util.PrefixResolver) {
-					if !c.Get("include_rejected").(bool) {
-						query.Where(dbx.HashExp{"rejected": false})
+					query.Where(demonlist.SubmissionStatusExp(prefixResolver("status"), c.Get("status").([]string)))
+					if c.Get("unclaimed").(bool) {
+						query.AndWhere(dbx.Or(
+							dbx.HashExp{prefixResolver("claimed_by"): ""},
+							dbx.NewExp(prefixResolver("claim_expires")+" <= {:now}", dbx.Params{"now": now.String()})))
 					}
 					query.OrderBy(prefixResolver("updated"))
 				})
 				if err != nil {
 					return util.NewErrorResponse(err, "could not load submissions")
 				}
+				for i := range submissions {
+					submission := &submissions[i]
+					if submission.ClaimedBy == nil || submission.ClaimedBy.Id == "" || submission.ClaimExpires == nil || !submission.ClaimExpires.Time().After(now.Time()) {
+						submission.ClaimedBy = nil
+						submission.ClaimExpires = nil
+					}
+				}
 				c.Response().Header().Set("Cache-Control", "no-store")
 				return c.JSON(200, submissions)
 			})
@@ -1,9 +1,11 @@
 package aredl
 
 import (
+	"AREDL/audit"
 	"AREDL/demonlist"
 	"AREDL/middlewares"
 	"AREDL/util"
+	"AREDL/webhook"
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/go-ozzo/ozzo-validation/v4/is"
 	"github.com/labstack/echo/v5"
@@ -19,36 +21,43 @@ import (
 // registerSubmissionAcceptEndpoint godoc
 //
 //	@Summary		Accept AREDL submission.
+//	@Description	Fails if another reviewer holds an active claim on the submission.
+//	@Description	The submission is kept with the accepted status so the submitter can see its timeline. It is replaced once the level is submitted again.
 //	@Description	Requires user permission: aredl.submission_review
 //	@Security		ApiKeyAuth
 //	@Tags			aredl
 //	@Param			id			path	string	true	"internal submission id"
 //	@Param			video_url	query	string	false	"video url"	format(url)
 //	@Param			mobile		query	bool	false	"whether submisssion was one on mobile"
+//	@Param			percentage	query	int		false	"reached percentage"	minimum(1)	maximum(100)
 //	@Param			ldm_id		query	int		false	"gd id of used ldm"
 //	@Param			raw_footage	query	string	false	"raw footage"	format(url)
+//	@Param			comment		query	string	false	"comment for the submitter that is added to the timeline"
 //	@Schemes		http https
 //	@Produce		json
 //	@Success		200
 //	@Failure		400	{object}	util.ErrorResponse
 //	@Failure		403	{object}	util.ErrorResponse
 //	@Router			/aredl/submissions/{id}/accept [post]
-func registerSubmissionAcceptEndpoint(e *echo.Group, app core.App) error {
+func registerSubmissionAcceptEndpoint(e *echo.Group, app core.App, listData demonlist.ListData) error {
 	_, err := e.AddRoute(echo.Route{
 		Method: http.MethodPost,
 		Path:   "/submissions/:id/accept",
 		Middlewares: []echo.MiddlewareFunc{
 			apis.ActivityLogger(app),
+			middlewares.RateLimit(app, middlewares.RateLimitDefault),
 			middlewares.CheckBanned(),
-			middlewares.RequirePermissionGroup(app, "aredl", "submission_review"),
+			middlewares.RequirePermissionGroup(app, listData.Name, "submission_review"),
 			middlewares.LoadParam(middlewares.LoadData{
 				"submissionData": middlewares.LoadMap("", middlewares.LoadData{
 					"id":          middlewares.LoadString(true),
 					"video_url":   middlewares.LoadString(false, is.URL),
 					"mobile":      middlewares.LoadBool(false),
+					"percentage":  middlewares.LoadInt(false, validation.Min(1), validation.Max(100)),
 					"ldm_id":      middlewares.LoadInt(false, validation.Min(1)),
 					"raw_footage": middlewares.LoadString(false, is.URL),
 				}),
+				"comment": middlewares.AddDefault("", middlewares.LoadString(false)),
 			}),
 		},
 		Handler: func(c echo.Context) error {
@@ -56,20 +65,27 @@ func registerSubmissionAcceptEndpoint(e *echo.Group, app core.App) error {
 			if userRecord == nil {
 				return util.NewErrorResponse(nil, "User not found")
 			}
-			aredl := demonlist.Aredl()
 			submissionData := c.Get("submissionData").(map[string]interface{})
 			submissionData["reviewer"] = userRecord.Id
 			return app.Dao().RunInTransaction(func(txDao *daos.Dao) error {
-				submissionRecord, err := txDao.FindRecordById(aredl.SubmissionsTableName, submissionData["id"].(string))
+				submissionRecord, err := txDao.FindRecordById(listData.SubmissionsTableName, submissionData["id"].(string))
 				if err != nil {
 					return util.NewErrorResponse(err, "Failed to accept submission")
 				}
-				if submissionRecord.GetBool("rejected") {
-					return util.NewErrorResponse(nil, "Submission has already been rejected")
+				err = demonlist.ValidateSubmissionTransition(demonlist.SubmissionStatus(submissionRecord), demonlist.SubmissionAccepted)
+				if err != nil {
+					return util.NewErrorResponse(err, "Submission can't be accepted")
+				}
+				err = demonlist.CheckSubmissionClaim(submissionRecord, userRecord.Id)
+				if err != nil {
+					return err
 				}
+				before := audit.Snapshot(submissionRecord)
 				recordData := map[string]any{}
 				recordData["reviewer"] = userRecord.Id
-				keys := []string{"level", "video_url", "mobile", "ldm_id", "raw_footage", "created", "submitted_by"}
+				// progress points get recalculated when the leaderboard is updated
+				recordData["points"] = 0
+				keys := []string{"level", "video_url", "mobile", "ldm_id", "raw_footage", "created", "submitted_by", "percentage"}
 				for _, key := range keys {
 					if value, ok := submissionData[key]; ok {
 						recordData[key] = value
@@ -77,20 +93,20 @@ func registerSubmissionAcceptEndpoint(e *echo.Group, app core.App) error {
 						recordData[key] = submissionRecord.Get(key)
 					}
 				}
-				recordCollection, err := txDao.FindCollectionByNameOrId(aredl.RecordsTableName)
+				recordCollection, err := txDao.FindCollectionByNameOrId(listData.RecordsTableName)
 				if err != nil {
 					return util.NewErrorResponse(err, "Failed to load record collection")
 				}
 				record := models.NewRecord(recordCollection)
 				if submissionRecord.GetBool("is_update") {
-					records, err := txDao.FindRecordsByExpr(aredl.RecordsTableName, dbx.HashExp{"submitted_by": submissionRecord.GetString("submitted_by"), "level": submissionRecord.GetString("level")})
+					records, err := txDao.FindRecordsByExpr(listData.RecordsTableName, dbx.HashExp{"submitted_by": submissionRecord.GetString("submitted_by"), "level": submissionRecord.GetString("level")})
 					if err != nil || len(records) != 1 {
 						return util.NewErrorResponse(err, "Unable to find updated record")
 					}
 					record = records[0]
 				} else {
 					var maxRecordPlacement int
-					err = txDao.DB().Select("COALESCE(max(placement_order),0)").From(aredl.RecordsTableName).Where(dbx.HashExp{"level": submissionData["level"]}).Row(&maxRecordPlacement)
+					err = txDao.DB().Select("COALESCE(max(placement_order),0)").From(listData.RecordsTableName).Where(dbx.HashExp{"level": submissionData["level"]}).Row(&maxRecordPlacement)
 					if err != nil {
 						return util.NewErrorResponse(err, "Failed to query max placement pos")
 					}
@@ -106,12 +122,32 @@ func registerSubmissionAcceptEndpoint(e *echo.Group, app core.App) error {
 				if err != nil {
 					return util.NewErrorResponse(err, "Failed to save record")
 				}
-				err = txDao.DeleteRecord(submissionRecord)
+				// the submission is kept instead of deleted, accepted is a final status so it can't enter the queue again
+				submissionRecord.Set("reviewer", userRecord.Id)
+				demonlist.ClearSubmissionClaim(submissionRecord)
+				err = demonlist.SetSubmissionStatus(txDao, listData, submissionRecord, demonlist.SubmissionAccepted, userRecord.Id, c.Get("comment").(string))
+				if err != nil {
+					return err
+				}
+				err = audit.Log(txDao, c, audit.Entry{
+					List:        listData.Name,
+					Action:      "submission_accepted",
+					TargetTable: listData.SubmissionsTableName,
+					TargetId:    submissionRecord.Id,
+					Before:      before,
+					After:       audit.Snapshot(record),
+				})
+				if err != nil {
+					return err
+				}
+				err = webhook.Emit(txDao, listData.Name, webhook.EventRecordAccepted, map[string]any{
+					"record": record.PublicExport(),
+				})
 				if err != nil {
-					return util.NewErrorResponse(err, "Failed to delete submission")
+					return err
 				}
 				c.Response().Header().Set("Cache-Control", "no-store")
-				return demonlist.UpdateLeaderboardAndPacksForUser(txDao, aredl, submissionRecord.GetString("submitted_by"))
+				return demonlist.UpdateLeaderboardAndPacksForUser(txDao, listData, submissionRecord.GetString("submitted_by"))
 			})
 		},
 	})
@@ -0,0 +1,71 @@
+package aredl
+
+import (
+	"AREDL/audit"
+	"AREDL/demonlist"
+	"AREDL/middlewares"
+	"AREDL/util"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/models"
+	"net/http"
+	"time"
+)
+
+// registerSubmissionClaimEndpoint godoc
+//
+//	@Summary		Claim AREDL submission
+//	@Description	Marks the submission as being reviewed by the authenticated user. While the claim is active other reviewers cannot accept or reject the submission.
+//	@Description	Claiming an already claimed submission extends the claim. Claims of other reviewers can only be taken over once they expired.
+//	@Description	Requires user permission: aredl.submission_review
+//	@Security		ApiKeyAuth
+//	@Tags			aredl
+//	@Param			id			path	string	true	"internal submission id"
+//	@Param			duration	query	int		false	"minutes until the claim expires"	default(30)	minimum(1)	maximum(240)
+//	@Schemes		http https
+//	@Produce		json
+//	@Success		200
+//	@Failure		400	{object}	util.ErrorResponse
+//	@Failure		403	{object}	util.ErrorResponse
+//	@Router			/aredl/submissions/{id}/claim [post]
+func registerSubmissionClaimEndpoint(e *echo.Group, app core.App, listData demonlist.ListData) error {
+	_, err := e.AddRoute(echo.Route{
+		Method: http.MethodPost,
+		Path:   "/submissions/:id/claim",
+		Middlewares: []echo.MiddlewareFunc{
+			apis.ActivityLogger(app),
+			middlewares.RateLimit(app, middlewares.RateLimitDefault),
+			middlewares.CheckBanned(),
+			middlewares.RequirePermissionGroup(app, listData.Name, "submission_review"),
+			middlewares.LoadParam(middlewares.LoadData{
+				"id":       middlewares.LoadString(true),
+				"duration": middlewares.AddDefault(30, middlewares.LoadInt(false, validation.Min(1), validation.Max(240))),
+			}),
+		},
+		Handler: func(c echo.Context) error {
+			userRecord, _ := c.Get(apis.ContextAuthRecordKey).(*models.Record)
+			if userRecord == nil {
+				return util.NewErrorResponse(nil, "User not found")
+			}
+			err := app.Dao().RunInTransaction(func(txDao *daos.Dao) error {
+				submissionRecord, err := demonlist.ClaimSubmission(txDao, listData, c.Get("id").(string), userRecord.Id, time.Duration(c.Get("duration").(int))*time.Minute)
+				if err != nil {
+					return err
+				}
+				return audit.Log(txDao, c, audit.Entry{
+					List:        listData.Name,
+					Action:      "submission_claimed",
+					TargetTable: listData.SubmissionsTableName,
+					TargetId:    submissionRecord.Id,
+					After:       audit.Snapshot(submissionRecord),
+				})
+			})
+			c.Response().Header().Set("Cache-Control", "no-store")
+			return err
+		},
+	})
+	return err
+}
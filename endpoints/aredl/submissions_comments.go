@@ -0,0 +1,82 @@
+package aredl
+
+import (
+	"AREDL/demonlist"
+	"AREDL/middlewares"
+	"AREDL/names"
+	"AREDL/util"
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/tools/types"
+	"net/http"
+)
+
+type SubmissionComment struct {
+	Id      string         `db:"id" json:"id"`
+	Created types.DateTime `db:"created" json:"created"`
+	Author  struct {
+		Id         string `db:"id" json:"id"`
+		GlobalName string `db:"global_name" json:"global_name"`
+	} `db:"author" json:"author" extend:"author,users,id"`
+	FromReviewer bool   `db:"from_reviewer" json:"from_reviewer"`
+	Message      string `db:"message" json:"message"`
+}
+
+// loadSubmissionComments loads the comment thread of the submission ordered from oldest to newest
+func loadSubmissionComments(dao *daos.Dao, listData demonlist.ListData, submissionId string) ([]SubmissionComment, error) {
+	var comments []SubmissionComment
+	tables := map[string]string{
+		"base":  listData.SubmissionCommentsTableName,
+		"users": names.TableUsers,
+	}
+	err := util.LoadFromDb(dao.DB(), &comments, tables, func(query *dbx.SelectQuery, prefixResolver util.PrefixResolver) {
+		query.Where(dbx.HashExp{prefixResolver("submission"): submissionId})
+		query.OrderBy(prefixResolver("created"), prefixResolver("rowid"))
+	})
+	if err != nil {
+		return nil, util.NewErrorResponse(err, "Could not load comments")
+	}
+	return comments, nil
+}
+
+// registerSubmissionCommentsEndpoint godoc
+//
+//	@Summary		AREDL submission comments
+//	@Description	Lists the comment thread of a submission ordered from oldest to newest.
+//	@Description	Requires user permission: aredl.submission_review
+//	@Security		ApiKeyAuth
+//	@Tags			aredl
+//	@Param			id	path	string	true	"internal submission id"
+//	@Schemes		http https
+//	@Produce		json
+//	@Success		200	{object}	[]SubmissionComment
+//	@Failure		400	{object}	util.ErrorResponse
+//	@Failure		403	{object}	util.ErrorResponse
+//	@Router			/aredl/submissions/{id}/comments [get]
+func registerSubmissionCommentsEndpoint(e *echo.Group, app core.App, listData demonlist.ListData) error {
+	_, err := e.AddRoute(echo.Route{
+		Method: http.MethodGet,
+		Path:   "/submissions/:id/comments",
+		Middlewares: []echo.MiddlewareFunc{
+			apis.ActivityLogger(app),
+			middlewares.RateLimit(app, middlewares.RateLimitDefault),
+			middlewares.CheckBanned(),
+			middlewares.RequirePermissionGroup(app, listData.Name, "submission_review"),
+			middlewares.LoadParam(middlewares.LoadData{
+				"id": middlewares.LoadString(true),
+			}),
+		},
+		Handler: func(c echo.Context) error {
+			comments, err := loadSubmissionComments(app.Dao(), listData, c.Get("id").(string))
+			if err != nil {
+				return err
+			}
+			c.Response().Header().Set("Cache-Control", "no-store")
+			return c.JSON(200, comments)
+		},
+	})
+	return err
+}
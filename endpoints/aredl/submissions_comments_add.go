@@ -0,0 +1,82 @@
+package aredl
+
+import (
+	"AREDL/audit"
+	"AREDL/demonlist"
+	"AREDL/middlewares"
+	"AREDL/util"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/models"
+	"net/http"
+)
+
+// registerSubmissionCommentAddEndpoint godoc
+//
+//	@Summary		Comment on AREDL submission
+//	@Description	Adds a reviewer message to the comment thread of a submission.
+//	@Description	With needs_info the submission is moved out of the queue until the submitter replies. This fails if another reviewer holds an active claim on the submission.
+//	@Description	Requires user permission: aredl.submission_review
+//	@Security		ApiKeyAuth
+//	@Tags			aredl
+//	@Param			id			path	string	true	"internal submission id"
+//	@Param			message		query	string	true	"message, max 1000 characters"
+//	@Param			needs_info	query	bool	false	"whether the submitter has to reply before the submission is reviewed further"	default(false)
+//	@Schemes		http https
+//	@Produce		json
+//	@Success		200
+//	@Failure		400	{object}	util.ErrorResponse
+//	@Failure		403	{object}	util.ErrorResponse
+//	@Router			/aredl/submissions/{id}/comments [post]
+func registerSubmissionCommentAddEndpoint(e *echo.Group, app core.App, listData demonlist.ListData) error {
+	_, err := e.AddRoute(echo.Route{
+		Method: http.MethodPost,
+		Path:   "/submissions/:id/comments",
+		Middlewares: []echo.MiddlewareFunc{
+			apis.ActivityLogger(app),
+			middlewares.RateLimit(app, middlewares.RateLimitDefault),
+			middlewares.CheckBanned(),
+			middlewares.RequirePermissionGroup(app, listData.Name, "submission_review"),
+			middlewares.LoadParam(middlewares.LoadData{
+				"id":         middlewares.LoadString(true),
+				"message":    middlewares.LoadString(true, validation.Length(1, 1000)),
+				"needs_info": middlewares.AddDefault(false, middlewares.LoadBool(false)),
+			}),
+		},
+		Handler: func(c echo.Context) error {
+			userRecord, _ := c.Get(apis.ContextAuthRecordKey).(*models.Record)
+			if userRecord == nil {
+				return util.NewErrorResponse(nil, "User not found")
+			}
+			err := app.Dao().RunInTransaction(func(txDao *daos.Dao) error {
+				submissionRecord, err := txDao.FindRecordById(listData.SubmissionsTableName, c.Get("id").(string))
+				if err != nil {
+					return util.NewErrorResponse(err, "Could not load submission")
+				}
+				before := audit.Snapshot(submissionRecord)
+				fromStatus := demonlist.SubmissionStatus(submissionRecord)
+				_, err = demonlist.AddSubmissionComment(txDao, listData, submissionRecord, userRecord.Id, c.Get("message").(string), true, c.Get("needs_info").(bool))
+				if err != nil {
+					return err
+				}
+				if demonlist.SubmissionStatus(submissionRecord) == fromStatus {
+					return nil
+				}
+				return audit.Log(txDao, c, audit.Entry{
+					List:        listData.Name,
+					Action:      "submission_info_requested",
+					TargetTable: listData.SubmissionsTableName,
+					TargetId:    submissionRecord.Id,
+					Before:      before,
+					After:       audit.Snapshot(submissionRecord),
+				})
+			})
+			c.Response().Header().Set("Cache-Control", "no-store")
+			return err
+		},
+	})
+	return err
+}
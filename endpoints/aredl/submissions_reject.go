@@ -1,9 +1,11 @@
 package aredl
 
 import (
+	"AREDL/audit"
 	"AREDL/demonlist"
 	"AREDL/middlewares"
 	"AREDL/util"
+	"AREDL/webhook"
 	"github.com/labstack/echo/v5"
 	"github.com/pocketbase/pocketbase/apis"
 	"github.com/pocketbase/pocketbase/core"
@@ -15,28 +17,33 @@ import (
 // registerSubmissionRejectEndpoint godoc
 //
 //	@Summary		Reject AREDL submission.
+//	@Description	Rejected submissions can be submitted again unless the rejection is final.
+//	@Description	Fails if another reviewer holds an active claim on the submission.
 //	@Description	Requires user permission: aredl.submission_review
 //	@Security		ApiKeyAuth
 //	@Tags			aredl
 //	@Param			id					path	string	true	"internal submission id"
 //	@Param			rejection_reason	query	string	false	"rejection reason"
+//	@Param			final				query	bool	false	"whether the submitter is not allowed to submit the level again"	default(false)
 //	@Schemes		http https
 //	@Produce		json
 //	@Success		200
 //	@Failure		400	{object}	util.ErrorResponse
 //	@Failure		403	{object}	util.ErrorResponse
 //	@Router			/aredl/submissions/{id}/reject [post]
-func registerSubmissionRejectEndpoint(e *echo.Group, app core.App) error {
+func registerSubmissionRejectEndpoint(e *echo.Group, app core.App, listData demonlist.ListData) error {
 	_, err := e.AddRoute(echo.Route{
 		Method: http.MethodPost,
 		Path:   "/submission/:id/reject",
 		Middlewares: []echo.MiddlewareFunc{
 			apis.ActivityLogger(app),
+			middlewares.RateLimit(app, middlewares.RateLimitDefault),
 			middlewares.CheckBanned(),
-			middlewares.RequirePermissionGroup(app, "aredl", "submission_review"),
+			middlewares.RequirePermissionGroup(app, listData.Name, "submission_review"),
 			middlewares.LoadParam(middlewares.LoadData{
 				"id":               middlewares.LoadString(true),
 				"rejection_reason": middlewares.LoadString(true),
+				"final":            middlewares.AddDefault(false, middlewares.LoadBool(false)),
 			}),
 		},
 		Handler: func(c echo.Context) error {
@@ -44,24 +51,39 @@ func registerSubmissionRejectEndpoint(e *echo.Group, app core.App) error {
 			if userRecord == nil {
 				return util.NewErrorResponse(nil, "User not found")
 			}
-			aredl := demonlist.Aredl()
 			return app.Dao().RunInTransaction(func(txDao *daos.Dao) error {
-				submissionRecord, err := txDao.FindRecordById(aredl.SubmissionsTableName, c.Get("id").(string))
+				submissionRecord, err := txDao.FindRecordById(listData.SubmissionsTableName, c.Get("id").(string))
 				if err != nil {
 					return util.NewErrorResponse(err, "Could not load submission")
 				}
-				if submissionRecord.GetBool("rejected") {
-					return util.NewErrorResponse(nil, "Submission already has been rejected")
+				err = demonlist.CheckSubmissionClaim(submissionRecord, userRecord.Id)
+				if err != nil {
+					return err
 				}
-				submissionRecord.Set("rejected", true)
+				before := audit.Snapshot(submissionRecord)
 				submissionRecord.Set("rejection_reason", c.Get("rejection_reason").(string))
 				submissionRecord.Set("reviewer", userRecord.Id)
-				err = txDao.SaveRecord(submissionRecord)
+				demonlist.ClearSubmissionClaim(submissionRecord)
+				status := util.If(c.Get("final").(bool), demonlist.SubmissionRejectedFinal, demonlist.SubmissionRejectedRetryable)
+				err = demonlist.SetSubmissionStatus(txDao, listData, submissionRecord, status, userRecord.Id, c.Get("rejection_reason").(string))
+				if err != nil {
+					return err
+				}
+				err = webhook.Emit(txDao, listData.Name, webhook.EventSubmissionRejected, map[string]any{
+					"submission": submissionRecord.PublicExport(),
+				})
 				if err != nil {
-					return util.NewErrorResponse(err, "Failed to update submission")
+					return err
 				}
 				c.Response().Header().Set("Cache-Control", "no-store")
-				return nil
+				return audit.Log(txDao, c, audit.Entry{
+					List:        listData.Name,
+					Action:      "submission_rejected",
+					TargetTable: listData.SubmissionsTableName,
+					TargetId:    submissionRecord.Id,
+					Before:      before,
+					After:       audit.Snapshot(submissionRecord),
+				})
 			})
 		},
 	})
@@ -0,0 +1,86 @@
+package aredl
+
+import (
+	"AREDL/audit"
+	"AREDL/demonlist"
+	"AREDL/middlewares"
+	"AREDL/util"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/models"
+	"net/http"
+)
+
+// registerSubmissionStatusEndpoint godoc
+//
+//	@Summary		Change AREDL submission status
+//	@Description	Moves a submission between the review statuses. Use the accept and reject endpoints to finish the review.
+//	@Description	Fails if another reviewer holds an active claim on the submission.
+//	@Description	Requires user permission: aredl.submission_review
+//	@Security		ApiKeyAuth
+//	@Tags			aredl
+//	@Param			id		path	string	true	"internal submission id"
+//	@Param			status	query	string	true	"new status"	Enums(pending, under_review, needs_info)
+//	@Param			comment	query	string	false	"comment that is shown to the submitter in the timeline"
+//	@Schemes		http https
+//	@Produce		json
+//	@Success		200
+//	@Failure		400	{object}	util.ErrorResponse
+//	@Failure		403	{object}	util.ErrorResponse
+//	@Router			/aredl/submissions/{id}/status [post]
+func registerSubmissionStatusEndpoint(e *echo.Group, app core.App, listData demonlist.ListData) error {
+	_, err := e.AddRoute(echo.Route{
+		Method: http.MethodPost,
+		Path:   "/submissions/:id/status",
+		Middlewares: []echo.MiddlewareFunc{
+			apis.ActivityLogger(app),
+			middlewares.RateLimit(app, middlewares.RateLimitDefault),
+			middlewares.CheckBanned(),
+			middlewares.RequirePermissionGroup(app, listData.Name, "submission_review"),
+			middlewares.LoadParam(middlewares.LoadData{
+				"id":      middlewares.LoadString(true),
+				"status":  middlewares.LoadString(true, validation.In(demonlist.SubmissionPending, demonlist.SubmissionUnderReview, demonlist.SubmissionNeedsInfo)),
+				"comment": middlewares.AddDefault("", middlewares.LoadString(false)),
+			}),
+		},
+		Handler: func(c echo.Context) error {
+			userRecord, _ := c.Get(apis.ContextAuthRecordKey).(*models.Record)
+			if userRecord == nil {
+				return util.NewErrorResponse(nil, "User not found")
+			}
+			err := app.Dao().RunInTransaction(func(txDao *daos.Dao) error {
+				submissionRecord, err := txDao.FindRecordById(listData.SubmissionsTableName, c.Get("id").(string))
+				if err != nil {
+					return util.NewErrorResponse(err, "Could not load submission")
+				}
+				err = demonlist.CheckSubmissionClaim(submissionRecord, userRecord.Id)
+				if err != nil {
+					return err
+				}
+				before := audit.Snapshot(submissionRecord)
+				status := c.Get("status").(string)
+				if status != demonlist.SubmissionUnderReview {
+					demonlist.ClearSubmissionClaim(submissionRecord)
+				}
+				err = demonlist.SetSubmissionStatus(txDao, listData, submissionRecord, status, userRecord.Id, c.Get("comment").(string))
+				if err != nil {
+					return err
+				}
+				return audit.Log(txDao, c, audit.Entry{
+					List:        listData.Name,
+					Action:      "submission_status_changed",
+					TargetTable: listData.SubmissionsTableName,
+					TargetId:    submissionRecord.Id,
+					Before:      before,
+					After:       audit.Snapshot(submissionRecord),
+				})
+			})
+			c.Response().Header().Set("Cache-Control", "no-store")
+			return err
+		},
+	})
+	return err
+}
@@ -0,0 +1,88 @@
+package aredl
+
+import (
+	"AREDL/demonlist"
+	"AREDL/middlewares"
+	"AREDL/names"
+	"AREDL/util"
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/tools/types"
+	"net/http"
+)
+
+type SubmissionTimelineEntry struct {
+	Id         string         `db:"id" json:"id"`
+	Created    types.DateTime `db:"created" json:"created"`
+	FromStatus string         `db:"from_status" json:"from_status"`
+	Status     string         `db:"status" json:"status"`
+	ChangedBy  *struct {
+		Id         string `db:"id" json:"id"`
+		GlobalName string `db:"global_name" json:"global_name"`
+	} `db:"changed_by" json:"changed_by,omitempty" extend:"changed_by,users,id"`
+	Comment string `db:"comment" json:"comment"`
+}
+
+// loadSubmissionTimeline loads all status changes of the submission ordered from oldest to newest
+func loadSubmissionTimeline(dao *daos.Dao, listData demonlist.ListData, submissionId string) ([]SubmissionTimelineEntry, error) {
+	var timeline []SubmissionTimelineEntry
+	tables := map[string]string{
+		"base":  listData.SubmissionTimelineTableName,
+		"users": names.TableUsers,
+	}
+	err := util.LoadFromDb(dao.DB(), &timeline, tables, func(query *dbx.SelectQuery, prefixResolver util.PrefixResolver) {
+		query.Where(dbx.HashExp{prefixResolver("submission"): submissionId})
+		query.OrderBy(prefixResolver("created"), prefixResolver("rowid"))
+	})
+	if err != nil {
+		return nil, util.NewErrorResponse(err, "Could not load submission timeline")
+	}
+	for i := range timeline {
+		if timeline[i].ChangedBy != nil && timeline[i].ChangedBy.Id == "" {
+			timeline[i].ChangedBy = nil
+		}
+	}
+	return timeline, nil
+}
+
+// registerSubmissionTimelineEndpoint godoc
+//
+//	@Summary		AREDL submission timeline
+//	@Description	Lists all status changes of a submission ordered from oldest to newest.
+//	@Description	Requires user permission: aredl.submission_review
+//	@Security		ApiKeyAuth
+//	@Tags			aredl
+//	@Param			id	path	string	true	"internal submission id"
+//	@Schemes		http https
+//	@Produce		json
+//	@Success		200	{object}	[]SubmissionTimelineEntry
+//	@Failure		400	{object}	util.ErrorResponse
+//	@Failure		403	{object}	util.ErrorResponse
+//	@Router			/aredl/submissions/{id}/timeline [get]
+func registerSubmissionTimelineEndpoint(e *echo.Group, app core.App, listData demonlist.ListData) error {
+	_, err := e.AddRoute(echo.Route{
+		Method: http.MethodGet,
+		Path:   "/submissions/:id/timeline",
+		Middlewares: []echo.MiddlewareFunc{
+			apis.ActivityLogger(app),
+			middlewares.RateLimit(app, middlewares.RateLimitDefault),
+			middlewares.CheckBanned(),
+			middlewares.RequirePermissionGroup(app, listData.Name, "submission_review"),
+			middlewares.LoadParam(middlewares.LoadData{
+				"id": middlewares.LoadString(true),
+			}),
+		},
+		Handler: func(c echo.Context) error {
+			timeline, err := loadSubmissionTimeline(app.Dao(), listData, c.Get("id").(string))
+			if err != nil {
+				return err
+			}
+			c.Response().Header().Set("Cache-Control", "no-store")
+			return c.JSON(200, timeline)
+		},
+	})
+	return err
+}
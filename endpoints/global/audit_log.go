@@ -0,0 +1,144 @@
+package global
+
+import (
+	"AREDL/middlewares"
+	"AREDL/names"
+	"AREDL/util"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+var auditDatePattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}( \d{2}:\d{2}(:\d{2})?)?$`)
+
+type AuditLogEntry struct {
+	Id          string        `db:"id" json:"id"`
+	Created     string        `db:"created" json:"created"`
+	List        string        `db:"list" json:"list,omitempty"`
+	Action      string        `db:"action" json:"action"`
+	TargetTable string        `db:"target_table" json:"target_table,omitempty"`
+	TargetId    string        `db:"target_id" json:"target_id,omitempty"`
+	ActorAdmin  string        `db:"actor_admin" json:"actor_admin,omitempty"`
+	Before      types.JsonRaw `db:"before" json:"before" swaggertype:"object"`
+	After       types.JsonRaw `db:"after" json:"after" swaggertype:"object"`
+	Actor       *struct {
+		Id         string `db:"id" json:"id"`
+		GlobalName string `db:"global_name" json:"global_name"`
+	} `db:"actor" json:"actor,omitempty" extend:"actor,users,id"`
+}
+
+type AuditLog struct {
+	List  []AuditLogEntry `json:"list"`
+	Page  int             `json:"page"`
+	Pages int             `json:"pages"`
+}
+
+// registerAuditLogEndpoint godoc
+//
+//	@Summary		Audit log
+//	@Description	Paged list of all moderation and list management actions, newest first.
+//	@Description	Every entry contains the user or admin that performed the action, the affected record and snapshots of it before and after the action.
+//	@Description	Requires user permission: audit_log_view
+//	@Security		ApiKeyAuth
+//	@Tags			global
+//	@Param			page			query	int		false	"select page"					default(1)	minimum(1)
+//	@Param			per_page		query	int		false	"number of results per page"	default(40)	minimum(1)	maximum(200)
+//	@Param			actor_id		query	string	false	"only actions performed by the given internal user id"
+//	@Param			action			query	string	false	"only the given action, for example user_banned or pack_updated"
+//	@Param			list			query	string	false	"only actions on the given list, use global for actions that are not related to a list"
+//	@Param			target_table	query	string	false	"only actions on records of the given table"
+//	@Param			target_id		query	string	false	"only actions on the record with the given id"
+//	@Param			from			query	string	false	"only actions at or after the given time, format: 2006-01-02 or 2006-01-02 15:04:05"
+//	@Param			to				query	string	false	"only actions before the given time, format: 2006-01-02 or 2006-01-02 15:04:05"
+//	@Schemes		http https
+//	@Produce		json
+//	@Success		200	{object}	AuditLog
+//	@Failure		400	{object}	util.ErrorResponse
+//	@Failure		403	{object}	util.ErrorResponse
+//	@Router			/audit-log [get]
+func registerAuditLogEndpoint(e *echo.Group, app core.App) error {
+	_, err := e.AddRoute(echo.Route{
+		Method: http.MethodGet,
+		Path:   "/audit-log",
+		Middlewares: []echo.MiddlewareFunc{
+			apis.ActivityLogger(app),
+			middlewares.CheckBanned(),
+			middlewares.RequirePermissionGroup(app, "", "audit_log_view"),
+			middlewares.LoadParam(middlewares.LoadData{
+				"page":         middlewares.AddDefault(1, middlewares.LoadInt(false, validation.Min(1))),
+				"per_page":     middlewares.AddDefault(40, middlewares.LoadInt(false, validation.Min(1), validation.Max(200))),
+				"actor_id":     middlewares.LoadString(false),
+				"action":       middlewares.LoadString(false),
+				"list":         middlewares.LoadString(false),
+				"target_table": middlewares.LoadString(false),
+				"target_id":    middlewares.LoadString(false),
+				"from":         middlewares.LoadString(false, validation.Match(auditDatePattern)),
+				"to":           middlewares.LoadString(false, validation.Match(auditDatePattern)),
+			}),
+		},
+		Handler: func(c echo.Context) error {
+			page := c.Get("page").(int)
+			perPage := c.Get("per_page").(int)
+			// filter builds the conditions of the given params, column resolves the column names of the audit log table
+			filter := func(column func(string) string) dbx.Expression {
+				var conditions []dbx.Expression
+				for param, field := range map[string]string{
+					"actor_id":     "actor",
+					"action":       "action",
+					"target_table": "target_table",
+					"target_id":    "target_id",
+				} {
+					if c.Get(param) != nil {
+						conditions = append(conditions, dbx.HashExp{column(field): c.Get(param)})
+					}
+				}
+				if c.Get("list") != nil {
+					conditions = append(conditions, dbx.HashExp{column("list"): util.If(c.Get("list") == "global", "", c.Get("list"))})
+				}
+				if c.Get("from") != nil {
+					conditions = append(conditions, dbx.NewExp(column("created")+" >= {:from}", dbx.Params{"from": c.Get("from")}))
+				}
+				if c.Get("to") != nil {
+					conditions = append(conditions, dbx.NewExp(column("created")+" < {:to}", dbx.Params{"to": c.Get("to")}))
+				}
+				return dbx.And(conditions...)
+			}
+			err := app.Dao().RunInTransaction(func(txDao *daos.Dao) error {
+				result := AuditLog{Page: page}
+				tableNames := map[string]string{
+					"base":  names.TableAuditLog,
+					"users": names.TableUsers,
+				}
+				err := util.LoadFromDb(txDao.DB(), &result.List, tableNames, func(query *dbx.SelectQuery, prefixResolver util.PrefixResolver) {
+					query.Where(filter(prefixResolver)).
+						Offset(int64((page-1)*perPage)).
+						Limit(int64(perPage)).
+						OrderBy(prefixResolver("created")+" DESC", prefixResolver("id"))
+				})
+				if err != nil {
+					return util.NewErrorResponse(err, "Failed to load audit log")
+				}
+				err = txDao.DB().
+					Select(fmt.Sprintf("(count(*) / %v + 1)", perPage)).
+					From(names.TableAuditLog).
+					Where(filter(func(field string) string { return field })).
+					Row(&result.Pages)
+				if err != nil {
+					return util.NewErrorResponse(err, "Failed to calculate page count")
+				}
+				c.Response().Header().Set("Cache-Control", "no-store")
+				return c.JSON(http.StatusOK, result)
+			})
+			return err
+		},
+	})
+	return err
+}
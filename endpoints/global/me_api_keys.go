@@ -0,0 +1,98 @@
+package global
+
+import (
+	"AREDL/apikey"
+	"AREDL/middlewares"
+	"AREDL/names"
+	"AREDL/util"
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/tools/types"
+	"net/http"
+)
+
+type ApiKey struct {
+	Id       string         `json:"id"`
+	Name     string         `json:"name"`
+	Prefix   string         `json:"prefix"`
+	Scopes   []string       `json:"scopes"`
+	Trusted  bool           `json:"trusted"`
+	Expires  types.DateTime `json:"expires"`
+	LastUsed types.DateTime `json:"last_used"`
+	Created  types.DateTime `json:"created"`
+}
+
+func apiKeyFromRecord(record *models.Record) ApiKey {
+	scopes := apikey.Scopes(record)
+	if scopes == nil {
+		scopes = []string{}
+	}
+	return ApiKey{
+		Id:       record.Id,
+		Name:     record.GetString("name"),
+		Prefix:   record.GetString("prefix"),
+		Scopes:   scopes,
+		Trusted:  record.GetBool("trusted"),
+		Expires:  record.GetDateTime("expires"),
+		LastUsed: record.GetDateTime("last_used"),
+		Created:  record.Created,
+	}
+}
+
+// registerApiKeyListEndpoint godoc
+//
+//	@Summary		List api keys
+//	@Description	Lists the api keys of the authenticated user. The keys themselves are only shown when they are created or rotated, prefix holds the first characters to recognize them
+//	@Description	Requires user permission: user_request_api_key
+//	@Security		ApiKeyAuth
+//	@Tags			global
+//	@Schemes		http https
+//	@Produce		json
+//	@Success		200	{object}	[]ApiKey
+//	@Failure		400	{object}	util.ErrorResponse
+//	@Failure		403	{object}	util.ErrorResponse
+//	@Router			/me/api-keys [get]
+func registerApiKeyListEndpoint(e *echo.Group, app core.App) error {
+	_, err := e.AddRoute(echo.Route{
+		Method: http.MethodGet,
+		Path:   "/me/api-keys",
+		Middlewares: []echo.MiddlewareFunc{
+			apis.ActivityLogger(app),
+			middlewares.CheckBanned(),
+			middlewares.RequirePermissionGroup(app, "", "user_request_api_key"),
+		},
+		Handler: func(c echo.Context) error {
+			err := app.Dao().RunInTransaction(func(txDao *daos.Dao) error {
+				userRecord, _ := c.Get(apis.ContextAuthRecordKey).(*models.Record)
+				if userRecord == nil {
+					return util.NewErrorResponse(nil, "User not found")
+				}
+				records, err := txDao.FindRecordsByFilter(names.TableApiKeys, "user = {:user}", "created", 0, 0, dbx.Params{"user": userRecord.Id})
+				if err != nil {
+					return util.NewErrorResponse(err, "Failed to load api keys")
+				}
+				c.Response().Header().Set("Cache-Control", "no-store")
+				return c.JSON(http.StatusOK, util.MapSlice(records, apiKeyFromRecord))
+			})
+			return err
+		},
+	})
+	return err
+}
+
+// findOwnApiKey loads the api key with the given id if it belongs to the authenticated user
+func findOwnApiKey(c echo.Context, dao *daos.Dao, id string) (*models.Record, error) {
+	userRecord, _ := c.Get(apis.ContextAuthRecordKey).(*models.Record)
+	if userRecord == nil {
+		return nil, util.NewErrorResponse(nil, "User not found")
+	}
+	record, err := dao.FindRecordById(names.TableApiKeys, id)
+	if err != nil || record.GetString("user") != userRecord.Id {
+		return nil, util.NewErrorResponse(err, "Api key not found")
+	}
+	return record, nil
+}
@@ -0,0 +1,126 @@
+package global
+
+import (
+	"AREDL/apikey"
+	"AREDL/audit"
+	"AREDL/middlewares"
+	"AREDL/names"
+	"AREDL/util"
+	"fmt"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/tools/list"
+	"github.com/pocketbase/pocketbase/tools/types"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type NewApiKey struct {
+	ApiKey
+	Key string `json:"key"`
+}
+
+// registerApiKeyCreateEndpoint godoc
+//
+//	@Summary		Create api key
+//	@Description	Creates a new api key for the authenticated user. The key is only returned once and cannot be requested again.
+//	@Description	Scopes limit the key to the given permissions in the format <list>.<action>, for example aredl.user_submit or global.user_request_api_key. A key without scopes has every permission of the user.
+//	@Description	A request authenticated with a scoped api key can only create keys with a subset of its scopes
+//	@Description	Requires user permission: user_request_api_key
+//	@Security		ApiKeyAuth
+//	@Tags			global
+//	@Param			name	query	string		true	"name of the key"
+//	@Param			scopes	query	[]string	false	"permissions the key is limited to, json array"
+//	@Param			expires	query	string		false	"time the key stops working, format: 2006-01-02 or 2006-01-02 15:04:05"
+//	@Schemes		http https
+//	@Produce		json
+//	@Success		200	{object}	NewApiKey
+//	@Failure		400	{object}	util.ErrorResponse
+//	@Failure		403	{object}	util.ErrorResponse
+//	@Router			/me/api-keys [post]
+func registerApiKeyCreateEndpoint(e *echo.Group, app core.App) error {
+	_, err := e.AddRoute(echo.Route{
+		Method: http.MethodPost,
+		Path:   "/me/api-keys",
+		Middlewares: []echo.MiddlewareFunc{
+			apis.ActivityLogger(app),
+			middlewares.CheckBanned(),
+			middlewares.RequirePermissionGroup(app, "", "user_request_api_key"),
+			middlewares.LoadParam(middlewares.LoadData{
+				"name":    middlewares.LoadString(true, validation.Length(1, 100)),
+				"scopes":  middlewares.AddDefault([]string{}, middlewares.LoadStringArray(false)),
+				"expires": middlewares.LoadString(false),
+			}),
+		},
+		Handler: func(c echo.Context) error {
+			err := app.Dao().RunInTransaction(func(txDao *daos.Dao) error {
+				userRecord, _ := c.Get(apis.ContextAuthRecordKey).(*models.Record)
+				if userRecord == nil {
+					return util.NewErrorResponse(nil, "User not found")
+				}
+				var expires types.DateTime
+				if c.Get("expires") != nil {
+					var err error
+					expires, err = types.ParseDateTime(c.Get("expires"))
+					if err != nil || expires.IsZero() {
+						return util.NewErrorResponse(err, "Invalid expiry time")
+					}
+					if !expires.Time().After(time.Now()) {
+						return util.NewErrorResponse(nil, "Expiry time has to be in the future")
+					}
+				}
+				scopes := list.ToUniqueStringSlice(c.Get("scopes").([]string))
+				permissions, err := middlewares.GetAllPermissions(txDao, userRecord.Id)
+				if err != nil {
+					return util.NewErrorResponse(err, "Failed to load permissions")
+				}
+				available := make([]string, 0, len(permissions))
+				for permission := range permissions {
+					listName, action, _ := strings.Cut(permission, ".")
+					available = append(available, apikey.Scope(listName, action))
+				}
+				for _, scope := range scopes {
+					if !list.ExistInSlice(scope, available) {
+						return util.NewErrorResponse(nil, fmt.Sprintf("Unknown scope: %s", scope))
+					}
+				}
+				// keys created with a scoped key must not have more permissions than the key itself
+				if requestScopes, _ := c.Get(middlewares.KeyApiKeyScopes).([]string); len(requestScopes) > 0 {
+					if len(scopes) == 0 {
+						return util.NewErrorResponse(nil, "A scoped api key can only create keys with scopes")
+					}
+					for _, scope := range scopes {
+						if !list.ExistInSlice(scope, requestScopes) {
+							return util.NewErrorResponse(nil, fmt.Sprintf("The api key is not allowed to use scope: %s", scope))
+						}
+					}
+				}
+				record, key, err := apikey.Create(txDao, app, userRecord.Id, c.Get("name").(string), scopes, expires)
+				if err != nil {
+					return err
+				}
+				err = audit.Log(txDao, c, audit.Entry{
+					Action:      "api_key_created",
+					TargetTable: names.TableApiKeys,
+					TargetId:    record.Id,
+					After:       audit.Snapshot(record),
+				})
+				if err != nil {
+					return err
+				}
+				c.Response().Header().Set("Cache-Control", "no-store")
+				return c.JSON(http.StatusOK, NewApiKey{
+					ApiKey: apiKeyFromRecord(record),
+					Key:    key,
+				})
+			})
+			return err
+		},
+	})
+	return err
+}
@@ -0,0 +1,66 @@
+package global
+
+import (
+	"AREDL/audit"
+	"AREDL/middlewares"
+	"AREDL/names"
+	"AREDL/util"
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
+	"net/http"
+)
+
+// registerApiKeyDeleteEndpoint godoc
+//
+//	@Summary		Revoke api key
+//	@Description	Deletes an api key of the authenticated user, it can't be used afterwards
+//	@Description	Requires user permission: user_request_api_key
+//	@Security		ApiKeyAuth
+//	@Tags			global
+//	@Param			id	path	string	true	"api key id"
+//	@Schemes		http https
+//	@Produce		json
+//	@Success		200
+//	@Failure		400	{object}	util.ErrorResponse
+//	@Failure		403	{object}	util.ErrorResponse
+//	@Router			/me/api-keys/{id} [delete]
+func registerApiKeyDeleteEndpoint(e *echo.Group, app core.App) error {
+	_, err := e.AddRoute(echo.Route{
+		Method: http.MethodDelete,
+		Path:   "/me/api-keys/:id",
+		Middlewares: []echo.MiddlewareFunc{
+			apis.ActivityLogger(app),
+			middlewares.CheckBanned(),
+			middlewares.RequirePermissionGroup(app, "", "user_request_api_key"),
+			middlewares.LoadParam(middlewares.LoadData{
+				"id": middlewares.LoadString(true),
+			}),
+		},
+		Handler: func(c echo.Context) error {
+			err := app.Dao().RunInTransaction(func(txDao *daos.Dao) error {
+				record, err := findOwnApiKey(c, txDao, c.Get("id").(string))
+				if err != nil {
+					return err
+				}
+				err = txDao.DeleteRecord(record)
+				if err != nil {
+					return util.NewErrorResponse(err, "Failed to revoke api key")
+				}
+				return audit.Log(txDao, c, audit.Entry{
+					Action:      "api_key_revoked",
+					TargetTable: names.TableApiKeys,
+					TargetId:    record.Id,
+					Before:      audit.Snapshot(record),
+				})
+			})
+			if err != nil {
+				return err
+			}
+			c.Response().Header().Set("Cache-Control", "no-store")
+			return c.String(http.StatusOK, "Revoked")
+		},
+	})
+	return err
+}
@@ -0,0 +1,82 @@
+package global
+
+import (
+	"AREDL/apikey"
+	"AREDL/audit"
+	"AREDL/middlewares"
+	"AREDL/names"
+	"AREDL/util"
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/tools/list"
+	"net/http"
+)
+
+// registerApiKeyRotateEndpoint godoc
+//
+//	@Summary		Rotate api key
+//	@Description	Replaces an api key of the authenticated user with a new one. Name, scopes and expiry stay the same, the old key stops working immediately.
+//	@Description	A request authenticated with a scoped api key can only rotate keys with a subset of its scopes
+//	@Description	Requires user permission: user_request_api_key
+//	@Security		ApiKeyAuth
+//	@Tags			global
+//	@Param			id	path	string	true	"api key id"
+//	@Schemes		http https
+//	@Produce		json
+//	@Success		200	{object}	NewApiKey
+//	@Failure		400	{object}	util.ErrorResponse
+//	@Failure		403	{object}	util.ErrorResponse
+//	@Router			/me/api-keys/{id}/rotate [post]
+func registerApiKeyRotateEndpoint(e *echo.Group, app core.App) error {
+	_, err := e.AddRoute(echo.Route{
+		Method: http.MethodPost,
+		Path:   "/me/api-keys/:id/rotate",
+		Middlewares: []echo.MiddlewareFunc{
+			apis.ActivityLogger(app),
+			middlewares.CheckBanned(),
+			middlewares.RequirePermissionGroup(app, "", "user_request_api_key"),
+			middlewares.LoadParam(middlewares.LoadData{
+				"id": middlewares.LoadString(true),
+			}),
+		},
+		Handler: func(c echo.Context) error {
+			err := app.Dao().RunInTransaction(func(txDao *daos.Dao) error {
+				record, err := findOwnApiKey(c, txDao, c.Get("id").(string))
+				if err != nil {
+					return err
+				}
+				// rotating returns the new key, so a scoped key must not be able to obtain a key with more permissions
+				if requestScopes, _ := c.Get(middlewares.KeyApiKeyScopes).([]string); len(requestScopes) > 0 {
+					scopes := apikey.Scopes(record)
+					if len(scopes) == 0 || len(list.SubtractSlice(scopes, requestScopes)) > 0 {
+						return util.NewErrorResponse(nil, "The api key is not allowed to rotate this key")
+					}
+				}
+				before := audit.Snapshot(record)
+				key, err := apikey.Rotate(txDao, record)
+				if err != nil {
+					return err
+				}
+				err = audit.Log(txDao, c, audit.Entry{
+					Action:      "api_key_rotated",
+					TargetTable: names.TableApiKeys,
+					TargetId:    record.Id,
+					Before:      before,
+					After:       audit.Snapshot(record),
+				})
+				if err != nil {
+					return err
+				}
+				c.Response().Header().Set("Cache-Control", "no-store")
+				return c.JSON(http.StatusOK, NewApiKey{
+					ApiKey: apiKeyFromRecord(record),
+					Key:    key,
+				})
+			})
+			return err
+		},
+	})
+	return err
+}
@@ -1,7 +1,7 @@
 package global
 
 import (
-	"AREDL/demonlist"
+	"AREDL/audit"
 	"AREDL/middlewares"
 	"AREDL/names"
 	"AREDL/util"
@@ -44,15 +44,22 @@ func registerMergeRequestAcceptEndpoint(e *echo.Group, app core.App) error {
 				if err != nil {
 					return err
 				}
-				err = demonlist.MergeUsers(txDao, record.GetString("user"), record.GetString("to_merge"))
+				before, after, err := mergeUsers(txDao, record.GetString("user"), record.GetString("to_merge"))
 				if err != nil {
 					return util.NewErrorResponse(err, "Failed to merge")
 				}
+				before["request"] = audit.Snapshot(record)
 				err = txDao.DeleteRecord(record)
 				if err != nil {
 					return err
 				}
-				return nil
+				return audit.Log(txDao, c, audit.Entry{
+					Action:      "merge_request_accepted",
+					TargetTable: names.TableMergeRequests,
+					TargetId:    record.Id,
+					Before:      before,
+					After:       after,
+				})
 			})
 			if err != nil {
 				return util.NewErrorResponse(err, "Failed to merge")
@@ -1,6 +1,7 @@
 package global
 
 import (
+	"AREDL/audit"
 	"AREDL/middlewares"
 	"AREDL/names"
 	"AREDL/util"
@@ -63,7 +64,7 @@ func registerMergeRequestEndpoint(e *echo.Group, app core.App) error {
 				if err != nil {
 					return util.NewErrorResponse(err, "Unknown legacy user")
 				}
-				_, err = util.AddRecordByCollectionName(txDao, app, names.TableMergeRequests, map[string]any{
+				requestRecord, err := util.AddRecordByCollectionName(txDao, app, names.TableMergeRequests, map[string]any{
 					"user":     userRecord.Id,
 					"to_merge": legacyRecord.Id,
 				})
@@ -71,7 +72,12 @@ func registerMergeRequestEndpoint(e *echo.Group, app core.App) error {
 					return util.NewErrorResponse(err, "Failed to create request")
 				}
 				c.Response().Header().Set("Cache-Control", "no-store")
-				return nil
+				return audit.Log(txDao, c, audit.Entry{
+					Action:      "merge_request_created",
+					TargetTable: names.TableMergeRequests,
+					TargetId:    requestRecord.Id,
+					After:       audit.Snapshot(requestRecord),
+				})
 			})
 			return err
 		},
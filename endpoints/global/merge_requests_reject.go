@@ -1,6 +1,7 @@
 package global
 
 import (
+	"AREDL/audit"
 	"AREDL/middlewares"
 	"AREDL/names"
 	"AREDL/util"
@@ -47,7 +48,12 @@ func registerMergeRequestRejectEndpoint(e *echo.Group, app core.App) error {
 				if err != nil {
 					return err
 				}
-				return nil
+				return audit.Log(txDao, c, audit.Entry{
+					Action:      "merge_request_rejected",
+					TargetTable: names.TableMergeRequests,
+					TargetId:    record.Id,
+					Before:      audit.Snapshot(record),
+				})
 			})
 			if err != nil {
 				return util.NewErrorResponse(err, "Failed to reject")
@@ -1,6 +1,7 @@
 package global
 
 import (
+	"AREDL/audit"
 	"AREDL/middlewares"
 	"AREDL/names"
 	"AREDL/util"
@@ -47,6 +48,7 @@ func registerNameChangeAcceptEndpoint(e *echo.Group, app core.App) error {
 				if err != nil {
 					return util.NewErrorResponse(err, "Could not find user in request")
 				}
+				before := audit.Snapshot(userRecord)
 				userRecord.Set("global_name", requestRecord.GetString("new_name"))
 				err = txDao.SaveRecord(userRecord)
 				if err != nil {
@@ -56,7 +58,13 @@ func registerNameChangeAcceptEndpoint(e *echo.Group, app core.App) error {
 				if err != nil {
 					return util.NewErrorResponse(err, "Failed to delete request")
 				}
-				return nil
+				return audit.Log(txDao, c, audit.Entry{
+					Action:      "name_change_accepted",
+					TargetTable: names.TableUsers,
+					TargetId:    userRecord.Id,
+					Before:      before,
+					After:       audit.Snapshot(userRecord),
+				})
 			})
 			c.Response().Header().Set("Cache-Control", "no-store")
 			return err
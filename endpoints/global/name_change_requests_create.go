@@ -1,6 +1,7 @@
 package global
 
 import (
+	"AREDL/audit"
 	"AREDL/middlewares"
 	"AREDL/names"
 	"AREDL/util"
@@ -50,6 +51,7 @@ func registerNameChangeRequestEndpoint(e *echo.Group, app core.App) error {
 				}
 				sameAsOld := userRecord.GetString("global_name") == c.Get("new_name")
 				requestRecord, _ := txDao.FindFirstRecordByData(names.TableNameChangeRequests, "user", userRecord.Id)
+				before := audit.Snapshot(requestRecord)
 				if requestRecord == nil {
 					if sameAsOld {
 						return util.NewErrorResponse(nil, "New name is the same as the old one")
@@ -63,7 +65,12 @@ func registerNameChangeRequestEndpoint(e *echo.Group, app core.App) error {
 					if err := txDao.DeleteRecord(requestRecord); err != nil {
 						return util.NewErrorResponse(err, "Failed to delete request")
 					}
-					return nil
+					return audit.Log(txDao, c, audit.Entry{
+						Action:      "name_change_withdrawn",
+						TargetTable: names.TableNameChangeRequests,
+						TargetId:    requestRecord.Id,
+						Before:      before,
+					})
 				}
 				requestForm := forms.NewRecordUpsert(app, requestRecord)
 				requestForm.SetDao(txDao)
@@ -77,7 +84,13 @@ func registerNameChangeRequestEndpoint(e *echo.Group, app core.App) error {
 				if err = requestForm.Submit(); err != nil {
 					return util.NewErrorResponse(err, "Invalid data")
 				}
-				return nil
+				return audit.Log(txDao, c, audit.Entry{
+					Action:      "name_change_requested",
+					TargetTable: names.TableNameChangeRequests,
+					TargetId:    requestRecord.Id,
+					Before:      before,
+					After:       audit.Snapshot(requestRecord),
+				})
 			})
 			c.Response().Header().Set("Cache-Control", "no-store")
 			return err
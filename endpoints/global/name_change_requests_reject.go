@@ -1,6 +1,7 @@
 package global
 
 import (
+	"AREDL/audit"
 	"AREDL/middlewares"
 	"AREDL/names"
 	"AREDL/util"
@@ -47,7 +48,12 @@ func registerNameChangeRejectEndpoint(e *echo.Group, app core.App) error {
 				if err != nil {
 					return util.NewErrorResponse(err, "Failed to delete request")
 				}
-				return nil
+				return audit.Log(txDao, c, audit.Entry{
+					Action:      "name_change_rejected",
+					TargetTable: names.TableNameChangeRequests,
+					TargetId:    requestRecord.Id,
+					Before:      audit.Snapshot(requestRecord),
+				})
 			})
 			c.Response().Header().Set("Cache-Control", "no-store")
 			return err
@@ -9,12 +9,15 @@ import (
 	"github.com/pocketbase/pocketbase/daos"
 	"github.com/pocketbase/pocketbase/models"
 	"net/http"
+	"strings"
 )
 
 // registerPermissionsEndpoint godoc
 //
 //	@Summary		Get a list of Permissions
-//	@Description	Returns all the available permissions to the authenticated user, if there is no authenticaiton provided, the permissions will be empty
+//	@Description	Returns all the available permissions to the authenticated user, if there is no authenticaiton provided, the permissions will be empty.
+//	@Description	Requests authenticated with a scoped api key only get the permissions within the scopes of the key
+//	@Description	Permissions that come from roles limited in time include the time they expire
 //	@Security		ApiKeyAuth
 //	@Tags			global
 //	@Schemes		http https
@@ -42,6 +45,12 @@ func registerPermissionsEndpoint(e *echo.Group, app core.App) error {
 				if err != nil {
 					return util.NewErrorResponse(err, "Failed to load permissions")
 				}
+				for permission := range result {
+					listName, action, _ := strings.Cut(permission, ".")
+					if !middlewares.ApiKeyAllows(c, listName, action) {
+						delete(result, permission)
+					}
+				}
 				c.Response().Header().Set("Cache-Control", "no-store")
 				return c.JSON(200, result)
 			})
@@ -9,7 +9,10 @@ import (
 func RegisterEndpoints(app core.App) {
 	util.RegisterEndpoints(app, "/api",
 		registerPermissionsEndpoint,
-		registerGetApiKeyEndpoint,
+		registerApiKeyListEndpoint,
+		registerApiKeyCreateEndpoint,
+		registerApiKeyDeleteEndpoint,
+		registerApiKeyRotateEndpoint,
 		registerMergeRequestEndpoint,
 		registerMergeRequestListEndpoint,
 		registerMergeRequestAcceptEndpoint,
@@ -22,7 +25,18 @@ func RegisterEndpoints(app core.App) {
 		registerBanAccountEndpoint,
 		registerUserMergeEndpoint,
 		registerChangeRoleEndpoint,
+		registerChangeRoleGrantEndpoint,
 		registerCreatePlaceholderUser,
 		registerUnbanAccountEndpoint,
+		registerAuditLogEndpoint,
+		registerRoleListEndpoint,
+		registerRoleCreateEndpoint,
+		registerRoleUpdateEndpoint,
+		registerRoleDeleteEndpoint,
+		registerRolePermissionListEndpoint,
+		registerRolePermissionCreateEndpoint,
+		registerRolePermissionUpdateEndpoint,
+		registerRolePermissionDeleteEndpoint,
+		registerPermissionHoldersEndpoint,
 	)
 }
@@ -0,0 +1,100 @@
+package global
+
+import (
+	"AREDL/demonlist"
+	"AREDL/middlewares"
+	"AREDL/names"
+	"AREDL/util"
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/tools/list"
+	"net/http"
+)
+
+type RolePermission struct {
+	Id            string   `json:"id"`
+	Roles         []string `json:"roles"`
+	List          string   `json:"list"`
+	Action        string   `json:"action"`
+	AffectedRoles []string `json:"affected_roles"`
+	// AffectsLowerRanks makes the permission affect every role ranked below the role of the user in addition to the affected roles
+	AffectsLowerRanks bool `json:"affects_lower_ranks"`
+}
+
+func rolePermissionFromRecord(record *models.Record) RolePermission {
+	return RolePermission{
+		Id:                record.Id,
+		Roles:             record.GetStringSlice("role"),
+		List:              record.GetString("list"),
+		Action:            record.GetString("action"),
+		AffectedRoles:     record.GetStringSlice("affected_roles"),
+		AffectsLowerRanks: record.GetBool("affects_lower_ranks"),
+	}
+}
+
+// loadRolePermissions loads the permissions of the role or of every role if it is empty
+func loadRolePermissions(dao *daos.Dao, role string) ([]RolePermission, error) {
+	collection, err := dao.FindCollectionByNameOrId(names.TablePermissions)
+	if err != nil {
+		return nil, err
+	}
+	var records []*models.Record
+	err = dao.RecordQuery(collection).OrderBy("list", "action").All(&records)
+	if err != nil {
+		return nil, err
+	}
+	result := []RolePermission{}
+	for _, record := range records {
+		if role == "" || list.ExistInSlice(role, record.GetStringSlice("role")) {
+			result = append(result, rolePermissionFromRecord(record))
+		}
+	}
+	return result, nil
+}
+
+// permissionListNames are the lists a permission can belong to
+func permissionListNames() []any {
+	return append([]any{"global"}, util.MapSlice(demonlist.Lists(), func(listData demonlist.ListData) any { return listData.Name })...)
+}
+
+// registerRolePermissionListEndpoint godoc
+//
+//	@Summary		List role permissions
+//	@Description	Lists the permissions of all roles. A permission can belong to multiple roles, affected roles are the roles of users the permission can be used on.
+//	@Description	Roles also have the permissions of the roles they inherit from, see list roles
+//	@Description	Requires user permission: manage_roles
+//	@Security		ApiKeyAuth
+//	@Tags			global
+//	@Param			role	query	string	false	"only permissions of the given role"
+//	@Schemes		http https
+//	@Produce		json
+//	@Success		200	{object}	[]RolePermission
+//	@Failure		400	{object}	util.ErrorResponse
+//	@Failure		403	{object}	util.ErrorResponse
+//	@Router			/permissions [get]
+func registerRolePermissionListEndpoint(e *echo.Group, app core.App) error {
+	_, err := e.AddRoute(echo.Route{
+		Method: http.MethodGet,
+		Path:   "/permissions",
+		Middlewares: []echo.MiddlewareFunc{
+			apis.ActivityLogger(app),
+			middlewares.CheckBanned(),
+			middlewares.RequirePermissionGroup(app, "", "manage_roles"),
+			middlewares.LoadParam(middlewares.LoadData{
+				"role": middlewares.AddDefault("", middlewares.LoadString(false)),
+			}),
+		},
+		Handler: func(c echo.Context) error {
+			result, err := loadRolePermissions(app.Dao(), c.Get("role").(string))
+			if err != nil {
+				return util.NewErrorResponse(err, "Failed to load permissions")
+			}
+			c.Response().Header().Set("Cache-Control", "no-store")
+			return c.JSON(http.StatusOK, result)
+		},
+	})
+	return err
+}
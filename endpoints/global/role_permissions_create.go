@@ -0,0 +1,110 @@
+package global
+
+import (
+	"AREDL/audit"
+	"AREDL/middlewares"
+	"AREDL/names"
+	"AREDL/util"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
+	"net/http"
+)
+
+// registerRolePermissionCreateEndpoint godoc
+//
+//	@Summary		Create role permission
+//	@Description	Gives the roles a permission
+//	@Description	Requires user permission: manage_roles
+//	@Description	Additionally the user needs to be able to affect the roles with their permission, hold the given permission themselves and be able to affect the affected roles with it.
+//	@Description	Permissions affecting lower ranks can only be granted by users whose permission affects lower ranks as well
+//	@Security		ApiKeyAuth
+//	@Tags			global
+//	@Param			roles				query	[]string	true	"roles that get the permission"
+//	@Param			list				query	string		true	"list the permission belongs to, global for permissions that are not bound to a list"
+//	@Param			action				query	string		true	"action of the permission"
+//	@Param			affected_roles		query	[]string	false	"roles of the users the permission can be used on"
+//	@Param			affects_lower_ranks	query	bool		false	"the permission can also be used on users whose roles are ranked below the role of the user using it"	default(false)
+//	@Schemes		http https
+//	@Produce		json
+//	@Success		200	{object}	RolePermission
+//	@Failure		400	{object}	util.ErrorResponse
+//	@Failure		403	{object}	util.ErrorResponse
+//	@Router			/permissions [post]
+func registerRolePermissionCreateEndpoint(e *echo.Group, app core.App) error {
+	_, err := e.AddRoute(echo.Route{
+		Method: http.MethodPost,
+		Path:   "/permissions",
+		Middlewares: []echo.MiddlewareFunc{
+			apis.ActivityLogger(app),
+			middlewares.CheckBanned(),
+			middlewares.RequirePermissionGroup(app, "", "manage_roles"),
+			middlewares.LoadParam(middlewares.LoadData{
+				"roles":               middlewares.LoadStringArray(true),
+				"list":                middlewares.LoadString(true, validation.In(permissionListNames()...)),
+				"action":              middlewares.LoadString(true, validation.Length(1, 100)),
+				"affected_roles":      middlewares.AddDefault([]string{}, middlewares.LoadStringArray(false)),
+				"affects_lower_ranks": middlewares.AddDefault(false, middlewares.LoadBool(false)),
+			}),
+		},
+		Handler: func(c echo.Context) error {
+			var result RolePermission
+			err := app.Dao().RunInTransaction(func(txDao *daos.Dao) error {
+				permission := RolePermission{
+					Roles:             c.Get("roles").([]string),
+					List:              c.Get("list").(string),
+					Action:            c.Get("action").(string),
+					AffectedRoles:     c.Get("affected_roles").([]string),
+					AffectsLowerRanks: c.Get("affects_lower_ranks").(bool),
+				}
+				if len(permission.Roles) == 0 {
+					return util.NewErrorResponse(nil, "roles can't be empty")
+				}
+				err := checkCanGrant(c, txDao, permission)
+				if err != nil {
+					return err
+				}
+				record, err := util.AddRecordByCollectionName(txDao, app, names.TablePermissions, map[string]any{
+					"role":                permission.Roles,
+					"list":                permission.List,
+					"action":              permission.Action,
+					"affected_roles":      permission.AffectedRoles,
+					"affects_lower_ranks": permission.AffectsLowerRanks,
+				})
+				if err != nil {
+					return util.NewErrorResponse(err, "Failed to create permission")
+				}
+				result = rolePermissionFromRecord(record)
+				return audit.Log(txDao, c, audit.Entry{
+					Action:      "permission_created",
+					TargetTable: names.TablePermissions,
+					TargetId:    record.Id,
+					After:       audit.Snapshot(record),
+				})
+			})
+			if err != nil {
+				return err
+			}
+			c.Response().Header().Set("Cache-Control", "no-store")
+			return c.JSON(http.StatusOK, result)
+		},
+	})
+	return err
+}
+
+// checkCanGrant checks if the authenticated user is allowed to give or take the permission
+func checkCanGrant(c echo.Context, dao *daos.Dao, permission RolePermission) error {
+	if !middlewares.CanAffectRole(c, permission.Roles) {
+		return util.NewErrorResponse(nil, "Not allowed to change the permissions of the given roles")
+	}
+	canGrant, err := middlewares.CanGrantPermission(c, dao, permission.List, permission.Action, permission.AffectedRoles, permission.AffectsLowerRanks)
+	if err != nil {
+		return util.NewErrorResponse(err, "Failed to load permissions")
+	}
+	if !canGrant {
+		return util.NewErrorResponse(nil, "Not allowed to grant a permission you don't have")
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package global
+
+import (
+	"AREDL/audit"
+	"AREDL/middlewares"
+	"AREDL/names"
+	"AREDL/util"
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
+	"net/http"
+)
+
+// registerRolePermissionDeleteEndpoint godoc
+//
+//	@Summary		Delete role permission
+//	@Description	Takes a permission from its roles
+//	@Description	Requires user permission: manage_roles
+//	@Description	Additionally the user needs to be able to grant the permission, see create role permission
+//	@Security		ApiKeyAuth
+//	@Tags			global
+//	@Param			id	path	string	true	"permission id"
+//	@Schemes		http https
+//	@Produce		json
+//	@Success		200
+//	@Failure		400	{object}	util.ErrorResponse
+//	@Failure		403	{object}	util.ErrorResponse
+//	@Router			/permissions/{id} [delete]
+func registerRolePermissionDeleteEndpoint(e *echo.Group, app core.App) error {
+	_, err := e.AddRoute(echo.Route{
+		Method: http.MethodDelete,
+		Path:   "/permissions/:id",
+		Middlewares: []echo.MiddlewareFunc{
+			apis.ActivityLogger(app),
+			middlewares.CheckBanned(),
+			middlewares.RequirePermissionGroup(app, "", "manage_roles"),
+			middlewares.LoadParam(middlewares.LoadData{
+				"id": middlewares.LoadString(true),
+			}),
+		},
+		Handler: func(c echo.Context) error {
+			err := app.Dao().RunInTransaction(func(txDao *daos.Dao) error {
+				record, err := txDao.FindRecordById(names.TablePermissions, c.Get("id").(string))
+				if err != nil {
+					return util.NewErrorResponse(err, "Permission not found")
+				}
+				err = checkCanGrant(c, txDao, rolePermissionFromRecord(record))
+				if err != nil {
+					return err
+				}
+				err = txDao.DeleteRecord(record)
+				if err != nil {
+					return util.NewErrorResponse(err, "Failed to delete permission")
+				}
+				return audit.Log(txDao, c, audit.Entry{
+					Action:      "permission_deleted",
+					TargetTable: names.TablePermissions,
+					TargetId:    record.Id,
+					Before:      audit.Snapshot(record),
+				})
+			})
+			if err != nil {
+				return err
+			}
+			c.Response().Header().Set("Cache-Control", "no-store")
+			return c.String(http.StatusOK, "Deleted")
+		},
+	})
+	return err
+}
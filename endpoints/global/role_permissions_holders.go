@@ -0,0 +1,95 @@
+package global
+
+import (
+	"AREDL/middlewares"
+	"AREDL/names"
+	"AREDL/util"
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/tools/list"
+	"net/http"
+)
+
+type PermissionHolder struct {
+	Id         string   `json:"id"`
+	GlobalName string   `json:"global_name"`
+	Roles      []string `json:"roles"`
+}
+
+type PermissionHolders struct {
+	// Roles are the roles that have the permission, including roles inheriting it
+	Roles []string `json:"roles"`
+	// Everyone is set if the permission is given to the default role, so every user holds it
+	Everyone bool               `json:"everyone"`
+	Users    []PermissionHolder `json:"users"`
+}
+
+// registerPermissionHoldersEndpoint godoc
+//
+//	@Summary		Users with a permission
+//	@Description	Lists every role that has the permission, directly or inherited from a parent, and every user with one of these roles. Used to audit who has access to an action
+//	@Description	Requires user permission: permission_holders_view
+//	@Security		ApiKeyAuth
+//	@Tags			global
+//	@Param			list	path	string	true	"list the permission belongs to, global for permissions that are not bound to a list"
+//	@Param			action	path	string	true	"action of the permission"
+//	@Schemes		http https
+//	@Produce		json
+//	@Success		200	{object}	PermissionHolders
+//	@Failure		400	{object}	util.ErrorResponse
+//	@Failure		403	{object}	util.ErrorResponse
+//	@Router			/permissions/{list}/{action}/users [get]
+func registerPermissionHoldersEndpoint(e *echo.Group, app core.App) error {
+	_, err := e.AddRoute(echo.Route{
+		Method: http.MethodGet,
+		Path:   "/permissions/:list/:action/users",
+		Middlewares: []echo.MiddlewareFunc{
+			apis.ActivityLogger(app),
+			middlewares.CheckBanned(),
+			middlewares.RequirePermissionGroup(app, "", "permission_holders_view"),
+			middlewares.LoadParam(middlewares.LoadData{
+				"list":   middlewares.LoadString(true),
+				"action": middlewares.LoadString(true),
+			}),
+		},
+		Handler: func(c echo.Context) error {
+			err := app.Dao().RunInTransaction(func(txDao *daos.Dao) error {
+				roles, err := middlewares.GetRolesWithPermission(txDao, c.Get("list").(string), c.Get("action").(string))
+				if err != nil {
+					return util.NewErrorResponse(err, "Failed to load permissions")
+				}
+				result := PermissionHolders{Roles: roles, Users: []PermissionHolder{}}
+				result.Everyone = list.ExistInSlice(middlewares.DefaultRole, result.Roles)
+
+				var rows []struct {
+					Id         string `db:"id"`
+					GlobalName string `db:"global_name"`
+					Role       string `db:"role"`
+				}
+				err = txDao.DB().Select("u.id", "u.global_name", "r.role").
+					From(names.TableRoles+" r").
+					InnerJoin(names.TableUsers+" u", dbx.NewExp("u.id = r.user")).
+					Where(dbx.In("r.role", list.ToInterfaceSlice(result.Roles)...)).
+					OrderBy("u.global_name", "u.id", "r.role").
+					All(&rows)
+				if err != nil {
+					return util.NewErrorResponse(err, "Failed to load users")
+				}
+				for _, row := range rows {
+					if last := len(result.Users) - 1; last >= 0 && result.Users[last].Id == row.Id {
+						result.Users[last].Roles = append(result.Users[last].Roles, row.Role)
+						continue
+					}
+					result.Users = append(result.Users, PermissionHolder{Id: row.Id, GlobalName: row.GlobalName, Roles: []string{row.Role}})
+				}
+				c.Response().Header().Set("Cache-Control", "no-store")
+				return c.JSON(http.StatusOK, result)
+			})
+			return err
+		},
+	})
+	return err
+}
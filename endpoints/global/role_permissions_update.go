@@ -0,0 +1,122 @@
+package global
+
+import (
+	"AREDL/audit"
+	"AREDL/middlewares"
+	"AREDL/names"
+	"AREDL/util"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/forms"
+	"net/http"
+)
+
+// registerRolePermissionUpdateEndpoint godoc
+//
+//	@Summary		Update role permission
+//	@Description	Changes a permission, fields that are not given stay the same
+//	@Description	Requires user permission: manage_roles
+//	@Description	Additionally the user needs to be able to grant the permission before and after the change, see create role permission
+//	@Security		ApiKeyAuth
+//	@Tags			global
+//	@Param			id					path	string		true	"permission id"
+//	@Param			roles				query	[]string	false	"roles that get the permission"
+//	@Param			list				query	string		false	"list the permission belongs to, global for permissions that are not bound to a list"
+//	@Param			action				query	string		false	"action of the permission"
+//	@Param			affected_roles		query	[]string	false	"roles of the users the permission can be used on"
+//	@Param			affects_lower_ranks	query	bool		false	"the permission can also be used on users whose roles are ranked below the role of the user using it"
+//	@Schemes		http https
+//	@Produce		json
+//	@Success		200	{object}	RolePermission
+//	@Failure		400	{object}	util.ErrorResponse
+//	@Failure		403	{object}	util.ErrorResponse
+//	@Router			/permissions/{id} [patch]
+func registerRolePermissionUpdateEndpoint(e *echo.Group, app core.App) error {
+	_, err := e.AddRoute(echo.Route{
+		Method: http.MethodPatch,
+		Path:   "/permissions/:id",
+		Middlewares: []echo.MiddlewareFunc{
+			apis.ActivityLogger(app),
+			middlewares.CheckBanned(),
+			middlewares.RequirePermissionGroup(app, "", "manage_roles"),
+			middlewares.LoadParam(middlewares.LoadData{
+				"id":                  middlewares.LoadString(true),
+				"roles":               middlewares.LoadStringArray(false),
+				"list":                middlewares.LoadString(false, validation.In(permissionListNames()...)),
+				"action":              middlewares.LoadString(false, validation.Length(1, 100)),
+				"affected_roles":      middlewares.LoadStringArray(false),
+				"affects_lower_ranks": middlewares.LoadBool(false),
+			}),
+		},
+		Handler: func(c echo.Context) error {
+			var result RolePermission
+			err := app.Dao().RunInTransaction(func(txDao *daos.Dao) error {
+				record, err := txDao.FindRecordById(names.TablePermissions, c.Get("id").(string))
+				if err != nil {
+					return util.NewErrorResponse(err, "Permission not found")
+				}
+				permission := rolePermissionFromRecord(record)
+				err = checkCanGrant(c, txDao, permission)
+				if err != nil {
+					return err
+				}
+				if c.Get("roles") != nil {
+					permission.Roles = c.Get("roles").([]string)
+					if len(permission.Roles) == 0 {
+						return util.NewErrorResponse(nil, "roles can't be empty")
+					}
+				}
+				if c.Get("list") != nil {
+					permission.List = c.Get("list").(string)
+				}
+				if c.Get("action") != nil {
+					permission.Action = c.Get("action").(string)
+				}
+				if c.Get("affected_roles") != nil {
+					permission.AffectedRoles = c.Get("affected_roles").([]string)
+				}
+				if c.Get("affects_lower_ranks") != nil {
+					permission.AffectsLowerRanks = c.Get("affects_lower_ranks").(bool)
+				}
+				err = checkCanGrant(c, txDao, permission)
+				if err != nil {
+					return err
+				}
+				before := audit.Snapshot(record)
+				form := forms.NewRecordUpsert(app, record)
+				form.SetDao(txDao)
+				err = form.LoadData(map[string]any{
+					"role":                permission.Roles,
+					"list":                permission.List,
+					"action":              permission.Action,
+					"affected_roles":      permission.AffectedRoles,
+					"affects_lower_ranks": permission.AffectsLowerRanks,
+				})
+				if err != nil {
+					return util.NewErrorResponse(err, "Failed to load permission data")
+				}
+				err = form.Submit()
+				if err != nil {
+					return util.NewErrorResponse(err, "Failed to update permission")
+				}
+				result = rolePermissionFromRecord(record)
+				return audit.Log(txDao, c, audit.Entry{
+					Action:      "permission_updated",
+					TargetTable: names.TablePermissions,
+					TargetId:    record.Id,
+					Before:      before,
+					After:       audit.Snapshot(record),
+				})
+			})
+			if err != nil {
+				return err
+			}
+			c.Response().Header().Set("Cache-Control", "no-store")
+			return c.JSON(http.StatusOK, result)
+		},
+	})
+	return err
+}
@@ -0,0 +1,91 @@
+package global
+
+import (
+	"AREDL/middlewares"
+	"AREDL/util"
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/tools/list"
+	"net/http"
+)
+
+// registerRoleListEndpoint godoc
+//
+//	@Summary		List roles
+//	@Description	Lists all roles with their place in the hierarchy. The default role is given to every user.
+//	@Description	A role has every permission of its parent and the parents of it. Permissions that affect lower ranks can be used on every role with a lower rank than the highest ranked role of the user
+//	@Description	Requires user permission: manage_roles
+//	@Security		ApiKeyAuth
+//	@Tags			global
+//	@Schemes		http https
+//	@Produce		json
+//	@Success		200	{object}	[]middlewares.Role
+//	@Failure		400	{object}	util.ErrorResponse
+//	@Failure		403	{object}	util.ErrorResponse
+//	@Router			/roles [get]
+func registerRoleListEndpoint(e *echo.Group, app core.App) error {
+	_, err := e.AddRoute(echo.Route{
+		Method: http.MethodGet,
+		Path:   "/roles",
+		Middlewares: []echo.MiddlewareFunc{
+			apis.ActivityLogger(app),
+			middlewares.CheckBanned(),
+			middlewares.RequirePermissionGroup(app, "", "manage_roles"),
+		},
+		Handler: func(c echo.Context) error {
+			roles, err := middlewares.GetRoleHierarchy(app.Dao())
+			if err != nil {
+				return util.NewErrorResponse(err, "Failed to load roles")
+			}
+			c.Response().Header().Set("Cache-Control", "no-store")
+			return c.JSON(http.StatusOK, roles)
+		},
+	})
+	return err
+}
+
+// isAdmin checks if the request is authenticated as an admin, admins are not bound to the role hierarchy
+func isAdmin(c echo.Context) bool {
+	admin, _ := c.Get(apis.ContextAdminKey).(*models.Admin)
+	return admin != nil
+}
+
+// canManageRole checks if the authenticated admin or user can change the role
+func canManageRole(c echo.Context, role string) bool {
+	return isAdmin(c) || middlewares.CanAffectRole(c, []string{role})
+}
+
+// checkRoleHierarchy checks if the authenticated user can give a role the rank and parent.
+// The rank has to be below the rank of the user and the user needs to be able to affect the parent, admins can use any rank and parent
+func checkRoleHierarchy(c echo.Context, dao *daos.Dao, rank int, parent string) error {
+	if !isAdmin(c) {
+		userRecord, _ := c.Get(apis.ContextAuthRecordKey).(*models.Record)
+		if userRecord == nil {
+			return util.NewErrorResponse(nil, "User not found")
+		}
+		userRank, err := middlewares.GetUserRank(dao, userRecord.Id)
+		if err != nil {
+			return util.NewErrorResponse(err, "Failed to load roles")
+		}
+		if rank >= userRank {
+			return util.NewErrorResponse(nil, "The rank has to be below your own rank")
+		}
+	}
+	if parent == "" {
+		return nil
+	}
+	roles, err := middlewares.GetRoles(dao)
+	if err != nil {
+		return util.NewErrorResponse(err, "Failed to load roles")
+	}
+	if !list.ExistInSlice(parent, roles) {
+		return util.NewErrorResponse(nil, "Parent role not found")
+	}
+	if !canManageRole(c, parent) {
+		return util.NewErrorResponse(nil, "Not allowed to inherit from the given role")
+	}
+	return nil
+}
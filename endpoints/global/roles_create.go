@@ -0,0 +1,100 @@
+package global
+
+import (
+	"AREDL/audit"
+	"AREDL/middlewares"
+	"AREDL/names"
+	"AREDL/util"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/tools/list"
+	"net/http"
+	"regexp"
+)
+
+var roleNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
+
+// registerRoleCreateEndpoint godoc
+//
+//	@Summary		Create role
+//	@Description	Creates a new role without permissions. The creator can assign the role and manage its permissions afterward
+//	@Description	Additionally the user needs to be able to affect the parent with their permission
+//	@Description	Admins are not bound to the role hierarchy
+//	@Description	Requires user permission: manage_roles
+//	@Security		ApiKeyAuth
+//	@Tags			global
+//	@Param			name	query	string	true	"name of the role, only letters, digits and underscores"
+//	@Param			rank	query	int		false	"rank of the role, has to be below your own rank"	default(0)
+//	@Param			parent	query	string	false	"role to inherit the permissions from"
+//	@Schemes		http https
+//	@Produce		json
+//	@Success		200
+//	@Failure		400	{object}	util.ErrorResponse
+//	@Failure		403	{object}	util.ErrorResponse
+//	@Router			/roles [post]
+func registerRoleCreateEndpoint(e *echo.Group, app core.App) error {
+	_, err := e.AddRoute(echo.Route{
+		Method: http.MethodPost,
+		Path:   "/roles",
+		Middlewares: []echo.MiddlewareFunc{
+			apis.ActivityLogger(app),
+			middlewares.CheckBanned(),
+			middlewares.RequirePermissionGroup(app, "", "manage_roles"),
+			middlewares.LoadParam(middlewares.LoadData{
+				"name":   middlewares.LoadString(true, validation.Length(1, 50), validation.Match(roleNamePattern)),
+				"rank":   middlewares.AddDefault(0, middlewares.LoadInt(false)),
+				"parent": middlewares.AddDefault("", middlewares.LoadString(false)),
+			}),
+		},
+		Handler: func(c echo.Context) error {
+			err := app.Dao().RunInTransaction(func(txDao *daos.Dao) error {
+				// admins create roles without a user, the role is not added to the permissions of anyone
+				creatorId := ""
+				if !isAdmin(c) {
+					userRecord, _ := c.Get(apis.ContextAuthRecordKey).(*models.Record)
+					if userRecord == nil {
+						return util.NewErrorResponse(nil, "User not found")
+					}
+					creatorId = userRecord.Id
+				}
+				role := c.Get("name").(string)
+				roles, err := middlewares.GetRoles(txDao)
+				if err != nil {
+					return util.NewErrorResponse(err, "Failed to load roles")
+				}
+				if list.ExistInSlice(role, roles) {
+					return util.NewErrorResponse(nil, "Role already exists")
+				}
+				rank, parent := c.Get("rank").(int), c.Get("parent").(string)
+				err = checkRoleHierarchy(c, txDao, rank, parent)
+				if err != nil {
+					return err
+				}
+				err = middlewares.CreateRole(txDao, creatorId, role)
+				if err != nil {
+					return util.NewErrorResponse(err, "Failed to create role")
+				}
+				err = middlewares.SetRoleHierarchy(txDao, role, rank, parent)
+				if err != nil {
+					return util.NewErrorResponse(err, "Failed to set role hierarchy")
+				}
+				return audit.Log(txDao, c, audit.Entry{
+					Action:      "role_created",
+					TargetTable: names.TableRoles,
+					TargetId:    role,
+					After:       map[string]any{"role": role, "rank": rank, "parent": parent},
+				})
+			})
+			if err != nil {
+				return err
+			}
+			c.Response().Header().Set("Cache-Control", "no-store")
+			return c.String(http.StatusOK, "Created")
+		},
+	})
+	return err
+}
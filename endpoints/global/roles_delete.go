@@ -0,0 +1,90 @@
+package global
+
+import (
+	"AREDL/audit"
+	"AREDL/middlewares"
+	"AREDL/names"
+	"AREDL/util"
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/tools/list"
+	"net/http"
+)
+
+// registerRoleDeleteEndpoint godoc
+//
+//	@Summary		Delete role
+//	@Description	Deletes a role and removes it from every user. Permissions that only belong to the role are deleted. The default role cannot be deleted
+//	@Description	Requires user permission: manage_roles
+//	@Description	Additionally the user needs to be able to affect the role with their permission
+//	@Description	Admins are not bound to the role hierarchy
+//	@Security		ApiKeyAuth
+//	@Tags			global
+//	@Param			role	path	string	true	"name of the role"
+//	@Schemes		http https
+//	@Produce		json
+//	@Success		200
+//	@Failure		400	{object}	util.ErrorResponse
+//	@Failure		403	{object}	util.ErrorResponse
+//	@Router			/roles/{role} [delete]
+func registerRoleDeleteEndpoint(e *echo.Group, app core.App) error {
+	_, err := e.AddRoute(echo.Route{
+		Method: http.MethodDelete,
+		Path:   "/roles/:role",
+		Middlewares: []echo.MiddlewareFunc{
+			apis.ActivityLogger(app),
+			middlewares.CheckBanned(),
+			middlewares.RequirePermissionGroup(app, "", "manage_roles"),
+			middlewares.LoadParam(middlewares.LoadData{
+				"role": middlewares.LoadString(true),
+			}),
+		},
+		Handler: func(c echo.Context) error {
+			err := app.Dao().RunInTransaction(func(txDao *daos.Dao) error {
+				role := c.Get("role").(string)
+				roles, err := middlewares.GetRoles(txDao)
+				if err != nil {
+					return util.NewErrorResponse(err, "Failed to load roles")
+				}
+				if !list.ExistInSlice(role, roles) {
+					return util.NewErrorResponse(nil, "Role not found")
+				}
+				if role == middlewares.DefaultRole {
+					return util.NewErrorResponse(nil, "The default role cannot be deleted")
+				}
+				if !canManageRole(c, role) {
+					return util.NewErrorResponse(nil, "Not allowed to delete the given role")
+				}
+				// the users and permissions of the role are kept in the snapshot so it can be restored
+				var users []string
+				err = txDao.DB().Select("user").From(names.TableRoles).Where(dbx.HashExp{"role": role}).Column(&users)
+				if err != nil {
+					return util.NewErrorResponse(err, "Failed to load users of the role")
+				}
+				permissions, err := loadRolePermissions(txDao, role)
+				if err != nil {
+					return util.NewErrorResponse(err, "Failed to load permissions")
+				}
+				err = middlewares.DeleteRole(txDao, role)
+				if err != nil {
+					return util.NewErrorResponse(err, "Failed to delete role")
+				}
+				return audit.Log(txDao, c, audit.Entry{
+					Action:      "role_deleted",
+					TargetTable: names.TableRoles,
+					TargetId:    role,
+					Before:      map[string]any{"role": role, "users": users, "permissions": permissions},
+				})
+			})
+			if err != nil {
+				return err
+			}
+			c.Response().Header().Set("Cache-Control", "no-store")
+			return c.String(http.StatusOK, "Deleted")
+		},
+	})
+	return err
+}
@@ -0,0 +1,112 @@
+package global
+
+import (
+	"AREDL/audit"
+	"AREDL/middlewares"
+	"AREDL/names"
+	"AREDL/util"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
+	"net/http"
+	"slices"
+)
+
+// registerRoleUpdateEndpoint godoc
+//
+//	@Summary		Update role
+//	@Description	Renames a role or changes its place in the hierarchy, fields that are not given stay the same. Users and permissions keep a renamed role under its new name. The default role cannot be renamed
+//	@Description	Requires user permission: manage_roles
+//	@Description	Additionally the user needs to be able to affect the role and its new parent with their permission
+//	@Description	Admins are not bound to the role hierarchy
+//	@Security		ApiKeyAuth
+//	@Tags			global
+//	@Param			role	path	string	true	"name of the role"
+//	@Param			name	query	string	false	"new name of the role, only letters, digits and underscores"
+//	@Param			rank	query	int		false	"rank of the role, has to be below your own rank"
+//	@Param			parent	query	string	false	"role to inherit the permissions from, empty to remove the parent"
+//	@Schemes		http https
+//	@Produce		json
+//	@Success		200
+//	@Failure		400	{object}	util.ErrorResponse
+//	@Failure		403	{object}	util.ErrorResponse
+//	@Router			/roles/{role} [patch]
+func registerRoleUpdateEndpoint(e *echo.Group, app core.App) error {
+	_, err := e.AddRoute(echo.Route{
+		Method: http.MethodPatch,
+		Path:   "/roles/:role",
+		Middlewares: []echo.MiddlewareFunc{
+			apis.ActivityLogger(app),
+			middlewares.CheckBanned(),
+			middlewares.RequirePermissionGroup(app, "", "manage_roles"),
+			middlewares.LoadParam(middlewares.LoadData{
+				"role":   middlewares.LoadString(true),
+				"name":   middlewares.LoadString(false, validation.Length(1, 50), validation.Match(roleNamePattern)),
+				"rank":   middlewares.LoadInt(false),
+				"parent": middlewares.LoadString(false),
+			}),
+		},
+		Handler: func(c echo.Context) error {
+			err := app.Dao().RunInTransaction(func(txDao *daos.Dao) error {
+				role := c.Get("role").(string)
+				hierarchy, err := middlewares.GetRoleHierarchy(txDao)
+				if err != nil {
+					return util.NewErrorResponse(err, "Failed to load roles")
+				}
+				index := slices.IndexFunc(hierarchy, func(r middlewares.Role) bool { return r.Name == role })
+				if index == -1 {
+					return util.NewErrorResponse(nil, "Role not found")
+				}
+				if !canManageRole(c, role) {
+					return util.NewErrorResponse(nil, "Not allowed to change the given role")
+				}
+				before := hierarchy[index]
+				after := before
+				if c.Get("rank") != nil || c.Get("parent") != nil {
+					if c.Get("rank") != nil {
+						after.Rank = c.Get("rank").(int)
+					}
+					if c.Get("parent") != nil {
+						after.Parent = c.Get("parent").(string)
+					}
+					err = checkRoleHierarchy(c, txDao, after.Rank, after.Parent)
+					if err != nil {
+						return err
+					}
+					err = middlewares.SetRoleHierarchy(txDao, role, after.Rank, after.Parent)
+					if err != nil {
+						return util.NewErrorResponse(err, "Failed to change role hierarchy")
+					}
+				}
+				if c.Get("name") != nil && c.Get("name").(string) != role {
+					after.Name = c.Get("name").(string)
+					if role == middlewares.DefaultRole {
+						return util.NewErrorResponse(nil, "The default role cannot be renamed")
+					}
+					if slices.ContainsFunc(hierarchy, func(r middlewares.Role) bool { return r.Name == after.Name }) {
+						return util.NewErrorResponse(nil, "Role already exists")
+					}
+					err = middlewares.RenameRole(txDao, role, after.Name)
+					if err != nil {
+						return util.NewErrorResponse(err, "Failed to rename role")
+					}
+				}
+				return audit.Log(txDao, c, audit.Entry{
+					Action:      "role_updated",
+					TargetTable: names.TableRoles,
+					TargetId:    after.Name,
+					Before:      before,
+					After:       after,
+				})
+			})
+			if err != nil {
+				return err
+			}
+			c.Response().Header().Set("Cache-Control", "no-store")
+			return c.String(http.StatusOK, "Updated")
+		},
+	})
+	return err
+}
@@ -1,10 +1,12 @@
 package global
 
 import (
+	"AREDL/audit"
 	"AREDL/demonlist"
 	"AREDL/middlewares"
 	"AREDL/names"
 	"AREDL/util"
+	"AREDL/webhook"
 	"github.com/labstack/echo/v5"
 	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase/apis"
@@ -55,6 +57,13 @@ func registerBanAccountEndpoint(e *echo.Group, app core.App) error {
 				if !hasPermission {
 					return util.NewErrorResponse(err, "Cannot perform action on given user")
 				}
+				roles, err := middlewares.GetUserRoles(txDao, userRecord.Id)
+				if err != nil {
+					return util.NewErrorResponse(err, "Could not load user roles")
+				}
+				// banning removes every role, so they are kept in the snapshot
+				before := audit.Snapshot(userRecord)
+				before["roles"] = roles
 				userRecord.Set("banned_from_list", true)
 				err = txDao.SaveRecord(userRecord)
 				if err != nil {
@@ -64,12 +73,28 @@ func registerBanAccountEndpoint(e *echo.Group, app core.App) error {
 				if err != nil {
 					return util.NewErrorResponse(err, "Failed to delete user roles")
 				}
-				aredl := demonlist.Aredl()
-				err = demonlist.UpdateLeaderboardByUserIds(txDao, aredl, []interface{}{userRecord.Id})
+				for _, listData := range demonlist.Lists() {
+					err = demonlist.UpdateLeaderboardByUserIds(txDao, listData, []interface{}{userRecord.Id})
+					if err != nil {
+						return util.NewErrorResponse(err, "Failed to update leaderboard")
+					}
+				}
+				err = webhook.Emit(txDao, "", webhook.EventUserBanned, map[string]any{
+					"user": map[string]any{
+						"id":          userRecord.Id,
+						"global_name": userRecord.GetString("global_name"),
+					},
+				})
 				if err != nil {
-					return util.NewErrorResponse(err, "Failed to update leaderboard")
+					return err
 				}
-				return nil
+				return audit.Log(txDao, c, audit.Entry{
+					Action:      "user_banned",
+					TargetTable: names.TableUsers,
+					TargetId:    userRecord.Id,
+					Before:      before,
+					After:       audit.Snapshot(userRecord),
+				})
 			})
 			c.Response().Header().Set("Cache-Control", "no-store")
 			return err
@@ -1,12 +1,15 @@
 package global
 
 import (
+	"AREDL/audit"
 	"AREDL/demonlist"
 	"AREDL/middlewares"
+	"AREDL/names"
 	"AREDL/util"
 	"github.com/labstack/echo/v5"
 	"github.com/pocketbase/pocketbase/apis"
 	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
 	"net/http"
 )
 
@@ -39,7 +42,20 @@ func registerUserMergeEndpoint(e *echo.Group, app core.App) error {
 			}),
 		},
 		Handler: func(c echo.Context) error {
-			err := demonlist.MergeUsers(app.Dao(), c.Get("id").(string), c.Get("secondary_id").(string))
+			err := app.Dao().RunInTransaction(func(txDao *daos.Dao) error {
+				primaryId := c.Get("primary_id").(string)
+				before, after, err := mergeUsers(txDao, primaryId, c.Get("secondary_id").(string))
+				if err != nil {
+					return err
+				}
+				return audit.Log(txDao, c, audit.Entry{
+					Action:      "users_merged",
+					TargetTable: names.TableUsers,
+					TargetId:    primaryId,
+					Before:      before,
+					After:       after,
+				})
+			})
 			if err != nil {
 				return util.NewErrorResponse(err, "Failed to merge")
 			}
@@ -49,3 +65,29 @@ func registerUserMergeEndpoint(e *echo.Group, app core.App) error {
 	})
 	return err
 }
+
+// mergeUsers merges the secondary into the primary user.
+// It returns snapshots of both users before the merge and of the primary user after it for the audit log
+func mergeUsers(dao *daos.Dao, primaryId string, secondaryId string) (map[string]any, map[string]any, error) {
+	primaryUser, err := dao.FindRecordById(names.TableUsers, primaryId)
+	if err != nil {
+		return nil, nil, util.NewErrorResponse(err, "Could not find primary user")
+	}
+	secondaryUser, err := dao.FindRecordById(names.TableUsers, secondaryId)
+	if err != nil {
+		return nil, nil, util.NewErrorResponse(err, "Could not find secondary user")
+	}
+	before := map[string]any{
+		"primary":   audit.Snapshot(primaryUser),
+		"secondary": audit.Snapshot(secondaryUser),
+	}
+	err = demonlist.MergeUsers(dao, primaryId, secondaryId)
+	if err != nil {
+		return nil, nil, err
+	}
+	primaryUser, err = dao.FindRecordById(names.TableUsers, primaryId)
+	if err != nil {
+		return nil, nil, util.NewErrorResponse(err, "Could not find primary user")
+	}
+	return before, audit.Snapshot(primaryUser), nil
+}
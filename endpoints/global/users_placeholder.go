@@ -1,6 +1,7 @@
 package global
 
 import (
+	"AREDL/audit"
 	"AREDL/middlewares"
 	"AREDL/names"
 	"AREDL/util"
@@ -56,6 +57,15 @@ func registerCreatePlaceholderUser(e *echo.Group, app core.App) error {
 				if err != nil {
 					return util.NewErrorResponse(err, "Failed to create placeholder user")
 				}
+				err = audit.Log(txDao, c, audit.Entry{
+					Action:      "placeholder_created",
+					TargetTable: names.TableUsers,
+					TargetId:    createdUser.Id,
+					After:       audit.Snapshot(createdUser),
+				})
+				if err != nil {
+					return err
+				}
 				response.Id = createdUser.Id
 				c.Response().Header().Set("Cache-Control", "no-store")
 				return c.JSON(200, response)
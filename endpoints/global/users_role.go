@@ -1,6 +1,7 @@
 package global
 
 import (
+	"AREDL/audit"
 	"AREDL/middlewares"
 	"AREDL/names"
 	"AREDL/util"
@@ -10,7 +11,9 @@ import (
 	"github.com/pocketbase/pocketbase/core"
 	"github.com/pocketbase/pocketbase/daos"
 	"github.com/pocketbase/pocketbase/tools/list"
+	"github.com/pocketbase/pocketbase/tools/types"
 	"net/http"
+	"time"
 )
 
 // registerChangeRoleEndpoint godoc
@@ -19,10 +22,13 @@ import (
 //	@Description	Promote or demote a user
 //	@Description	Requires user permission: user_change_role
 //	@Description	Additionally the user needs to be able to affect the user with their permission and give the user the new role
+//	@Description	Roles that are added can be limited to a time range with starts and expires, roles the user already has keep their time range
 //	@Security		ApiKeyAuth
 //	@Tags			global
 //	@Param			id		path	string		true	"internal user id"
 //	@Param			roles	query	[]string	true	"new roles"
+//	@Param			starts	query	string		false	"time the added roles start, format: 2006-01-02 or 2006-01-02 15:04:05"
+//	@Param			expires	query	string		false	"time the added roles expire, format: 2006-01-02 or 2006-01-02 15:04:05"
 //	@Schemes		http https
 //	@Produce		json
 //	@Success		200
@@ -38,8 +44,10 @@ func registerChangeRoleEndpoint(e *echo.Group, app core.App) error {
 			middlewares.CheckBanned(),
 			middlewares.RequirePermissionGroup(app, "", "user_change_role"),
 			middlewares.LoadParam(middlewares.LoadData{
-				"id":    middlewares.LoadString(true),
-				"roles": middlewares.LoadStringArray(true),
+				"id":      middlewares.LoadString(true),
+				"roles":   middlewares.LoadStringArray(true),
+				"starts":  middlewares.LoadString(false),
+				"expires": middlewares.LoadString(false),
 			}),
 		},
 		Handler: func(c echo.Context) error {
@@ -49,10 +57,16 @@ func registerChangeRoleEndpoint(e *echo.Group, app core.App) error {
 					return util.NewErrorResponse(err, "Could not find given user")
 				}
 
-				currentRoles, err := middlewares.GetUserRoles(txDao, userRecord.Id)
+				starts, expires, err := loadGrantTime(c)
+				if err != nil {
+					return err
+				}
+				// grants that did not start yet or are about to expire count as well, so they are not added twice
+				grants, err := middlewares.GetRoleGrants(txDao, userRecord.Id)
 				if err != nil {
 					return util.NewErrorResponse(err, "Could not load user roles")
 				}
+				currentRoles := append(util.MapSlice(grants, func(grant middlewares.RoleGrant) string { return grant.Role }), middlewares.DefaultRole)
 				if !middlewares.CanAffectRole(c, currentRoles) {
 					return util.NewErrorResponse(nil, "Not allowed to change the rank of the given user")
 				}
@@ -69,12 +83,18 @@ func registerChangeRoleEndpoint(e *echo.Group, app core.App) error {
 					return util.NewErrorResponse(err, "Failed to remove roles")
 				}
 				for _, role := range rolesToAdd {
-					_, err = txDao.DB().Insert(names.TableRoles, dbx.Params{"role": role, "user": userRecord.Id}).Execute()
+					_, err = txDao.DB().Insert(names.TableRoles, dbx.Params{"role": role, "user": userRecord.Id, "starts": starts, "expires": expires}).Execute()
 					if err != nil {
 						return util.NewErrorResponse(err, "Failed to add role")
 					}
 				}
-				return nil
+				return audit.Log(txDao, c, audit.Entry{
+					Action:      "roles_changed",
+					TargetTable: names.TableUsers,
+					TargetId:    userRecord.Id,
+					Before:      map[string]any{"roles": currentRoles},
+					After:       map[string]any{"roles": newRoles},
+				})
 			})
 			c.Response().Header().Set("Cache-Control", "no-store")
 			return err
@@ -82,3 +102,31 @@ func registerChangeRoleEndpoint(e *echo.Group, app core.App) error {
 	})
 	return err
 }
+
+// loadGrantTime parses the optional starts and expires params of a role grant. Empty values mean the grant is not limited
+func loadGrantTime(c echo.Context) (types.DateTime, types.DateTime, error) {
+	var starts, expires types.DateTime
+	var err error
+	if c.Get("starts") != nil {
+		starts, err = types.ParseDateTime(c.Get("starts"))
+		if err != nil {
+			return starts, expires, util.NewErrorResponse(err, "Invalid start time")
+		}
+	}
+	if c.Get("expires") != nil {
+		expires, err = types.ParseDateTime(c.Get("expires"))
+		if err != nil {
+			return starts, expires, util.NewErrorResponse(err, "Invalid expiry time")
+		}
+	}
+	if expires.IsZero() {
+		return starts, expires, nil
+	}
+	if !expires.Time().After(time.Now()) {
+		return starts, expires, util.NewErrorResponse(nil, "Expiry time has to be in the future")
+	}
+	if !starts.IsZero() && !expires.Time().After(starts.Time()) {
+		return starts, expires, util.NewErrorResponse(nil, "Expiry time has to be after the start time")
+	}
+	return starts, expires, nil
+}
@@ -0,0 +1,104 @@
+package global
+
+import (
+	"AREDL/audit"
+	"AREDL/middlewares"
+	"AREDL/names"
+	"AREDL/util"
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
+	"net/http"
+)
+
+// registerChangeRoleGrantEndpoint godoc
+//
+//	@Summary		Change role time range
+//	@Description	Changes the time range of a role the user has. Empty values remove the limit, so an empty expiry makes the role permanent
+//	@Description	Requires user permission: user_change_role
+//	@Description	Additionally the user needs to be able to affect the user and the role with their permission
+//	@Security		ApiKeyAuth
+//	@Tags			global
+//	@Param			id		path	string	true	"internal user id"
+//	@Param			role	path	string	true	"role of the user"
+//	@Param			starts	query	string	false	"time the role starts, format: 2006-01-02 or 2006-01-02 15:04:05"
+//	@Param			expires	query	string	false	"time the role expires, format: 2006-01-02 or 2006-01-02 15:04:05"
+//	@Schemes		http https
+//	@Produce		json
+//	@Success		200	{object}	middlewares.RoleGrant
+//	@Failure		400	{object}	util.ErrorResponse
+//	@Failure		403	{object}	util.ErrorResponse
+//	@Router			/users/{id}/role/{role} [patch]
+func registerChangeRoleGrantEndpoint(e *echo.Group, app core.App) error {
+	_, err := e.AddRoute(echo.Route{
+		Method: http.MethodPatch,
+		Path:   "/users/:id/role/:role",
+		Middlewares: []echo.MiddlewareFunc{
+			apis.ActivityLogger(app),
+			middlewares.CheckBanned(),
+			middlewares.RequirePermissionGroup(app, "", "user_change_role"),
+			middlewares.LoadParam(middlewares.LoadData{
+				"id":      middlewares.LoadString(true),
+				"role":    middlewares.LoadString(true),
+				"starts":  middlewares.LoadString(false),
+				"expires": middlewares.LoadString(false),
+			}),
+		},
+		Handler: func(c echo.Context) error {
+			var result middlewares.RoleGrant
+			err := app.Dao().RunInTransaction(func(txDao *daos.Dao) error {
+				userRecord, err := txDao.FindRecordById(names.TableUsers, c.Get("id").(string))
+				if err != nil {
+					return util.NewErrorResponse(err, "Could not find given user")
+				}
+				canAffect, err := middlewares.CanAffectUser(c, txDao, userRecord.Id)
+				if err != nil {
+					return util.NewErrorResponse(err, "Could not load user roles")
+				}
+				role := c.Get("role").(string)
+				if !canAffect || !middlewares.CanAffectRole(c, []string{role}) {
+					return util.NewErrorResponse(nil, "Not allowed to change the role of the given user")
+				}
+				starts, expires, err := loadGrantTime(c)
+				if err != nil {
+					return err
+				}
+				grants, err := middlewares.GetRoleGrants(txDao, userRecord.Id)
+				if err != nil {
+					return util.NewErrorResponse(err, "Could not load user roles")
+				}
+				var before *middlewares.RoleGrant
+				for i := range grants {
+					if grants[i].Role == role {
+						before = &grants[i]
+					}
+				}
+				if before == nil {
+					return util.NewErrorResponse(nil, "The user does not have the given role")
+				}
+				_, err = txDao.DB().Update(names.TableRoles,
+					dbx.Params{"starts": starts, "expires": expires},
+					dbx.HashExp{"user": userRecord.Id, "role": role}).Execute()
+				if err != nil {
+					return util.NewErrorResponse(err, "Failed to change role")
+				}
+				result = middlewares.RoleGrant{Role: role, Starts: starts, Expires: expires}
+				return audit.Log(txDao, c, audit.Entry{
+					Action:      "role_grant_changed",
+					TargetTable: names.TableUsers,
+					TargetId:    userRecord.Id,
+					Before:      *before,
+					After:       result,
+				})
+			})
+			if err != nil {
+				return err
+			}
+			c.Response().Header().Set("Cache-Control", "no-store")
+			return c.JSON(http.StatusOK, result)
+		},
+	})
+	return err
+}
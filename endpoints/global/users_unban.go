@@ -1,6 +1,7 @@
 package global
 
 import (
+	"AREDL/audit"
 	"AREDL/demonlist"
 	"AREDL/middlewares"
 	"AREDL/names"
@@ -50,17 +51,25 @@ func registerUnbanAccountEndpoint(e *echo.Group, app core.App) error {
 				if err != nil {
 					return util.NewErrorResponse(err, "Failed to unban user")
 				}
+				before := audit.Snapshot(userRecord)
 				userRecord.Set("banned_from_list", false)
 				err = txDao.SaveRecord(userRecord)
 				if err != nil {
 					return util.NewErrorResponse(err, "Failed to unban user")
 				}
-				aredl := demonlist.Aredl()
-				err = demonlist.UpdateLeaderboardByUserIds(txDao, aredl, []interface{}{userRecord.Id})
-				if err != nil {
-					return util.NewErrorResponse(err, "Failed to update leaderboard")
+				for _, listData := range demonlist.Lists() {
+					err = demonlist.UpdateLeaderboardByUserIds(txDao, listData, []interface{}{userRecord.Id})
+					if err != nil {
+						return util.NewErrorResponse(err, "Failed to update leaderboard")
+					}
 				}
-				return nil
+				return audit.Log(txDao, c, audit.Entry{
+					Action:      "user_unbanned",
+					TargetTable: names.TableUsers,
+					TargetId:    userRecord.Id,
+					Before:      before,
+					After:       audit.Snapshot(userRecord),
+				})
 			})
 			c.Response().Header().Set("Cache-Control", "no-store")
 			return err
@@ -12,6 +12,7 @@ require (
 	github.com/swaggo/swag v1.16.3
 	gopkg.in/Knetic/govaluate.v2 v2.3.0
 	modernc.org/mathutil v1.6.0
+	modernc.org/sqlite v1.29.2
 )
 
 require (
@@ -108,7 +109,6 @@ require (
 	modernc.org/gc/v3 v3.0.0-20240304020402-f0dba7c97c2b // indirect
 	modernc.org/libc v1.41.0 // indirect
 	modernc.org/memory v1.7.2 // indirect
-	modernc.org/sqlite v1.29.2 // indirect
 	modernc.org/strutil v1.2.0 // indirect
 	modernc.org/token v1.1.0 // indirect
 )
@@ -5,7 +5,9 @@ import (
 	"AREDL/edel"
 	"AREDL/endpoints/aredl"
 	"AREDL/endpoints/global"
+	"AREDL/middlewares"
 	"AREDL/migration"
+	"AREDL/webhook"
 	"github.com/Simolater/echo-swagger"
 	"github.com/pocketbase/pocketbase"
 	"github.com/pocketbase/pocketbase/core"
@@ -31,6 +33,10 @@ import (
 func main() {
 	app := pocketbase.New()
 
+	if err := demonlist.RegisterConfiguredLists(); err != nil {
+		log.Fatal(err)
+	}
+
 	migration.Register(app)
 	edel.Register(app)
 
@@ -45,8 +51,16 @@ func main() {
 	aredl.RegisterEndpoints(app)
 
 	RegisterUserAuth(app)
+	middlewares.RegisterPermissionCache(app)
+	middlewares.RegisterRoleExpiry(app)
+	middlewares.RegisterRateLimits(app)
 
 	demonlist.RegisterUpdatePoints(app)
+	demonlist.RegisterLiveEvents(app)
+	demonlist.RegisterSnapshots(app)
+	demonlist.RegisterLeaderboardCountries(app)
+
+	webhook.RegisterDispatcher(app)
 
 	if err := app.Start(); err != nil {
 		log.Fatal(err)
@@ -101,6 +101,28 @@ func LoadStringArray(required bool, rules ...validation.Rule) LoadFunc {
 	}
 }
 
+// LoadJson parses the parameter as JSON into a value of type T
+func LoadJson[T any](required bool) LoadFunc {
+	return func(key string, params map[string][]string) (interface{}, error) {
+		if _, exists := params[key]; !exists {
+			if required {
+				return nil, util.NewErrorResponse(nil, fmt.Sprintf("%s can't be empty", key))
+			} else {
+				return nil, nil
+			}
+		}
+		if len(params[key]) != 1 {
+			return nil, util.NewErrorResponse(nil, fmt.Sprintf("%s has to hold exactly one value", key))
+		}
+		var value T
+		err := json.Unmarshal([]byte(params[key][0]), &value)
+		if err != nil {
+			return nil, util.NewErrorResponse(nil, fmt.Sprintf("%s: could not parse json", key))
+		}
+		return value, nil
+	}
+}
+
 func LoadMap(prefix string, toLoad LoadData) LoadFunc {
 	return func(_ string, params map[string][]string) (interface{}, error) {
 		valueMap := make(map[string]interface{})
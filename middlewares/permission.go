@@ -1,24 +1,33 @@
 package middlewares
 
 import (
-	"AREDL/names"
+	"AREDL/apikey"
 	"AREDL/util"
+	"errors"
 	"fmt"
 	"github.com/labstack/echo/v5"
-	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase/apis"
 	"github.com/pocketbase/pocketbase/core"
 	"github.com/pocketbase/pocketbase/daos"
 	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/tools/types"
 )
 
 const KeyAffectedRoles = "affected_groups"
 
+// KeyApiKeyScopes holds the scopes of the api key the request was authenticated with
+const KeyApiKeyScopes = "api_key_scopes"
+
 type PermissionData struct {
 	AffectedRoles []string `json:"affected_roles,omitempty"`
+	// AffectsLowerRanks is set if the affected roles include every role ranked below the user
+	AffectsLowerRanks bool `json:"affects_lower_ranks,omitempty"`
+	// Expires is the time the user loses the permission because their roles giving it expire. It is nil for permanent permissions
+	Expires *types.DateTime `json:"expires,omitempty"`
 }
 
 // RequirePermissionGroup checks if the authenticated user is an admin or has access to the given action.
+// Requests authenticated with an api key also need the action to be part of the scopes of the key.
 // Furthermore, it loads all roles the user can affect with the given action into the context using KeyAffectedGroups as key.
 func RequirePermissionGroup(app core.App, listName string, action string) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
@@ -33,12 +42,11 @@ func RequirePermissionGroup(app core.App, listName string, action string) echo.M
 			}
 
 			if apiKey != "" {
-				users, err := app.Dao().FindRecordsByExpr(names.TableUsers, dbx.HashExp{"api_key": apiKey})
-				if err != nil || len(users) != 1 {
-					return apis.NewForbiddenError("Api Key not found", nil)
+				var err error
+				record, err = authenticateApiKey(app, c, apiKey)
+				if err != nil {
+					return err
 				}
-				record = users[0]
-				c.Set(apis.ContextAuthRecordKey, record)
 			}
 
 			if record != nil {
@@ -49,6 +57,9 @@ func RequirePermissionGroup(app core.App, listName string, action string) echo.M
 				if !hasPermission {
 					return apis.NewForbiddenError("You are not allowed to access this endpoint", nil)
 				}
+				if !ApiKeyAllows(c, listName, action) {
+					return apis.NewForbiddenError("The api key is not allowed to access this endpoint", nil)
+				}
 				c.Set(KeyAffectedRoles, permissionData.AffectedRoles)
 			}
 			return next(c)
@@ -62,77 +73,78 @@ func LoadApiKey(app core.App) echo.MiddlewareFunc {
 			apiKey := c.Request().Header.Get("api-key")
 
 			if apiKey != "" {
-				users, err := app.Dao().FindRecordsByExpr(names.TableUsers, dbx.HashExp{"api_key": apiKey})
-				if err != nil || len(users) != 1 {
-					return apis.NewForbiddenError("Api Key not found", nil)
+				_, err := authenticateApiKey(app, c, apiKey)
+				if err != nil {
+					return err
 				}
-				record := users[0]
-				c.Set(apis.ContextAuthRecordKey, record)
 			}
 			return next(c)
 		}
 	}
 }
 
-func GetUserRoles(dao *daos.Dao, userId string) ([]string, error) {
-	type RoleData struct {
-		Role string `db:"role"`
+// authenticateApiKey loads the user of the api key and its scopes into the context
+func authenticateApiKey(app core.App, c echo.Context, apiKey string) (*models.Record, error) {
+	record, scopes, err := apikey.Authenticate(app.Dao(), apiKey)
+	if errors.Is(err, apikey.ErrExpired) {
+		return nil, apis.NewForbiddenError("Api Key expired", nil)
 	}
-	var roleData []RoleData
-	err := dao.DB().Select("role").From(names.TableRoles).Where(dbx.HashExp{"user": userId}).All(&roleData)
 	if err != nil {
-		return nil, err
+		return nil, apis.NewForbiddenError("Api Key not found", nil)
 	}
-	roles := util.MapSlice(roleData, func(v RoleData) string { return v.Role })
-	roles = append(roles, "default")
-	return roles, nil
+	c.Set(apis.ContextAuthRecordKey, record)
+	c.Set(KeyApiKeyScopes, scopes)
+	return record, nil
 }
 
-func GetPermission(dao *daos.Dao, userId string, list string, action string) (bool, PermissionData, error) {
-	roles, err := GetUserRoles(dao, userId)
+// ApiKeyAllows checks if the api key of the request can use the given permission. Requests without an api key are always allowed
+func ApiKeyAllows(c echo.Context, listName string, action string) bool {
+	scopes, _ := c.Get(KeyApiKeyScopes).([]string)
+	return apikey.Allows(scopes, listName, action)
+}
+
+// GetUserRoles returns the roles the user currently has, including the default role
+func GetUserRoles(dao *daos.Dao, userId string) ([]string, error) {
+	grants, err := GetRoleGrants(dao, userId)
 	if err != nil {
-		return false, PermissionData{}, err
+		return nil, err
 	}
+	return activeRoles(grants, types.NowDateTime()), nil
+}
+
+func activeRoles(grants []RoleGrant, at types.DateTime) []string {
+	var roles []string
+	for _, grant := range grants {
+		if grant.Active(at) {
+			roles = append(roles, grant.Role)
+		}
+	}
+	return append(roles, DefaultRole)
+}
+
+func GetPermission(dao *daos.Dao, userId string, list string, action string) (bool, PermissionData, error) {
 	if list == "" {
 		list = "global"
 	}
-	permissionRecords, err := dao.FindRecordsByExpr(names.TablePermissions, dbx.And(dbx.OrLike("role", roles...)), dbx.HashExp{"list": list, "action": action})
+	permissions, err := GetAllPermissions(dao, userId)
 	if err != nil {
 		return false, PermissionData{}, err
 	}
-	permissionDataMap := mergePermissions(permissionRecords)
-	key := fmt.Sprintf("%v.%v", list, action)
-	permissionData, exist := permissionDataMap[key]
+	permissionData, exist := permissions[fmt.Sprintf("%v.%v", list, action)]
 	return exist, permissionData, nil
 }
 
+// GetAllPermissions returns every permission of the user, including the permissions inherited from the parents of their roles
 func GetAllPermissions(dao *daos.Dao, userId string) (map[string]PermissionData, error) {
-	roles, err := GetUserRoles(dao, userId)
+	grants, err := GetRoleGrants(dao, userId)
 	if err != nil {
 		return nil, err
 	}
-	permissionRecords, err := dao.FindRecordsByExpr(names.TablePermissions, dbx.OrLike("role", roles...))
+	tables, err := loadPermissionTables(dao)
 	if err != nil {
 		return nil, err
 	}
-	return mergePermissions(permissionRecords), nil
-}
-
-func mergePermissions(permissionRecords []*models.Record) map[string]PermissionData {
-	result := map[string]PermissionData{}
-	for _, permission := range permissionRecords {
-		fullAction := fmt.Sprintf("%v.%v", permission.GetString("list"), permission.GetString("action"))
-		if permData, ok := result[fullAction]; ok {
-			result[fullAction] = PermissionData{
-				AffectedRoles: append(permData.AffectedRoles, permission.GetStringSlice("affected_roles")...),
-			}
-		} else {
-			result[fullAction] = PermissionData{
-				AffectedRoles: permission.GetStringSlice("affected_roles"),
-			}
-		}
-	}
-	return result
+	return tables.resolveGrants(grants, types.NowDateTime()), nil
 }
 
 func CanAffectUser(c echo.Context, dao *daos.Dao, userId string) (bool, error) {
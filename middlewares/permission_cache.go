@@ -0,0 +1,306 @@
+package middlewares
+
+import (
+	"AREDL/names"
+	"fmt"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/tools/list"
+	"github.com/pocketbase/pocketbase/tools/types"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Role is a role together with its place in the hierarchy.
+// A role has every permission of its parent and the parents of it. Permissions that affect lower ranks affect every role with a lower rank
+type Role struct {
+	Name   string `json:"name"`
+	Rank   int    `json:"rank"`
+	Parent string `json:"parent,omitempty"`
+}
+
+type rolePermission struct {
+	roles             []string
+	fullAction        string
+	affectedRoles     []string
+	affectsLowerRanks bool
+}
+
+// permissionTables holds all roles and permissions. Resolved permissions are cached per set of roles
+type permissionTables struct {
+	roles       []Role
+	ranks       map[string]int
+	parents     map[string]string
+	permissions []rolePermission
+
+	mutex    sync.Mutex
+	resolved map[string]map[string]PermissionData
+}
+
+var (
+	permissionCacheMutex      sync.Mutex
+	permissionCache           *permissionTables
+	permissionCacheGeneration int
+)
+
+// RegisterPermissionCache clears the permission cache whenever a role or permission changes
+func RegisterPermissionCache(app core.App) {
+	invalidate := func(e *core.ModelEvent) error {
+		InvalidatePermissionCache()
+		return nil
+	}
+	// role names are the options of the role fields, so collection changes can add or remove roles
+	tables := []string{names.TablePermissions, names.TableRoleDefinitions, "_collections"}
+	app.OnModelAfterCreate(tables...).Add(invalidate)
+	app.OnModelAfterUpdate(tables...).Add(invalidate)
+	app.OnModelAfterDelete(tables...).Add(invalidate)
+}
+
+// InvalidatePermissionCache clears the permission cache, the next permission check reloads all roles and permissions
+func InvalidatePermissionCache() {
+	permissionCacheMutex.Lock()
+	defer permissionCacheMutex.Unlock()
+	permissionCache = nil
+	permissionCacheGeneration++
+}
+
+// loadPermissionTables returns the cached roles and permissions. Transactions always load them,
+// so changes that are not committed yet are never cached
+func loadPermissionTables(dao *daos.Dao) (*permissionTables, error) {
+	_, inTransaction := dao.DB().(*dbx.Tx)
+	generation := 0
+	if !inTransaction {
+		permissionCacheMutex.Lock()
+		cached, currentGeneration := permissionCache, permissionCacheGeneration
+		permissionCacheMutex.Unlock()
+		if cached != nil {
+			return cached, nil
+		}
+		generation = currentGeneration
+	}
+	tables, err := readPermissionTables(dao)
+	if err != nil {
+		return nil, err
+	}
+	if !inTransaction {
+		permissionCacheMutex.Lock()
+		// the tables are outdated if the cache was invalidated while they were loaded
+		if generation == permissionCacheGeneration {
+			permissionCache = tables
+		}
+		permissionCacheMutex.Unlock()
+	}
+	return tables, nil
+}
+
+func readPermissionTables(dao *daos.Dao) (*permissionTables, error) {
+	roleNames, err := GetRoles(dao)
+	if err != nil {
+		return nil, err
+	}
+	var definitions []struct {
+		Role   string `db:"role"`
+		Rank   int    `db:"rank"`
+		Parent string `db:"parent"`
+	}
+	err = dao.DB().Select("role", "rank", "parent").From(names.TableRoleDefinitions).All(&definitions)
+	if err != nil {
+		return nil, err
+	}
+	permissionRecords, err := dao.FindRecordsByExpr(names.TablePermissions)
+	if err != nil {
+		return nil, err
+	}
+	tables := &permissionTables{
+		ranks:    map[string]int{},
+		parents:  map[string]string{},
+		resolved: map[string]map[string]PermissionData{},
+	}
+	for _, definition := range definitions {
+		tables.ranks[definition.Role] = definition.Rank
+		if definition.Parent != "" {
+			tables.parents[definition.Role] = definition.Parent
+		}
+	}
+	for _, role := range roleNames {
+		tables.roles = append(tables.roles, Role{Name: role, Rank: tables.ranks[role], Parent: tables.parents[role]})
+	}
+	for _, record := range permissionRecords {
+		tables.permissions = append(tables.permissions, rolePermission{
+			roles:             record.GetStringSlice("role"),
+			fullAction:        fmt.Sprintf("%v.%v", record.GetString("list"), record.GetString("action")),
+			affectedRoles:     record.GetStringSlice("affected_roles"),
+			affectsLowerRanks: record.GetBool("affects_lower_ranks"),
+		})
+	}
+	return tables, nil
+}
+
+// inheritedRoles returns the roles together with all their parents
+func (tables *permissionTables) inheritedRoles(roles []string) []string {
+	var result []string
+	for _, role := range roles {
+		for role != "" && !list.ExistInSlice(role, result) {
+			result = append(result, role)
+			role = tables.parents[role]
+		}
+	}
+	return result
+}
+
+// rank returns the highest rank of the roles
+func (tables *permissionTables) rank(roles []string) int {
+	rank := 0
+	for _, role := range roles {
+		rank = max(rank, tables.ranks[role])
+	}
+	return rank
+}
+
+// resolve returns the permissions of a user with the given roles. The result must not be modified
+func (tables *permissionTables) resolve(userRoles []string) map[string]PermissionData {
+	sortedRoles := slices.Clone(userRoles)
+	slices.Sort(sortedRoles)
+	key := strings.Join(sortedRoles, ",")
+	tables.mutex.Lock()
+	defer tables.mutex.Unlock()
+	if result, exists := tables.resolved[key]; exists {
+		return result
+	}
+
+	roles := tables.inheritedRoles(userRoles)
+	rank := tables.rank(userRoles)
+	var lowerRoles []string
+	for _, role := range tables.roles {
+		if role.Rank < rank {
+			lowerRoles = append(lowerRoles, role.Name)
+		}
+	}
+	result := map[string]PermissionData{}
+	for _, permission := range tables.permissions {
+		if !permission.hasAny(roles) {
+			continue
+		}
+		permissionData := result[permission.fullAction]
+		permissionData.AffectedRoles = append(permissionData.AffectedRoles, permission.affectedRoles...)
+		if permission.affectsLowerRanks {
+			permissionData.AffectsLowerRanks = true
+			permissionData.AffectedRoles = append(permissionData.AffectedRoles, lowerRoles...)
+		}
+		permissionData.AffectedRoles = list.ToUniqueStringSlice(permissionData.AffectedRoles)
+		result[permission.fullAction] = permissionData
+	}
+	tables.resolved[key] = result
+	return result
+}
+
+// hasAny checks if the permission belongs to one of the roles
+func (permission rolePermission) hasAny(roles []string) bool {
+	return slices.ContainsFunc(permission.roles, func(role string) bool { return list.ExistInSlice(role, roles) })
+}
+
+// resolveGrants returns the permissions of a user with the given role grants at the given time.
+// A permission that is only given by roles that expire expires together with the last of these roles
+func (tables *permissionTables) resolveGrants(grants []RoleGrant, at types.DateTime) map[string]PermissionData {
+	var permanent, expiring []RoleGrant
+	for _, grant := range grants {
+		if !grant.Active(at) {
+			continue
+		}
+		if grant.Expires.IsZero() {
+			permanent = append(permanent, grant)
+		} else {
+			expiring = append(expiring, grant)
+		}
+	}
+	permissions := maps.Clone(tables.resolve(activeRoles(append(slices.Clone(permanent), expiring...), at)))
+	if len(expiring) == 0 {
+		return permissions
+	}
+	permanentPermissions := tables.resolve(activeRoles(permanent, at))
+	for fullAction, permissionData := range permissions {
+		if _, exists := permanentPermissions[fullAction]; exists {
+			continue
+		}
+		var expires types.DateTime
+		for _, grant := range expiring {
+			if _, exists := tables.resolve([]string{grant.Role})[fullAction]; exists && grant.Expires.Time().After(expires.Time()) {
+				expires = grant.Expires
+			}
+		}
+		permissionData.Expires = &expires
+		permissions[fullAction] = permissionData
+	}
+	return permissions
+}
+
+// GetRoleHierarchy returns every role with its rank and parent
+func GetRoleHierarchy(dao *daos.Dao) ([]Role, error) {
+	tables, err := loadPermissionTables(dao)
+	if err != nil {
+		return nil, err
+	}
+	return slices.Clone(tables.roles), nil
+}
+
+// GetRolesWithPermission returns every role that has the permission, either directly or inherited from a parent
+func GetRolesWithPermission(dao *daos.Dao, listName string, action string) ([]string, error) {
+	if listName == "" {
+		listName = "global"
+	}
+	tables, err := loadPermissionTables(dao)
+	if err != nil {
+		return nil, err
+	}
+	fullAction := fmt.Sprintf("%v.%v", listName, action)
+	result := []string{}
+	for _, role := range tables.roles {
+		roles := tables.inheritedRoles([]string{role.Name})
+		if slices.ContainsFunc(tables.permissions, func(permission rolePermission) bool {
+			return permission.fullAction == fullAction && permission.hasAny(roles)
+		}) {
+			result = append(result, role.Name)
+		}
+	}
+	return result, nil
+}
+
+// GetUserRank returns the highest rank of the roles of the user
+func GetUserRank(dao *daos.Dao, userId string) (int, error) {
+	roles, err := GetUserRoles(dao, userId)
+	if err != nil {
+		return 0, err
+	}
+	tables, err := loadPermissionTables(dao)
+	if err != nil {
+		return 0, err
+	}
+	return tables.rank(roles), nil
+}
+
+// SetRoleHierarchy changes the rank and parent of the role. An empty parent removes the parent
+func SetRoleHierarchy(dao *daos.Dao, role string, rank int, parent string) error {
+	tables, err := loadPermissionTables(dao)
+	if err != nil {
+		return err
+	}
+	if parent != "" && list.ExistInSlice(role, tables.inheritedRoles([]string{parent})) {
+		return fmt.Errorf("%s cannot inherit from %s, it would inherit from itself", role, parent)
+	}
+	collection, err := dao.FindCollectionByNameOrId(names.TableRoleDefinitions)
+	if err != nil {
+		return err
+	}
+	record, err := dao.FindFirstRecordByData(names.TableRoleDefinitions, "role", role)
+	if err != nil {
+		record = models.NewRecord(collection)
+		record.Set("role", role)
+	}
+	record.Set("rank", rank)
+	record.Set("parent", parent)
+	return dao.SaveRecord(record)
+}
@@ -0,0 +1,157 @@
+package middlewares
+
+import (
+	"maps"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// testPermissionTables has the hierarchy admin > moderator > helper, member is ranked below helper without a parent
+func testPermissionTables(parents map[string]string) *permissionTables {
+	tables := &permissionTables{
+		roles: []Role{
+			{Name: "admin", Rank: 40},
+			{Name: "moderator", Rank: 30},
+			{Name: "helper", Rank: 20},
+			{Name: "member", Rank: 10},
+		},
+		ranks:   map[string]int{"admin": 40, "moderator": 30, "helper": 20, "member": 10},
+		parents: parents,
+		permissions: []rolePermission{
+			{roles: []string{"helper"}, fullAction: "aredl.submission_review"},
+			{roles: []string{"moderator"}, fullAction: "global.user_ban", affectedRoles: []string{"member"}},
+			{roles: []string{"moderator"}, fullAction: "global.user_change_role", affectedRoles: []string{"helper"}},
+			{roles: []string{"admin"}, fullAction: "global.user_change_role", affectsLowerRanks: true},
+			{roles: []string{"member", "helper"}, fullAction: "global.user_submit"},
+		},
+		resolved: map[string]map[string]PermissionData{},
+	}
+	for i := range tables.roles {
+		tables.roles[i].Parent = parents[tables.roles[i].Name]
+	}
+	return tables
+}
+
+var testRoleParents = map[string]string{"admin": "moderator", "moderator": "helper"}
+
+func TestInheritedRoles(t *testing.T) {
+	tests := []struct {
+		name    string
+		parents map[string]string
+		roles   []string
+		want    []string
+	}{
+		{name: "without parent", parents: testRoleParents, roles: []string{"member"}, want: []string{"member"}},
+		{name: "parent chain", parents: testRoleParents, roles: []string{"admin"}, want: []string{"admin", "moderator", "helper"}},
+		{name: "shared parents are added once", parents: testRoleParents, roles: []string{"moderator", "admin"}, want: []string{"moderator", "helper", "admin"}},
+		{name: "unknown role", parents: testRoleParents, roles: []string{"unknown"}, want: []string{"unknown"}},
+		{name: "cycle", parents: map[string]string{"admin": "moderator", "moderator": "admin"}, roles: []string{"admin"}, want: []string{"admin", "moderator"}},
+		{name: "self cycle", parents: map[string]string{"helper": "helper"}, roles: []string{"helper"}, want: []string{"helper"}},
+		{
+			name:    "cycle below the role",
+			parents: map[string]string{"admin": "moderator", "moderator": "helper", "helper": "moderator"},
+			roles:   []string{"admin"},
+			want:    []string{"admin", "moderator", "helper"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := testPermissionTables(test.parents).inheritedRoles(test.roles)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("inheritedRoles(%v) = %v, want %v", test.roles, got, test.want)
+			}
+		})
+	}
+}
+
+func TestPermissionTablesResolve(t *testing.T) {
+	tests := []struct {
+		name  string
+		roles []string
+		want  map[string]PermissionData
+	}{
+		{name: "without roles", roles: nil, want: map[string]PermissionData{}},
+		{name: "direct permission", roles: []string{"member"}, want: map[string]PermissionData{"global.user_submit": {}}},
+		{
+			name:  "inherited permissions",
+			roles: []string{"moderator"},
+			want: map[string]PermissionData{
+				"aredl.submission_review": {},
+				"global.user_ban":         {AffectedRoles: []string{"member"}},
+				"global.user_change_role": {AffectedRoles: []string{"helper"}},
+				"global.user_submit":      {},
+			},
+		},
+		{
+			name:  "lower ranks are merged with the affected roles",
+			roles: []string{"admin"},
+			want: map[string]PermissionData{
+				"aredl.submission_review": {},
+				"global.user_ban":         {AffectedRoles: []string{"member"}},
+				"global.user_change_role": {AffectedRoles: []string{"helper", "moderator", "member"}, AffectsLowerRanks: true},
+				"global.user_submit":      {},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := testPermissionTables(testRoleParents).resolve(test.roles)
+			if !equalPermissions(got, test.want) {
+				t.Errorf("resolve(%v) = %v, want %v", test.roles, got, test.want)
+			}
+		})
+	}
+}
+
+// equalPermissions compares permissions without distinguishing between empty and missing affected roles
+func equalPermissions(a map[string]PermissionData, b map[string]PermissionData) bool {
+	return maps.EqualFunc(a, b, func(x PermissionData, y PermissionData) bool {
+		return slices.Equal(x.AffectedRoles, y.AffectedRoles) && x.AffectsLowerRanks == y.AffectsLowerRanks && x.Expires == y.Expires
+	})
+}
+
+func TestPermissionTablesResolveWithCycle(t *testing.T) {
+	tables := testPermissionTables(map[string]string{"admin": "moderator", "moderator": "helper", "helper": "admin"})
+	got := tables.resolve([]string{"helper"})
+	for _, action := range []string{"aredl.submission_review", "global.user_ban", "global.user_change_role"} {
+		if _, exists := got[action]; !exists {
+			t.Errorf("resolve() is missing %s inherited through the cycle", action)
+		}
+	}
+}
+
+func TestPermissionTablesResolveCachesByRoleSet(t *testing.T) {
+	tables := testPermissionTables(testRoleParents)
+	first := tables.resolve([]string{"member", "helper"})
+	if len(tables.resolved) != 1 {
+		t.Fatalf("resolve() cached %d role sets, want 1", len(tables.resolved))
+	}
+	second := tables.resolve([]string{"helper", "member"})
+	if len(tables.resolved) != 1 || reflect.ValueOf(first).Pointer() != reflect.ValueOf(second).Pointer() {
+		t.Error("resolve() did not reuse the result of the same roles in a different order")
+	}
+	roles := []string{"member", "helper"}
+	tables.resolve(roles)
+	if !slices.Equal(roles, []string{"member", "helper"}) {
+		t.Errorf("resolve() changed the order of the given roles to %v", roles)
+	}
+}
+
+func TestPermissionTablesRank(t *testing.T) {
+	tables := testPermissionTables(testRoleParents)
+	tests := []struct {
+		roles []string
+		want  int
+	}{
+		{roles: nil, want: 0},
+		{roles: []string{"member"}, want: 10},
+		{roles: []string{"member", "moderator"}, want: 30},
+		{roles: []string{"unknown"}, want: 0},
+	}
+	for _, test := range tests {
+		if got := tables.rank(test.roles); got != test.want {
+			t.Errorf("rank(%v) = %v, want %v", test.roles, got, test.want)
+		}
+	}
+}
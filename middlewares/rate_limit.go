@@ -0,0 +1,282 @@
+package middlewares
+
+import (
+	"AREDL/apikey"
+	"AREDL/names"
+	"AREDL/util"
+	"errors"
+	"fmt"
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/models"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimitGroup is a request budget that is shared by all routes using the group.
+// Every api key, authenticated user and ip address has its own budget in every group
+type RateLimitGroup struct {
+	// Requests is the number of requests allowed per window
+	Requests int
+	// TrustedRequests is the number of requests allowed per window for trusted api keys
+	TrustedRequests int
+	Window          time.Duration
+
+	mutex     sync.Mutex
+	counters  map[string]*rateLimitCounter
+	lastSweep time.Time
+}
+
+type rateLimitCounter struct {
+	count int
+	reset time.Time
+}
+
+// cachedApiKey is an api key the rate limit looked up. The id is empty if the key does not exist
+type cachedApiKey struct {
+	id      string
+	trusted bool
+	expires time.Time
+}
+
+// apiKeyCache caches the api keys of the rate limit, so requests don't look up their key in the database every time
+type apiKeyCache struct {
+	mutex     sync.Mutex
+	entries   map[string]cachedApiKey
+	lastSweep time.Time
+}
+
+const (
+	// apiKeyCacheDuration is the time an api key stays cached
+	apiKeyCacheDuration = time.Minute
+	// apiKeyCacheUnknownLimit is the number of entries after which unknown keys are no longer cached
+	apiKeyCacheUnknownLimit = 10000
+)
+
+var (
+	// RateLimitDefault is used by the public list routes
+	RateLimitDefault = &RateLimitGroup{Requests: 120, TrustedRequests: 1200, Window: time.Minute}
+	// RateLimitExpensive is used by routes that are expensive to load and are requested a lot by bots, like the leaderboard and profiles
+	RateLimitExpensive = &RateLimitGroup{Requests: 30, TrustedRequests: 600, Window: time.Minute}
+
+	// rateLimitGroups are the groups that can be configured, by the name used in their environment variables
+	rateLimitGroups = map[string]*RateLimitGroup{
+		"DEFAULT":   RateLimitDefault,
+		"EXPENSIVE": RateLimitExpensive,
+	}
+	rateLimitApiKeys = &apiKeyCache{}
+	// rateLimitIP resolves the ip address requests without an api key or user are counted with
+	rateLimitIP = echo.ExtractIPDirect()
+)
+
+// RegisterRateLimits configures the rate limits with environment variables when the server starts.
+// AREDL_RATE_LIMIT_<GROUP>_REQUESTS, AREDL_RATE_LIMIT_<GROUP>_TRUSTED_REQUESTS and AREDL_RATE_LIMIT_<GROUP>_WINDOW change the budget
+// of the DEFAULT or EXPENSIVE group, the window is a duration like 1m. Unset variables keep the defaults.
+// AREDL_TRUSTED_PROXIES is a comma separated list of ip addresses or ranges like 10.0.0.0/8 of the reverse proxies in front of the server.
+// The X-Forwarded-For header is only used for requests from these proxies, without any the ip address of the connection is used.
+// Cached api keys are cleared whenever a key changes
+func RegisterRateLimits(app core.App) {
+	app.OnBeforeServe().Add(func(e *core.ServeEvent) error {
+		return configureRateLimits(os.Getenv)
+	})
+	clearApiKeys := func(e *core.ModelEvent) error {
+		rateLimitApiKeys.clear()
+		return nil
+	}
+	app.OnModelAfterCreate(names.TableApiKeys).Add(clearApiKeys)
+	app.OnModelAfterUpdate(names.TableApiKeys).Add(clearApiKeys)
+	app.OnModelAfterDelete(names.TableApiKeys).Add(clearApiKeys)
+}
+
+func configureRateLimits(getenv func(string) string) error {
+	for name, group := range rateLimitGroups {
+		err := group.configure(getenv, "AREDL_RATE_LIMIT_"+name)
+		if err != nil {
+			return err
+		}
+	}
+	extractor, err := trustedProxyExtractor(getenv("AREDL_TRUSTED_PROXIES"))
+	if err != nil {
+		return err
+	}
+	rateLimitIP = extractor
+	return nil
+}
+
+// configure changes the budget of the group with the environment variables starting with the prefix
+func (group *RateLimitGroup) configure(getenv func(string) string, prefix string) error {
+	group.mutex.Lock()
+	defer group.mutex.Unlock()
+
+	for _, setting := range []struct {
+		name  string
+		value *int
+	}{{prefix + "_REQUESTS", &group.Requests}, {prefix + "_TRUSTED_REQUESTS", &group.TrustedRequests}} {
+		value := getenv(setting.name)
+		if value == "" {
+			continue
+		}
+		requests, err := strconv.Atoi(value)
+		if err != nil || requests < 1 {
+			return fmt.Errorf("%s has to be a positive number", setting.name)
+		}
+		*setting.value = requests
+	}
+	if value := getenv(prefix + "_WINDOW"); value != "" {
+		window, err := time.ParseDuration(value)
+		if err != nil || window <= 0 {
+			return fmt.Errorf("%s_WINDOW has to be a positive duration", prefix)
+		}
+		group.Window = window
+	}
+	return nil
+}
+
+// trustedProxyExtractor returns how the ip address of a request is resolved with the given comma separated trusted proxies
+func trustedProxyExtractor(proxies string) (echo.IPExtractor, error) {
+	var ranges []echo.TrustOption
+	for _, proxy := range strings.Split(proxies, ",") {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+		if !strings.Contains(proxy, "/") {
+			proxy += util.If(strings.Contains(proxy, ":"), "/128", "/32")
+		}
+		_, ipRange, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %s", proxy)
+		}
+		ranges = append(ranges, echo.TrustIPRange(ipRange))
+	}
+	if len(ranges) == 0 {
+		return echo.ExtractIPDirect(), nil
+	}
+	// only the configured proxies are trusted, not every private address
+	options := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	return echo.ExtractIPFromXFFHeader(append(options, ranges...)...), nil
+}
+
+// RateLimit limits the number of requests per window of the given group.
+// Requests are counted per api key if one is provided, otherwise per authenticated user or ip address.
+// The budget is reported in the RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers,
+// requests over the budget are rejected with 429 and a Retry-After header
+func RateLimit(app core.App, group *RateLimitGroup) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			now := time.Now()
+			identity, limit := rateLimitIdentity(app, c, group, now)
+			remaining, reset := group.take(identity, limit, now)
+
+			header := c.Response().Header()
+			resetSeconds := strconv.Itoa(int(math.Ceil(reset.Seconds())))
+			header.Set("RateLimit-Limit", strconv.Itoa(limit))
+			header.Set("RateLimit-Remaining", strconv.Itoa(max(remaining, 0)))
+			header.Set("RateLimit-Reset", resetSeconds)
+			if remaining < 0 {
+				header.Set("Retry-After", resetSeconds)
+				return apis.NewApiError(http.StatusTooManyRequests, "Too many requests", nil)
+			}
+			return next(c)
+		}
+	}
+}
+
+// rateLimitIdentity returns the key the request is counted with and the budget it has.
+// Unknown api keys are counted by ip address, they are rejected later on anyway.
+// Keys with an invalid format are neither looked up nor cached, so random keys can't fill the cache
+func rateLimitIdentity(app core.App, c echo.Context, group *RateLimitGroup, now time.Time) (string, int) {
+	if key := c.Request().Header.Get("api-key"); apikey.ValidFormat(key) {
+		cached, exists := rateLimitApiKeys.get(key, now)
+		if !exists {
+			record, err := apikey.Find(app.Dao(), key)
+			if err == nil {
+				cached = cachedApiKey{id: record.Id, trusted: record.GetBool("trusted")}
+				rateLimitApiKeys.set(key, cached, now)
+			} else if errors.Is(err, apikey.ErrNotFound) {
+				rateLimitApiKeys.set(key, cached, now)
+			}
+		}
+		if cached.id != "" {
+			return "key:" + cached.id, util.If(cached.trusted, group.TrustedRequests, group.Requests)
+		}
+	}
+	if record, _ := c.Get(apis.ContextAuthRecordKey).(*models.Record); record != nil {
+		return "user:" + record.Id, group.Requests
+	}
+	return "ip:" + rateLimitIP(c.Request()), group.Requests
+}
+
+// get returns the cached api key if it has not expired yet
+func (cache *apiKeyCache) get(key string, now time.Time) (cachedApiKey, bool) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	cached, exists := cache.entries[key]
+	if !exists || !now.Before(cached.expires) {
+		return cachedApiKey{}, false
+	}
+	return cached, true
+}
+
+// set caches the api key. Expired keys are removed once per cache duration so the cache doesn't grow forever.
+// Unknown keys are not cached once the cache reaches its limit, existing keys can only fill it up to the number of keys in the database
+func (cache *apiKeyCache) set(key string, cached cachedApiKey, now time.Time) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	if cache.entries == nil {
+		cache.entries = map[string]cachedApiKey{}
+	}
+	if now.Sub(cache.lastSweep) >= apiKeyCacheDuration {
+		for entryKey, entry := range cache.entries {
+			if !now.Before(entry.expires) {
+				delete(cache.entries, entryKey)
+			}
+		}
+		cache.lastSweep = now
+	}
+	if _, exists := cache.entries[key]; !exists && cached.id == "" && len(cache.entries) >= apiKeyCacheUnknownLimit {
+		return
+	}
+	cached.expires = now.Add(apiKeyCacheDuration)
+	cache.entries[key] = cached
+}
+
+func (cache *apiKeyCache) clear() {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	cache.entries = nil
+}
+
+// take counts a request of the identity and returns the remaining requests together with the time until the window resets.
+// The remaining requests are negative if the budget is used up
+func (group *RateLimitGroup) take(identity string, limit int, now time.Time) (int, time.Duration) {
+	group.mutex.Lock()
+	defer group.mutex.Unlock()
+
+	if group.counters == nil {
+		group.counters = map[string]*rateLimitCounter{}
+	}
+	// windows that are over are removed once per window so the counters don't grow forever
+	if now.Sub(group.lastSweep) >= group.Window {
+		for key, counter := range group.counters {
+			if !now.Before(counter.reset) {
+				delete(group.counters, key)
+			}
+		}
+		group.lastSweep = now
+	}
+	counter, exists := group.counters[identity]
+	if !exists || !now.Before(counter.reset) {
+		counter = &rateLimitCounter{reset: now.Add(group.Window)}
+		group.counters[identity] = counter
+	}
+	counter.count++
+	return limit - counter.count, counter.reset.Sub(now)
+}
@@ -0,0 +1,199 @@
+package middlewares
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateLimitGroupTake(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	type request struct {
+		identity      string
+		after         time.Duration
+		wantRemaining int
+		wantReset     time.Duration
+	}
+	tests := []struct {
+		name     string
+		requests []request
+	}{
+		{
+			name: "counts down",
+			requests: []request{
+				{identity: "a", wantRemaining: 2, wantReset: time.Minute},
+				{identity: "a", after: 10 * time.Second, wantRemaining: 1, wantReset: 50 * time.Second},
+				{identity: "a", after: 20 * time.Second, wantRemaining: 0, wantReset: 40 * time.Second},
+				{identity: "a", after: 30 * time.Second, wantRemaining: -1, wantReset: 30 * time.Second},
+			},
+		},
+		{
+			name: "identities have their own budget",
+			requests: []request{
+				{identity: "a", wantRemaining: 2, wantReset: time.Minute},
+				{identity: "b", wantRemaining: 2, wantReset: time.Minute},
+				{identity: "a", wantRemaining: 1, wantReset: time.Minute},
+			},
+		},
+		{
+			name: "window resets",
+			requests: []request{
+				{identity: "a", wantRemaining: 2, wantReset: time.Minute},
+				{identity: "a", wantRemaining: 1, wantReset: time.Minute},
+				{identity: "a", after: time.Minute, wantRemaining: 2, wantReset: time.Minute},
+				{identity: "a", after: 90 * time.Second, wantRemaining: 1, wantReset: 30 * time.Second},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			group := &RateLimitGroup{Requests: 3, Window: time.Minute}
+			for i, request := range test.requests {
+				remaining, reset := group.take(request.identity, group.Requests, start.Add(request.after))
+				if remaining != request.wantRemaining || reset != request.wantReset {
+					t.Errorf("request %d: take() = (%v, %v), want (%v, %v)", i, remaining, reset, request.wantRemaining, request.wantReset)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimitGroupTakeRemovesOldWindows(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	group := &RateLimitGroup{Requests: 3, Window: time.Minute}
+	group.take("a", 3, start)
+	group.take("b", 3, start.Add(30*time.Second))
+	group.take("c", 3, start.Add(time.Minute))
+	if _, exists := group.counters["a"]; exists {
+		t.Error("the counter of an old window was not removed")
+	}
+	if _, exists := group.counters["b"]; !exists {
+		t.Error("the counter of a running window was removed")
+	}
+}
+
+type rateLimitBudget struct {
+	Requests        int
+	TrustedRequests int
+	Window          time.Duration
+}
+
+func TestRateLimitGroupConfigure(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    rateLimitBudget
+		wantErr string
+	}{
+		{name: "defaults", env: map[string]string{}, want: rateLimitBudget{Requests: 10, TrustedRequests: 100, Window: time.Minute}},
+		{
+			name: "all settings",
+			env:  map[string]string{"TEST_REQUESTS": "5", "TEST_TRUSTED_REQUESTS": "50", "TEST_WINDOW": "30s"},
+			want: rateLimitBudget{Requests: 5, TrustedRequests: 50, Window: 30 * time.Second},
+		},
+		{name: "invalid requests", env: map[string]string{"TEST_REQUESTS": "many"}, wantErr: "TEST_REQUESTS"},
+		{name: "zero requests", env: map[string]string{"TEST_TRUSTED_REQUESTS": "0"}, wantErr: "TEST_TRUSTED_REQUESTS"},
+		{name: "invalid window", env: map[string]string{"TEST_WINDOW": "-1m"}, wantErr: "TEST_WINDOW"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			group := &RateLimitGroup{Requests: 10, TrustedRequests: 100, Window: time.Minute}
+			err := group.configure(func(name string) string { return test.env[name] }, "TEST")
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("configure() error = %v, want it to contain %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("configure() failed: %v", err)
+			}
+			got := rateLimitBudget{Requests: group.Requests, TrustedRequests: group.TrustedRequests, Window: group.Window}
+			if got != test.want {
+				t.Errorf("configure() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestTrustedProxyExtractor(t *testing.T) {
+	tests := []struct {
+		name          string
+		proxies       string
+		remoteAddr    string
+		forwardedFor  string
+		want          string
+		wantConfigErr bool
+	}{
+		{name: "without proxies", remoteAddr: "10.0.0.1:1234", forwardedFor: "1.2.3.4", want: "10.0.0.1"},
+		{name: "from trusted proxy", proxies: "10.0.0.1", remoteAddr: "10.0.0.1:1234", forwardedFor: "1.2.3.4", want: "1.2.3.4"},
+		{name: "from trusted range", proxies: "192.168.0.1, 10.0.0.0/8", remoteAddr: "10.2.3.4:1234", forwardedFor: "1.2.3.4", want: "1.2.3.4"},
+		{name: "from untrusted address", proxies: "10.0.0.1", remoteAddr: "10.0.0.2:1234", forwardedFor: "1.2.3.4", want: "10.0.0.2"},
+		{name: "spoofed header behind proxy", proxies: "10.0.0.1", remoteAddr: "10.0.0.1:1234", forwardedFor: "6.6.6.6, 1.2.3.4", want: "1.2.3.4"},
+		{name: "private address is not trusted", proxies: "10.0.0.1", remoteAddr: "10.0.0.1:1234", forwardedFor: "6.6.6.6, 192.168.0.5", want: "192.168.0.5"},
+		{name: "ipv6 proxy", proxies: "::1", remoteAddr: "[::1]:1234", forwardedFor: "1.2.3.4", want: "1.2.3.4"},
+		{name: "invalid proxy", proxies: "proxy", wantConfigErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			extractor, err := trustedProxyExtractor(test.proxies)
+			if (err != nil) != test.wantConfigErr {
+				t.Fatalf("trustedProxyExtractor() error = %v, wantErr %v", err, test.wantConfigErr)
+			}
+			if test.wantConfigErr {
+				return
+			}
+			request := &http.Request{RemoteAddr: test.remoteAddr, Header: http.Header{}}
+			request.Header.Set("X-Forwarded-For", test.forwardedFor)
+			if got := extractor(request); got != test.want {
+				t.Errorf("ip = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestApiKeyCache(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := &apiKeyCache{}
+	cache.set("known", cachedApiKey{id: "id", trusted: true}, start)
+	cache.set("unknown", cachedApiKey{}, start)
+	if cached, exists := cache.get("known", start.Add(time.Second)); !exists || cached.id != "id" || !cached.trusted {
+		t.Errorf("get(known) = (%+v, %v), want the cached key", cached, exists)
+	}
+	if cached, exists := cache.get("unknown", start.Add(time.Second)); !exists || cached.id != "" {
+		t.Errorf("get(unknown) = (%+v, %v), want a cached unknown key", cached, exists)
+	}
+	if _, exists := cache.get("known", start.Add(apiKeyCacheDuration)); exists {
+		t.Error("get() returned an expired key")
+	}
+	cache.clear()
+	if _, exists := cache.get("unknown", start.Add(time.Second)); exists {
+		t.Error("get() returned a key after the cache was cleared")
+	}
+}
+
+func TestApiKeyCacheLimitsUnknownKeys(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := &apiKeyCache{}
+	for i := 0; i < apiKeyCacheUnknownLimit; i++ {
+		cache.set(fmt.Sprint("unknown", i), cachedApiKey{}, start)
+	}
+	cache.set("unknown", cachedApiKey{}, start)
+	if _, exists := cache.get("unknown", start); exists {
+		t.Error("an unknown key was cached in a full cache")
+	}
+	cache.set("known", cachedApiKey{id: "id"}, start)
+	if _, exists := cache.get("known", start); !exists {
+		t.Error("an existing key was not cached in a full cache")
+	}
+	cache.set("unknown0", cachedApiKey{}, start.Add(time.Second))
+	if cached, exists := cache.get("unknown0", start.Add(apiKeyCacheDuration)); !exists || cached.id != "" {
+		t.Error("a cached unknown key was not refreshed in a full cache")
+	}
+	cache.set("unknown", cachedApiKey{}, start.Add(apiKeyCacheDuration))
+	if _, exists := cache.get("unknown", start.Add(apiKeyCacheDuration)); !exists {
+		t.Error("an unknown key was not cached after the expired keys were removed")
+	}
+}
@@ -0,0 +1,89 @@
+package middlewares
+
+import (
+	"AREDL/audit"
+	"AREDL/names"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/tools/cron"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// RoleGrant is a role given to a user. Starts and Expires are zero if the grant is not limited
+type RoleGrant struct {
+	Role    string         `db:"role" json:"role"`
+	Starts  types.DateTime `db:"starts" json:"starts"`
+	Expires types.DateTime `db:"expires" json:"expires"`
+}
+
+// Active checks if the grant is active at the given time
+func (grant RoleGrant) Active(at types.DateTime) bool {
+	return (grant.Starts.IsZero() || !grant.Starts.Time().After(at.Time())) &&
+		(grant.Expires.IsZero() || grant.Expires.Time().After(at.Time()))
+}
+
+// ActiveRoleGrantExp matches the role grants that are active at the given time
+func ActiveRoleGrantExp(startsColumn string, expiresColumn string, at types.DateTime) dbx.Expression {
+	return dbx.And(
+		dbx.Or(dbx.HashExp{startsColumn: ""}, dbx.NewExp("[["+startsColumn+"]] <= {:at}", dbx.Params{"at": at.String()})),
+		dbx.Or(dbx.HashExp{expiresColumn: ""}, dbx.NewExp("[["+expiresColumn+"]] > {:at}", dbx.Params{"at": at.String()})),
+	)
+}
+
+// GetRoleGrants returns every role given to the user, including grants that have not started yet or already expired
+func GetRoleGrants(dao *daos.Dao, userId string) ([]RoleGrant, error) {
+	var grants []RoleGrant
+	err := dao.DB().Select("role", "starts", "expires").From(names.TableRoles).Where(dbx.HashExp{"user": userId}).All(&grants)
+	return grants, err
+}
+
+// RegisterRoleExpiry revokes expired role grants every five minutes
+func RegisterRoleExpiry(app core.App) {
+	app.OnBeforeServe().Add(func(e *core.ServeEvent) error {
+		scheduler := cron.New()
+		scheduler.MustAdd("role_expiry", "*/5 * * * *", func() {
+			err := RevokeExpiredRoles(app.Dao())
+			if err != nil {
+				app.Logger().Error("Failed to revoke expired roles", "error", err)
+			}
+		})
+		scheduler.Start()
+		return nil
+	})
+}
+
+// RevokeExpiredRoles deletes every expired role grant and records the revocation in the audit log
+func RevokeExpiredRoles(dao *daos.Dao) error {
+	return dao.RunInTransaction(func(txDao *daos.Dao) error {
+		var expired []struct {
+			Id string `db:"id"`
+			RoleGrant
+			User string `db:"user"`
+		}
+		now := types.NowDateTime()
+		err := txDao.DB().Select("id", "user", "role", "starts", "expires").
+			From(names.TableRoles).
+			Where(dbx.And(dbx.Not(dbx.HashExp{"expires": ""}), dbx.NewExp("expires <= {:now}", dbx.Params{"now": now.String()}))).
+			All(&expired)
+		if err != nil {
+			return err
+		}
+		for _, grant := range expired {
+			_, err = txDao.DB().Delete(names.TableRoles, dbx.HashExp{"id": grant.Id}).Execute()
+			if err != nil {
+				return err
+			}
+			err = audit.Log(txDao, nil, audit.Entry{
+				Action:      "role_expired",
+				TargetTable: names.TableUsers,
+				TargetId:    grant.User,
+				Before:      grant.RoleGrant,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,137 @@
+package middlewares
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func testDate(t *testing.T, value time.Time) types.DateTime {
+	t.Helper()
+	date, err := types.ParseDateTime(value)
+	if err != nil {
+		t.Fatalf("failed to parse %v: %v", value, err)
+	}
+	return date
+}
+
+func TestRoleGrantActive(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	at := testDate(t, now)
+	before, after := testDate(t, now.Add(-time.Hour)), testDate(t, now.Add(time.Hour))
+	tests := []struct {
+		name  string
+		grant RoleGrant
+		want  bool
+	}{
+		{name: "unlimited", grant: RoleGrant{}, want: true},
+		{name: "started", grant: RoleGrant{Starts: before}, want: true},
+		{name: "starts now", grant: RoleGrant{Starts: at}, want: true},
+		{name: "not started", grant: RoleGrant{Starts: after}, want: false},
+		{name: "expires later", grant: RoleGrant{Expires: after}, want: true},
+		{name: "expires now", grant: RoleGrant{Expires: at}, want: false},
+		{name: "expired", grant: RoleGrant{Expires: before}, want: false},
+		{name: "within range", grant: RoleGrant{Starts: before, Expires: after}, want: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.grant.Active(at); got != test.want {
+				t.Errorf("Active() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestActiveRoles(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	grants := []RoleGrant{
+		{Role: "helper"},
+		{Role: "moderator", Expires: testDate(t, now.Add(-time.Hour))},
+		{Role: "member", Starts: testDate(t, now.Add(time.Hour))},
+		{Role: "admin", Expires: testDate(t, now.Add(time.Hour))},
+	}
+	got := activeRoles(grants, testDate(t, now))
+	want := []string{"helper", "admin", DefaultRole}
+	if !slices.Equal(got, want) {
+		t.Errorf("activeRoles() = %v, want %v", got, want)
+	}
+}
+
+func TestResolveGrantsExpiry(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	at := testDate(t, now)
+	soon, later := testDate(t, now.Add(time.Hour)), testDate(t, now.Add(48*time.Hour))
+	tests := []struct {
+		name   string
+		grants []RoleGrant
+		// want is the expiry of every permission the user has, a zero time for permanent permissions
+		want map[string]types.DateTime
+	}{
+		{
+			name:   "permanent",
+			grants: []RoleGrant{{Role: "helper"}},
+			want:   map[string]types.DateTime{"aredl.submission_review": {}, "global.user_submit": {}},
+		},
+		{
+			name:   "expiring",
+			grants: []RoleGrant{{Role: "helper", Expires: soon}},
+			want:   map[string]types.DateTime{"aredl.submission_review": soon, "global.user_submit": soon},
+		},
+		{
+			name:   "permanent role gives the permission as well",
+			grants: []RoleGrant{{Role: "helper", Expires: soon}, {Role: "member"}},
+			want:   map[string]types.DateTime{"aredl.submission_review": soon, "global.user_submit": {}},
+		},
+		{
+			name:   "last expiring role",
+			grants: []RoleGrant{{Role: "helper", Expires: later}, {Role: "moderator", Expires: soon}},
+			want: map[string]types.DateTime{
+				"aredl.submission_review": later,
+				"global.user_submit":      later,
+				"global.user_ban":         soon,
+				"global.user_change_role": soon,
+			},
+		},
+		{
+			name:   "inactive grants are ignored",
+			grants: []RoleGrant{{Role: "helper", Starts: soon}, {Role: "member", Expires: at}},
+			want:   map[string]types.DateTime{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := testPermissionTables(testRoleParents).resolveGrants(test.grants, at)
+			if len(got) != len(test.want) {
+				t.Fatalf("resolveGrants() = %v, want the permissions %v", got, test.want)
+			}
+			for fullAction, wantExpires := range test.want {
+				permissionData, exists := got[fullAction]
+				if !exists {
+					t.Errorf("resolveGrants() is missing %s", fullAction)
+					continue
+				}
+				if wantExpires.IsZero() {
+					if permissionData.Expires != nil {
+						t.Errorf("%s expires at %v, want it to be permanent", fullAction, permissionData.Expires)
+					}
+					continue
+				}
+				if permissionData.Expires == nil || *permissionData.Expires != wantExpires {
+					t.Errorf("%s expires at %v, want %v", fullAction, permissionData.Expires, wantExpires)
+				}
+			}
+		})
+	}
+}
+
+func TestResolveGrantsDoesNotChangeTheCache(t *testing.T) {
+	tables := testPermissionTables(testRoleParents)
+	tables.resolveGrants([]RoleGrant{{Role: "helper", Expires: testDate(t, time.Now().Add(time.Hour))}}, types.NowDateTime())
+	for fullAction, permissionData := range tables.resolve(activeRoles([]RoleGrant{{Role: "helper"}}, types.NowDateTime())) {
+		if permissionData.Expires != nil {
+			t.Errorf("the cached permission %s got an expiry", fullAction)
+		}
+	}
+}
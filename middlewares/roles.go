@@ -0,0 +1,200 @@
+package middlewares
+
+import (
+	"AREDL/names"
+	"AREDL/util"
+	"github.com/labstack/echo/v5"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/models/schema"
+	"github.com/pocketbase/pocketbase/tools/list"
+	"slices"
+)
+
+// DefaultRole is the role every user has without it being assigned
+const DefaultRole = "default"
+
+// roleFields are the select fields holding role names. Their options are the list of existing roles
+var roleFields = []struct {
+	collection string
+	field      string
+}{
+	{names.TableRoles, "role"},
+	{names.TablePermissions, "role"},
+	{names.TablePermissions, "affected_roles"},
+	{names.TableRoleDefinitions, "role"},
+	{names.TableRoleDefinitions, "parent"},
+}
+
+// roleManagingActions are the permissions the creator of a role gets to affect it with, so they can use it afterward
+var roleManagingActions = []string{"manage_roles", "user_change_role"}
+
+// GetRoles returns the names of all roles including the default role
+func GetRoles(dao *daos.Dao) ([]string, error) {
+	collection, err := dao.FindCollectionByNameOrId(names.TablePermissions)
+	if err != nil {
+		return nil, err
+	}
+	options := collection.Schema.GetFieldByName("role").Options.(*schema.SelectOptions)
+	return slices.Clone(options.Values), nil
+}
+
+// updateRoleOptions changes the options of every role field. Multi selects allow to select every role
+func updateRoleOptions(dao *daos.Dao, update func(values []string) []string) error {
+	collections := map[string]*models.Collection{}
+	for _, roleField := range roleFields {
+		collection, exists := collections[roleField.collection]
+		if !exists {
+			var err error
+			collection, err = dao.FindCollectionByNameOrId(roleField.collection)
+			if err != nil {
+				return err
+			}
+			collections[roleField.collection] = collection
+		}
+		options := collection.Schema.GetFieldByName(roleField.field).Options.(*schema.SelectOptions)
+		options.Values = update(options.Values)
+		if options.MaxSelect > 1 {
+			options.MaxSelect = max(len(options.Values), 1)
+		}
+	}
+	for _, collection := range collections {
+		if err := dao.SaveCollection(collection); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateRole adds a new role without permissions. The creator is allowed to assign the role and manage its permissions afterward.
+// The creator id is empty for roles created by admins
+func CreateRole(dao *daos.Dao, creatorId string, role string) error {
+	err := updateRoleOptions(dao, func(values []string) []string {
+		return append(values, role)
+	})
+	if err != nil || creatorId == "" {
+		return err
+	}
+	creatorRoles, err := GetUserRoles(dao, creatorId)
+	if err != nil {
+		return err
+	}
+	permissions, err := dao.FindRecordsByExpr(names.TablePermissions)
+	if err != nil {
+		return err
+	}
+	for _, permission := range permissions {
+		heldByCreator := slices.ContainsFunc(permission.GetStringSlice("role"), func(role string) bool {
+			return list.ExistInSlice(role, creatorRoles)
+		})
+		if !heldByCreator || !list.ExistInSlice(permission.GetString("action"), roleManagingActions) {
+			continue
+		}
+		permission.Set("affected_roles", append(permission.GetStringSlice("affected_roles"), role))
+		if err = dao.SaveRecord(permission); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RenameRole renames a role in every permission, user role and in the role hierarchy
+func RenameRole(dao *daos.Dao, oldName string, newName string) error {
+	err := updateRoleOptions(dao, func(values []string) []string {
+		return append(values, newName)
+	})
+	if err != nil {
+		return err
+	}
+	for _, column := range []struct{ table, field string }{
+		{names.TableRoles, "role"},
+		{names.TableRoleDefinitions, "role"},
+		{names.TableRoleDefinitions, "parent"},
+	} {
+		_, err = dao.DB().Update(column.table, dbx.Params{column.field: newName}, dbx.HashExp{column.field: oldName}).Execute()
+		if err != nil {
+			return err
+		}
+	}
+	err = updateRolePermissions(dao, func(roles []string) []string {
+		return util.MapSlice(roles, func(role string) string { return util.If(role == oldName, newName, role) })
+	})
+	if err != nil {
+		return err
+	}
+	return updateRoleOptions(dao, func(values []string) []string {
+		return list.SubtractSlice(values, []string{oldName})
+	})
+}
+
+// DeleteRole removes a role from every user and permission. Permissions that only belonged to the role are deleted
+// and roles that inherited from it lose their parent
+func DeleteRole(dao *daos.Dao, role string) error {
+	_, err := dao.DB().Delete(names.TableRoles, dbx.HashExp{"role": role}).Execute()
+	if err != nil {
+		return err
+	}
+	_, err = dao.DB().Delete(names.TableRoleDefinitions, dbx.HashExp{"role": role}).Execute()
+	if err != nil {
+		return err
+	}
+	_, err = dao.DB().Update(names.TableRoleDefinitions, dbx.Params{"parent": ""}, dbx.HashExp{"parent": role}).Execute()
+	if err != nil {
+		return err
+	}
+	err = updateRolePermissions(dao, func(roles []string) []string {
+		return list.SubtractSlice(roles, []string{role})
+	})
+	if err != nil {
+		return err
+	}
+	return updateRoleOptions(dao, func(values []string) []string {
+		return list.SubtractSlice(values, []string{role})
+	})
+}
+
+// updateRolePermissions applies the update to the roles and affected roles of every permission. Permissions without roles are deleted
+func updateRolePermissions(dao *daos.Dao, update func(roles []string) []string) error {
+	permissions, err := dao.FindRecordsByExpr(names.TablePermissions)
+	if err != nil {
+		return err
+	}
+	for _, permission := range permissions {
+		roles := update(permission.GetStringSlice("role"))
+		affectedRoles := update(permission.GetStringSlice("affected_roles"))
+		if len(roles) == 0 {
+			if err = dao.DeleteRecord(permission); err != nil {
+				return err
+			}
+			continue
+		}
+		if slices.Equal(roles, permission.GetStringSlice("role")) && slices.Equal(affectedRoles, permission.GetStringSlice("affected_roles")) {
+			continue
+		}
+		permission.Set("role", roles)
+		permission.Set("affected_roles", affectedRoles)
+		if err = dao.SaveRecord(permission); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CanGrantPermission checks if the authenticated user holds the permission themselves and can affect all the given affected roles with it.
+// Permissions affecting lower ranks can only be granted by users whose permission affects lower ranks as well
+func CanGrantPermission(c echo.Context, dao *daos.Dao, listName string, action string, affectedRoles []string, affectsLowerRanks bool) (bool, error) {
+	record, _ := c.Get(apis.ContextAuthRecordKey).(*models.Record)
+	if record == nil {
+		return false, nil
+	}
+	hasPermission, permissionData, err := GetPermission(dao, record.Id, listName, action)
+	if err != nil || !hasPermission {
+		return false, err
+	}
+	if affectsLowerRanks && !permissionData.AffectsLowerRanks {
+		return false, nil
+	}
+	return util.IsSubset(permissionData.AffectedRoles, affectedRoles), nil
+}
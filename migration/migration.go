@@ -9,7 +9,10 @@ import (
 	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase"
 	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/migrations"
 	"github.com/pocketbase/pocketbase/models"
+	"github.com/pocketbase/pocketbase/models/schema"
+	"github.com/pocketbase/pocketbase/tools/types"
 	"github.com/spf13/cobra"
 	"io"
 	"os"
@@ -18,6 +21,165 @@ import (
 	"strings"
 )
 
+func init() {
+	migrations.AppMigrations.Register(migrateCompletionPercentages, nil, "1718000000_completion_percentages.go")
+	migrations.AppMigrations.Register(migrateSubmissionStatus, revertSubmissionStatus, "1718100000_submission_status.go")
+	migrations.AppMigrations.Register(migrateHistoryLevels, revertHistoryLevels, "1718200000_history_levels.go")
+}
+
+// migrateCompletionPercentages turns records and submissions that have been added before percentages existed into completions in every registered list
+func migrateCompletionPercentages(db dbx.Builder) error {
+	dao := daos.New(db)
+	for _, listData := range demonlist.Lists() {
+		for _, tableName := range []string{listData.RecordsTableName, listData.SubmissionsTableName} {
+			if !dao.HasTable(tableName) {
+				// the collections of the list are imported after the migrations on a new database
+				continue
+			}
+			_, err := dao.DB().Update(tableName,
+				dbx.Params{"percentage": 100},
+				dbx.Or(dbx.HashExp{"percentage": 0}, dbx.HashExp{"percentage": nil})).Execute()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// submissionStatusField is the status field of the submission collections, the id matches pb_schema.json
+func submissionStatusField() *schema.SchemaField {
+	return &schema.SchemaField{
+		Id:       "9pacfr8w",
+		Name:     "status",
+		Type:     schema.FieldTypeSelect,
+		Required: true,
+		Options: &schema.SelectOptions{
+			MaxSelect: 1,
+			Values:    demonlist.SubmissionStatuses,
+		},
+	}
+}
+
+// migrateSubmissionStatus replaces the rejected flag of submissions with their status.
+// Rejected submissions can be submitted again, every other submission is waiting for a review
+func migrateSubmissionStatus(db dbx.Builder) error {
+	dao := daos.New(db)
+	for _, listData := range demonlist.Lists() {
+		collection, err := dao.FindCollectionByNameOrId(listData.SubmissionsTableName)
+		if err != nil {
+			// the collections of the list are imported after the migrations on a new database
+			continue
+		}
+		if collection.Schema.GetFieldByName("status") == nil {
+			collection.Schema.AddField(submissionStatusField())
+			err = dao.SaveCollection(collection)
+			if err != nil {
+				return err
+			}
+		}
+		status := dbx.NewExp("{:pending}", dbx.Params{"pending": demonlist.SubmissionPending})
+		if collection.Schema.GetFieldByName("rejected") != nil {
+			status = dbx.NewExp("CASE WHEN rejected = 1 THEN {:rejected} ELSE {:pending} END", dbx.Params{
+				"rejected": demonlist.SubmissionRejectedRetryable,
+				"pending":  demonlist.SubmissionPending,
+			})
+		}
+		_, err = dao.DB().Update(collection.Name,
+			dbx.Params{"status": status},
+			dbx.Or(dbx.HashExp{"status": ""}, dbx.HashExp{"status": nil})).Execute()
+		if err != nil {
+			return err
+		}
+		if field := collection.Schema.GetFieldByName("rejected"); field != nil {
+			collection.Schema.RemoveField(field.Id)
+			err = dao.SaveCollection(collection)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// revertSubmissionStatus adds the rejected flag back to the submissions and sets it for every rejected submission
+func revertSubmissionStatus(db dbx.Builder) error {
+	dao := daos.New(db)
+	for _, listData := range demonlist.Lists() {
+		collection, err := dao.FindCollectionByNameOrId(listData.SubmissionsTableName)
+		if err != nil {
+			continue
+		}
+		if collection.Schema.GetFieldByName("rejected") == nil {
+			collection.Schema.AddField(&schema.SchemaField{
+				Id:   "oharleuo",
+				Name: "rejected",
+				Type: schema.FieldTypeBool,
+			})
+			err = dao.SaveCollection(collection)
+			if err != nil {
+				return err
+			}
+		}
+		_, err = dao.DB().Update(collection.Name,
+			dbx.Params{"rejected": dbx.NewExp("status IN ({:retryable}, {:final})", dbx.Params{
+				"retryable": demonlist.SubmissionRejectedRetryable,
+				"final":     demonlist.SubmissionRejectedFinal,
+			})},
+			nil).Execute()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateHistoryLevels turns the level and cause of the position history into plain ids.
+// They refer to a level on the list or to its entry in the removed levels, which uses the same id,
+// so removing a level doesn't delete its history or leave relations to a missing level behind
+func migrateHistoryLevels(db dbx.Builder) error {
+	return setHistoryLevelFields(daos.New(db), func(field *schema.SchemaField, levelCollectionId string) {
+		field.Type = schema.FieldTypeText
+		field.Options = &schema.TextOptions{}
+	})
+}
+
+// revertHistoryLevels turns the level and cause of the position history back into relations to the levels of the list
+func revertHistoryLevels(db dbx.Builder) error {
+	return setHistoryLevelFields(daos.New(db), func(field *schema.SchemaField, levelCollectionId string) {
+		field.Type = schema.FieldTypeRelation
+		field.Options = &schema.RelationOptions{
+			CollectionId:  levelCollectionId,
+			CascadeDelete: field.Name == "level",
+			MaxSelect:     types.Pointer(1),
+		}
+	})
+}
+
+func setHistoryLevelFields(dao *daos.Dao, change func(field *schema.SchemaField, levelCollectionId string)) error {
+	for _, listData := range demonlist.Lists() {
+		collection, err := dao.FindCollectionByNameOrId(listData.HistoryTableName)
+		if err != nil {
+			// the collections of the list are imported after the migrations on a new database
+			continue
+		}
+		levelCollection, err := dao.FindCollectionByNameOrId(listData.LevelTableName)
+		if err != nil {
+			return err
+		}
+		for _, name := range []string{"level", "cause"} {
+			if field := collection.Schema.GetFieldByName(name); field != nil {
+				change(field, levelCollection.Id)
+			}
+		}
+		err = dao.SaveCollection(collection)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func readFileIntoJson(path string, v any) error {
 	list, err := os.Open(path)
 	if err != nil {
@@ -277,13 +439,14 @@ func Register(app *pocketbase.PocketBase) {
 					}
 
 					levelRecordData := map[string]any{
-						"position":       position + 1,
-						"name":           level.Name,
-						"publisher":      publisherId,
-						"level_id":       level.Id,
-						"level_password": level.Password,
-						"legacy":         levelData.Legacy,
-						"two_player":     twoPlayer,
+						"position":           position + 1,
+						"name":               level.Name,
+						"publisher":          publisherId,
+						"level_id":           level.Id,
+						"level_password":     level.Password,
+						"legacy":             levelData.Legacy,
+						"two_player":         twoPlayer,
+						"percent_to_qualify": level.PercentToQualify,
 					}
 
 					levelId, ok := oldLevelIds[level.Name]
@@ -341,6 +504,7 @@ func Register(app *pocketbase.PocketBase) {
 							"submitted_by":    playerId,
 							"placement_order": recordOrder + 1,
 							"mobile":          mobile,
+							"percentage":      percent,
 						})
 						if err != nil {
 							return nil, err
@@ -348,13 +512,15 @@ func Register(app *pocketbase.PocketBase) {
 						return submissionRecord, nil
 					}
 
-					_, err = addSubmissionRecord(level.Verifier, 0, level.Verification, 60, false)
+					_, err = addSubmissionRecord(level.Verifier, 0, level.Verification, 100, false)
 					if err != nil {
 						return err
 					}
 
 					for submissionOrder, playerRecord := range level.Records {
-						_, err := addSubmissionRecord(playerRecord.User, submissionOrder+1, playerRecord.Link, playerRecord.Percent, playerRecord.Mobile)
+						// records without a percent are completions
+						percent := util.If(playerRecord.Percent == 0, 100, playerRecord.Percent)
+						_, err := addSubmissionRecord(playerRecord.User, submissionOrder+1, playerRecord.Link, percent, playerRecord.Mobile)
 						if err != nil {
 							return err
 						}
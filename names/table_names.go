@@ -7,3 +7,9 @@ const TablePermissions = "role_permissions"
 const TablePointFormular = "point_formula"
 const TableRoles = "roles"
 const TableLevelInfo = "level_info"
+const TablePointFormulaHistory = "point_formula_history"
+const TableAuditLog = "audit_log"
+const TableWebhooks = "webhooks"
+const TableWebhookDeliveries = "webhook_deliveries"
+const TableApiKeys = "api_keys"
+const TableRoleDefinitions = "role_definitions"
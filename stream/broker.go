@@ -0,0 +1,81 @@
+// Package stream distributes live list events to the clients of the event stream endpoint.
+package stream
+
+import (
+	"sync"
+)
+
+// subscriberBuffer is the number of events that can be queued for a slow client before new events get dropped for it
+const subscriberBuffer = 64
+
+// Event is a single change that is streamed to clients.
+// LevelId and UserId are used for filtering, events without both are sent to every client of the list
+type Event struct {
+	Name    string
+	List    string
+	LevelId string
+	UserId  string
+	Data    any
+}
+
+// Filter limits the events a subscriber receives. Empty fields match everything
+type Filter struct {
+	List    string
+	LevelId string
+	UserId  string
+}
+
+func (f Filter) matches(event Event) bool {
+	if event.List != f.List {
+		return false
+	}
+	if event.LevelId == "" && event.UserId == "" {
+		return true
+	}
+	if f.LevelId != "" && f.LevelId != event.LevelId {
+		return false
+	}
+	if f.UserId != "" && f.UserId != event.UserId {
+		return false
+	}
+	return true
+}
+
+type subscriber struct {
+	filter Filter
+	events chan Event
+}
+
+var (
+	mutex       sync.RWMutex
+	subscribers = map[*subscriber]struct{}{}
+)
+
+// Subscribe returns a channel that receives every published event matching the filter.
+// The returned function has to be called once the events are no longer read
+func Subscribe(filter Filter) (<-chan Event, func()) {
+	sub := &subscriber{filter: filter, events: make(chan Event, subscriberBuffer)}
+	mutex.Lock()
+	subscribers[sub] = struct{}{}
+	mutex.Unlock()
+	return sub.events, func() {
+		mutex.Lock()
+		delete(subscribers, sub)
+		mutex.Unlock()
+	}
+}
+
+// Publish sends the event to all matching subscribers without blocking
+func Publish(event Event) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	for sub := range subscribers {
+		if !sub.filter.matches(event) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+		}
+	}
+}
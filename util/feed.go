@@ -0,0 +1,131 @@
+package util
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+// Feed is a list of entries that can be rendered as Atom or RSS feed
+type Feed struct {
+	Title   string
+	Link    string
+	Updated time.Time
+	Entries []FeedEntry
+}
+
+type FeedEntry struct {
+	Id        string
+	Title     string
+	Content   string
+	Published time.Time
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Id      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  atomAuthor  `xml:"author"`
+	Link    atomLink    `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+}
+
+type atomEntry struct {
+	Id        string   `xml:"id"`
+	Title     string   `xml:"title"`
+	Updated   string   `xml:"updated"`
+	Published string   `xml:"published"`
+	Content   atomText `xml:"content"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Guid        rssGuid `xml:"guid"`
+	Title       string  `xml:"title"`
+	Description string  `xml:"description"`
+	PubDate     string  `xml:"pubDate"`
+}
+
+type rssGuid struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// Atom renders the feed as Atom 1.0 document
+func (f Feed) Atom() ([]byte, error) {
+	feed := atomFeed{
+		Id:      f.Link,
+		Title:   f.Title,
+		Updated: f.Updated.UTC().Format(time.RFC3339),
+		Author:  atomAuthor{Name: f.Title},
+		Link:    atomLink{Href: f.Link, Rel: "self"},
+		Entries: MapSlice(f.Entries, func(entry FeedEntry) atomEntry {
+			published := entry.Published.UTC().Format(time.RFC3339)
+			return atomEntry{
+				Id:        entry.Id,
+				Title:     entry.Title,
+				Updated:   published,
+				Published: published,
+				Content:   atomText{Type: "text", Body: entry.Content},
+			}
+		}),
+	}
+	return marshalFeed(feed)
+}
+
+// RSS renders the feed as RSS 2.0 document
+func (f Feed) RSS() ([]byte, error) {
+	feed := rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:         f.Title,
+			Link:          f.Link,
+			Description:   f.Title,
+			LastBuildDate: f.Updated.UTC().Format(time.RFC1123Z),
+			Items: MapSlice(f.Entries, func(entry FeedEntry) rssItem {
+				return rssItem{
+					Guid:        rssGuid{Value: entry.Id},
+					Title:       entry.Title,
+					Description: entry.Content,
+					PubDate:     entry.Published.UTC().Format(time.RFC1123Z),
+				}
+			}),
+		},
+	}
+	return marshalFeed(feed)
+}
+
+func marshalFeed(feed any) ([]byte, error) {
+	data, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
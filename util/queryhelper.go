@@ -104,7 +104,7 @@ func loadFields(query *dbx.SelectQuery, toLoad reflect.Type, optional bool, curr
 			if currentOptional {
 				fieldName = fmt.Sprintf("COALESCE(%v, '')", fieldName)
 			}
-			if currentPrefix != "" {
+			if currentPrefix != "" || currentOptional {
 				query.AndSelect(fmt.Sprintf("%v AS %v%v", fieldName, currentPrefix, dbName))
 			} else {
 				query.AndSelect(fieldName)
@@ -0,0 +1,138 @@
+package webhook
+
+import (
+	"AREDL/names"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/cron"
+	"github.com/pocketbase/pocketbase/tools/types"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// maxAttempts is the number of failed deliveries after which a delivery is given up
+	maxAttempts = 10
+	// backoffBase is the time waited after the first failed delivery, it doubles with every further attempt
+	backoffBase = time.Minute
+	batchSize   = 50
+	// deliveredRetention is how long delivered and failed deliveries are kept after they have been queued
+	deliveredRetention = 7 * 24 * time.Hour
+)
+
+var (
+	client      = &http.Client{Timeout: 10 * time.Second}
+	dispatching sync.Mutex
+)
+
+// RegisterDispatcher sends the queued deliveries every minute
+func RegisterDispatcher(app core.App) {
+	app.OnBeforeServe().Add(func(e *core.ServeEvent) error {
+		scheduler := cron.New()
+		scheduler.MustAdd("webhooks", "* * * * *", func() {
+			Dispatch(app)
+		})
+		scheduler.Start()
+		return nil
+	})
+}
+
+// Dispatch sends every queued delivery that is due. Deliveries are not sent twice if it is called while a previous call is still running
+func Dispatch(app core.App) {
+	if !dispatching.TryLock() {
+		return
+	}
+	defer dispatching.Unlock()
+
+	l := app.Logger()
+	now := types.NowDateTime().String()
+	var deliveries []struct {
+		Id       string `db:"id"`
+		Event    string `db:"event"`
+		Payload  string `db:"payload"`
+		Attempts int    `db:"attempts"`
+		Url      string `db:"url"`
+		Secret   string `db:"secret"`
+	}
+	err := app.Dao().DB().Select("d.id", "d.event", "d.payload", "d.attempts", "w.url", "w.secret").
+		From(names.TableWebhookDeliveries+" d").
+		InnerJoin(names.TableWebhooks+" w", dbx.NewExp("w.id = d.webhook")).
+		Where(dbx.HashExp{"d.status": deliveryStatusPending, "w.active": true}).
+		AndWhere(dbx.NewExp("d.next_attempt <= {:now}", dbx.Params{"now": now})).
+		OrderBy("d.next_attempt").
+		Limit(batchSize).
+		All(&deliveries)
+	if err != nil {
+		l.Error("Failed to load webhook deliveries", "error", err)
+		return
+	}
+	for _, delivery := range deliveries {
+		params := dbx.Params{"attempts": delivery.Attempts + 1}
+		err = send(delivery.Url, delivery.Secret, delivery.Id, delivery.Event, []byte(delivery.Payload))
+		if err == nil {
+			params["status"] = deliveryStatusDelivered
+			params["delivered"] = types.NowDateTime().String()
+			params["last_error"] = ""
+		} else {
+			l.Warn("Webhook delivery failed", "delivery", delivery.Id, "error", err)
+			params["last_error"] = err.Error()
+			if delivery.Attempts+1 >= maxAttempts {
+				params["status"] = deliveryStatusFailed
+			} else {
+				backoff := backoffBase * time.Duration(1<<delivery.Attempts)
+				nextAttempt, _ := types.ParseDateTime(time.Now().Add(backoff))
+				params["next_attempt"] = nextAttempt.String()
+			}
+		}
+		_, err = app.Dao().DB().Update(names.TableWebhookDeliveries, params, dbx.HashExp{"id": delivery.Id}).Execute()
+		if err != nil {
+			l.Error("Failed to update webhook delivery", "delivery", delivery.Id, "error", err)
+		}
+	}
+	cleanupBefore, _ := types.ParseDateTime(time.Now().Add(-deliveredRetention))
+	_, err = app.Dao().DB().Delete(names.TableWebhookDeliveries, dbx.And(
+		dbx.In("status", deliveryStatusDelivered, deliveryStatusFailed),
+		dbx.NewExp("created < {:before}", dbx.Params{"before": cleanupBefore.String()}))).Execute()
+	if err != nil {
+		l.Error("Failed to clean up webhook deliveries", "error", err)
+	}
+}
+
+func send(url string, secret string, deliveryId string, event string, payload []byte) error {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("create request error: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Event", event)
+	req.Header.Set("X-Webhook-Delivery", deliveryId)
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", "sha256="+sign(secret, timestamp, payload))
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request error: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("invalid status code: %v", resp.StatusCode)
+	}
+	return nil
+}
+
+// sign calculates the hex encoded HMAC-SHA256 of "<timestamp>.<payload>"
+func sign(secret string, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,96 @@
+// Package webhook delivers list events to external receivers.
+//
+// Webhooks are managed by admins through the webhooks collection. Every webhook subscribes to a set of events and
+// can be limited to a single list. Events are written into the webhook_deliveries collection inside the transaction
+// that caused them, the dispatcher sends them afterwards and retries failed deliveries with an exponential backoff.
+//
+// Every delivery is a POST request with the payload as JSON body. The X-Webhook-Signature header contains
+// sha256=<hex encoded HMAC-SHA256 of "<X-Webhook-Timestamp>.<body>" using the secret of the webhook>.
+package webhook
+
+import (
+	"AREDL/names"
+	"AREDL/util"
+	"encoding/json"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/daos"
+	"github.com/pocketbase/pocketbase/tools/list"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Events that webhooks can subscribe to
+const (
+	EventLevelPlaced        = "level_placed"
+	EventLevelMoved         = "level_moved"
+	EventLevelMovedToLegacy = "level_moved_to_legacy"
+	EventLevelRemoved       = "level_removed"
+	EventRecordAccepted     = "record_accepted"
+	EventSubmissionRejected = "submission_rejected"
+	EventPackChanged        = "pack_changed"
+	EventUserBanned         = "user_banned"
+)
+
+const (
+	deliveryStatusPending   = "pending"
+	deliveryStatusDelivered = "delivered"
+	deliveryStatusFailed    = "failed"
+)
+
+// Payload is the body of every delivery. List is empty for events that are not related to a list
+type Payload struct {
+	Event   string `json:"event"`
+	List    string `json:"list,omitempty"`
+	Created string `json:"created"`
+	Data    any    `json:"data"`
+}
+
+// Emit queues the event for every active webhook that subscribed to it.
+// It has to be called with the dao of the transaction that caused the event, so nothing is sent if it gets rolled back.
+// Events without a list are sent to every webhook, the others only to webhooks without a list or with the same list
+func Emit(dao *daos.Dao, listName string, event string, data any) error {
+	var webhooks []struct {
+		Id     string                  `db:"id"`
+		Events types.JsonArray[string] `db:"events"`
+		List   string                  `db:"list"`
+	}
+	err := dao.DB().Select("id", "events", "list").
+		From(names.TableWebhooks).
+		Where(dbx.HashExp{"active": true}).
+		All(&webhooks)
+	if err != nil {
+		return util.NewErrorResponse(err, "Failed to load webhooks")
+	}
+	now := types.NowDateTime().String()
+	var payload []byte
+	for _, webhook := range webhooks {
+		if !list.ExistInSlice(event, webhook.Events) {
+			continue
+		}
+		if listName != "" && webhook.List != "" && webhook.List != listName {
+			continue
+		}
+		if payload == nil {
+			payload, err = json.Marshal(Payload{
+				Event:   event,
+				List:    listName,
+				Created: now,
+				Data:    data,
+			})
+			if err != nil {
+				return util.NewErrorResponse(err, "Failed to encode webhook payload")
+			}
+		}
+		_, err = dao.DB().Insert(names.TableWebhookDeliveries, dbx.Params{
+			"webhook":      webhook.Id,
+			"event":        event,
+			"payload":      string(payload),
+			"status":       deliveryStatusPending,
+			"attempts":     0,
+			"next_attempt": now,
+		}).Execute()
+		if err != nil {
+			return util.NewErrorResponse(err, "Failed to queue webhook delivery")
+		}
+	}
+	return nil
+}